@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/sebaactis/wallet-go-api/internal/authz"
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
+	"github.com/sebaactis/wallet-go-api/internal/entities/session"
+	"github.com/sebaactis/wallet-go-api/internal/entities/token"
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/entities/wallet"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+	"github.com/sebaactis/wallet-go-api/internal/platform/config"
+	"github.com/sebaactis/wallet-go-api/internal/platform/database"
+	"github.com/sebaactis/wallet-go-api/internal/platform/filestore"
+	"github.com/sebaactis/wallet-go-api/internal/validation"
+)
+
+const usage = `uso: admin <comando> [args]
+
+comandos:
+  set-role EMAIL ROL
+                    asigna el rol (user, support, admin) y cierra las sesiones del usuario
+  set-rate BASE QUOTE TIPO
+                    carga el tipo de cambio medio (unidades de QUOTE por 1 BASE) en fx_rates
+  set-fee -type withdraw|transfer -currency MONEDA -kind flat|percentage|tiered [opciones]
+                    carga o reemplaza la comisión del tipo y la moneda. Opciones: -flat MONTO,
+                    -bps PUNTOS_BASICOS, -min MONTO, -max MONTO, -tiers "HASTA:FIJO:BPS,..."
+                    (HASTA vacío = sin tope) y -disable para dejarla sin efecto
+  set-kyc EMAIL NIVEL
+                    asigna el nivel de KYC del usuario, que define sus límites por nivel
+  set-limit -scope account|user|tier -id ID -currency MONEDA -kind TIPO -value VALOR [-delete]
+                    carga o quita un límite. TIPO: single, daily_volume, monthly_volume (VALOR es
+                    un monto) o daily_count (VALOR es una cantidad). Con -scope tier, ID es el nivel
+  reconcile [-freeze]
+                    compara el saldo de cada cuenta con su ledger; sale con código 1 si hay
+                    diferencias. Con -freeze congela las cuentas de clientes afectadas
+  unfreeze ACCOUNT_ID
+                    libera una cuenta congelada
+  outbox-dead       lista los eventos del outbox que agotaron sus intentos
+  outbox-retry EVENT_ID
+                    vuelve a encolar un evento muerto del outbox, con los intentos en cero
+  statements-generate YYYY-MM
+                    genera los extractos mensuales en PDF que falten de un mes cerrado
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	_ = godotenv.Load()
+	cfg := config.Load()
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("open db %v", err)
+	}
+
+	ctx := context.Background()
+
+	validator := validation.NewValidator()
+	tokenService := token.NewService(token.NewRepository(db), validator)
+	userService := user.NewService(user.NewRepository(db), tokenService, validator)
+	sessionService := session.NewService(session.NewRepository(db))
+	walletService := wallet.NewService(db, nil, cfg.FXSpreadBps, cfg.FXQuoteTTL)
+
+	switch args[0] {
+	case "set-role":
+		if len(args) != 3 {
+			log.Fatal("set-role: uso set-role EMAIL ROL")
+		}
+
+		u, err := userService.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(args[1])))
+		if err != nil {
+			log.Fatalf("set-role: %v", err)
+		}
+
+		if err := userService.SetRole(ctx, u.ID, authz.Role(args[2])); err != nil {
+			log.Fatalf("set-role: %v", err)
+		}
+
+		if err := sessionService.EndAllForUser(ctx, u.ID); err != nil {
+			log.Fatalf("set-role: %v", err)
+		}
+
+		fmt.Printf("%s ahora es %s\n", u.Email, args[2])
+
+	case "set-rate":
+		if len(args) != 4 {
+			log.Fatal("set-rate: uso set-rate BASE QUOTE TIPO")
+		}
+
+		if err := wallet.NewDBRateProvider(db).SetRate(ctx, args[1], args[2], args[3]); err != nil {
+			log.Fatalf("set-rate: %v", err)
+		}
+
+		fmt.Printf("1 %s = %s %s\n", strings.ToUpper(args[1]), args[3], strings.ToUpper(args[2]))
+
+	case "set-fee":
+		fs := flag.NewFlagSet("set-fee", flag.ExitOnError)
+		txType := fs.String("type", "", "tipo de transacción: withdraw o transfer")
+		currency := fs.String("currency", "", "moneda ISO 4217")
+		kind := fs.String("kind", "", "flat, percentage o tiered")
+		flat := fs.String("flat", "0", "monto fijo")
+		bps := fs.Int64("bps", 0, "porcentaje en puntos básicos (150 = 1,50 %)")
+		minFee := fs.String("min", "0", "comisión mínima (0 = sin mínimo)")
+		maxFee := fs.String("max", "0", "comisión máxima (0 = sin máximo)")
+		tiers := fs.String("tiers", "", "tramos HASTA:FIJO:BPS separados por coma")
+		disable := fs.Bool("disable", false, "dejar el esquema inactivo")
+		_ = fs.Parse(args[1:])
+
+		cur := strings.ToUpper(strings.TrimSpace(*currency))
+		if cur == "" {
+			log.Fatal("set-fee: falta -currency")
+		}
+		schedule := &wallet.FeeSchedule{
+			TxType:        *txType,
+			Currency:      cur,
+			Kind:          *kind,
+			FlatAmount:    parseMoney("set-fee", *flat, cur),
+			PercentageBps: *bps,
+			MinFee:        parseMoney("set-fee", *minFee, cur),
+			MaxFee:        parseMoney("set-fee", *maxFee, cur),
+			Active:        !*disable,
+		}
+
+		if *tiers != "" {
+			for _, raw := range strings.Split(*tiers, ",") {
+				parts := strings.Split(strings.TrimSpace(raw), ":")
+				if len(parts) != 3 {
+					log.Fatalf("set-fee: tramo inválido %q", raw)
+				}
+
+				tier := wallet.FeeTier{FlatAmount: parseMoney("set-fee", parts[1], cur)}
+				if parts[0] != "" {
+					upTo := parseMoney("set-fee", parts[0], cur)
+					tier.UpTo = &upTo
+				}
+				if tier.PercentageBps, err = strconv.ParseInt(parts[2], 10, 64); err != nil {
+					log.Fatalf("set-fee: puntos básicos inválidos %q", parts[2])
+				}
+
+				schedule.Tiers = append(schedule.Tiers, tier)
+			}
+		}
+
+		if err := walletService.SetFeeSchedule(ctx, schedule); err != nil {
+			log.Fatalf("set-fee: %v", err)
+		}
+
+		state := "activa"
+		if !schedule.Active {
+			state = "inactiva"
+		}
+		fmt.Printf("comisión %s %s (%s) %s\n", schedule.TxType, schedule.Currency, schedule.Kind, state)
+
+	case "set-kyc":
+		if len(args) != 3 {
+			log.Fatal("set-kyc: uso set-kyc EMAIL NIVEL")
+		}
+
+		u, err := userService.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(args[1])))
+		if err != nil {
+			log.Fatalf("set-kyc: %v", err)
+		}
+
+		tier, err := strconv.Atoi(args[2])
+		if err != nil {
+			log.Fatalf("set-kyc: nivel inválido %q", args[2])
+		}
+
+		if err := userService.SetKYCTier(ctx, u.ID, tier); err != nil {
+			log.Fatalf("set-kyc: %v", err)
+		}
+
+		fmt.Printf("%s ahora tiene nivel de KYC %d\n", u.Email, tier)
+
+	case "set-limit":
+		fs := flag.NewFlagSet("set-limit", flag.ExitOnError)
+		scope := fs.String("scope", "", "account, user o tier")
+		id := fs.Uint("id", 0, "id de la cuenta o del usuario, o nivel de KYC")
+		currency := fs.String("currency", "", "moneda ISO 4217")
+		kind := fs.String("kind", "", "single, daily_volume, monthly_volume o daily_count")
+		value := fs.String("value", "", "monto o, en daily_count, cantidad")
+		remove := fs.Bool("delete", false, "quitar el límite")
+		_ = fs.Parse(args[1:])
+
+		cur := strings.ToUpper(strings.TrimSpace(*currency))
+		if cur == "" {
+			log.Fatal("set-limit: falta -currency")
+		}
+
+		if *remove {
+			if err := walletService.DeleteLimit(ctx, *scope, *id, cur, *kind); err != nil {
+				log.Fatalf("set-limit: %v", err)
+			}
+			fmt.Printf("límite %s %s %d %s quitado\n", *kind, *scope, *id, cur)
+			break
+		}
+
+		l := &wallet.Limit{Scope: *scope, ScopeID: *id, Currency: cur, Kind: *kind}
+		if l.Kind == wallet.LimitDailyCount {
+			if l.Value, err = strconv.ParseInt(*value, 10, 64); err != nil {
+				log.Fatalf("set-limit: cantidad inválida %q", *value)
+			}
+		} else {
+			l.Value = parseMoney("set-limit", *value, cur)
+		}
+
+		if err := walletService.SetLimit(ctx, l); err != nil {
+			log.Fatalf("set-limit: %v", err)
+		}
+
+		fmt.Printf("límite %s %s %d %s: %s\n", l.Kind, l.Scope, l.ScopeID, l.Currency, *value)
+
+	case "reconcile":
+		fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+		freeze := fs.Bool("freeze", false, "congelar las cuentas de clientes con diferencias")
+		_ = fs.Parse(args[1:])
+
+		report, err := walletService.Reconcile(ctx, *freeze)
+		if err != nil {
+			log.Fatalf("reconcile: %v", err)
+		}
+
+		printReport(report)
+		if !report.OK() {
+			os.Exit(1)
+		}
+
+	case "unfreeze":
+		if len(args) != 2 {
+			log.Fatal("unfreeze: uso unfreeze ACCOUNT_ID")
+		}
+
+		id, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil || id == 0 {
+			log.Fatalf("unfreeze: id inválido %q", args[1])
+		}
+
+		if err := walletService.Unfreeze(ctx, uint(id)); err != nil {
+			log.Fatalf("unfreeze: %v", err)
+		}
+
+		fmt.Printf("cuenta %d liberada\n", id)
+
+	case "outbox-dead":
+		events, err := outbox.NewRepository(db).Dead(ctx, 100)
+		if err != nil {
+			log.Fatalf("outbox-dead: %v", err)
+		}
+
+		for _, e := range events {
+			fmt.Printf("%d\t%s\t%s %d\t%d intentos\t%s\n", e.ID, e.Type, e.AggregateType, e.AggregateID, e.Attempts, e.LastError)
+		}
+
+	case "outbox-retry":
+		if len(args) != 2 {
+			log.Fatal("outbox-retry: uso outbox-retry EVENT_ID")
+		}
+
+		id, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil || id == 0 {
+			log.Fatalf("outbox-retry: id inválido %q", args[1])
+		}
+
+		if err := outbox.NewRepository(db).Requeue(ctx, uint(id)); err != nil {
+			log.Fatalf("outbox-retry: %v", err)
+		}
+
+		fmt.Printf("evento %d encolado\n", id)
+
+	case "statements-generate":
+		if len(args) != 2 {
+			log.Fatal("statements-generate: uso statements-generate YYYY-MM")
+		}
+
+		month, err := time.ParseInLocation("2006-01", args[1], time.Local)
+		if err != nil {
+			log.Fatalf("statements-generate: mes inválido %q", args[1])
+		}
+
+		store, err := filestore.New(cfg.FileStoreDir)
+		if err != nil {
+			log.Fatalf("statements-generate: %v", err)
+		}
+
+		n, err := wallet.NewStatementArchive(walletService, store).GenerateMonth(ctx, month)
+		if err != nil {
+			log.Fatalf("statements-generate: %v (%d generados)", err, n)
+		}
+
+		fmt.Printf("%d extractos generados para %s\n", n, args[1])
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func parseMoney(cmd, s, currency string) int64 {
+	m, err := money.Parse(strings.TrimSpace(s), currency)
+	if err != nil || m.Amount < 0 {
+		log.Fatalf("%s: monto inválido %q para %s", cmd, s, currency)
+	}
+	return m.Amount
+}
+
+func printReport(r *wallet.ReconciliationReport) {
+	fmt.Printf("reconciliación %s: %d cuentas, %d con diferencias, %d transacciones desbalanceadas\n",
+		r.CheckedAt.UTC().Format("2006-01-02 15:04:05"), r.AccountsChecked, len(r.Discrepancies), len(r.UnbalancedTransactions))
+
+	for _, d := range r.Discrepancies {
+		kind := fmt.Sprintf("usuario %d", d.UserID)
+		if d.SystemCode != nil {
+			kind = "interna " + *d.SystemCode
+		}
+
+		fmt.Printf("  cuenta %d (%s, %s): saldo %s, ledger %s, diferencia %s",
+			d.AccountID, kind, d.Currency,
+			money.Format(d.Balance, d.Currency), money.Format(d.LedgerBalance, d.Currency), money.Format(d.Difference(), d.Currency))
+		if d.Frozen {
+			fmt.Print(" [congelada]")
+		}
+		fmt.Println()
+
+		if len(d.Transactions) > 0 {
+			fmt.Printf("    transacciones: %v\n", d.Transactions)
+		} else {
+			fmt.Println("    el ledger es consistente: el saldo se modificó por fuera de él")
+		}
+	}
+
+	if len(r.UnbalancedTransactions) > 0 {
+		fmt.Printf("  transacciones desbalanceadas: %v\n", r.UnbalancedTransactions)
+	}
+}
@@ -6,20 +6,29 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sebaactis/wallet-go-api/internal/auth"
 	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/entities/idempotency"
+	"github.com/sebaactis/wallet-go-api/internal/entities/mfa"
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
+	"github.com/sebaactis/wallet-go-api/internal/entities/schedule"
+	"github.com/sebaactis/wallet-go-api/internal/entities/session"
 	"github.com/sebaactis/wallet-go-api/internal/entities/token"
 	"github.com/sebaactis/wallet-go-api/internal/entities/user"
 	"github.com/sebaactis/wallet-go-api/internal/entities/wallet"
+	"github.com/sebaactis/wallet-go-api/internal/entities/webhook"
 	httpx "github.com/sebaactis/wallet-go-api/internal/http"
 	"github.com/sebaactis/wallet-go-api/internal/httpmw"
 	"github.com/sebaactis/wallet-go-api/internal/platform/config"
 	"github.com/sebaactis/wallet-go-api/internal/platform/database"
+	"github.com/sebaactis/wallet-go-api/internal/platform/filestore"
 	"github.com/sebaactis/wallet-go-api/internal/validation"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -35,6 +44,11 @@ func main() {
 		log.Fatalf("migrate: %v", err)
 	}
 
+	fileStore, err := filestore.New(cfg.FileStoreDir)
+	if err != nil {
+		log.Fatalf("file store: %v", err)
+	}
+
 	validator := validation.NewValidator()
 	rateLimiter := httpmw.NewRateLimiter(10, time.Minute*1)
 	jwt := auth.NewJWT()
@@ -43,33 +57,52 @@ func main() {
 	userRepo := user.NewRepository(db)
 	accountRepo := account.NewRepository(db)
 	tokenRepo := token.NewRepository(db)
+	sessionRepo := session.NewRepository(db)
+	mfaRepo := mfa.NewRepository(db)
+	scheduleRepo := schedule.NewRepository(db)
+	idempotencyRepo := idempotency.NewRepository(db)
+	webhookRepo := webhook.NewRepository(db)
 
 	// Servicios
-	
+
 	accountService := account.NewService(accountRepo)
-	walletService := wallet.NewService(db)
+	walletService := wallet.NewService(db, fxRates(cfg, db), cfg.FXSpreadBps, cfg.FXQuoteTTL)
 	tokenService := token.NewService(tokenRepo, validator)
+	sessionService := session.NewService(sessionRepo)
+	mfaService := mfa.NewService(mfaRepo)
 	userService := user.NewService(userRepo, tokenService, validator)
+	scheduleService := schedule.NewService(scheduleRepo, walletService, accountRepo, cfg.ScheduleMaxAttempts, cfg.ScheduleRetryDelay)
+	idempotencyService := idempotency.NewService(idempotencyRepo, cfg.IdempotencyTTL)
+	statementArchive := wallet.NewStatementArchive(walletService, fileStore)
+	webhookService := webhook.NewService(webhookRepo, cfg.WebhookTimeout, cfg.WebhookMaxAttempts, cfg.WebhookRetryDelay, cfg.WebhookDisableAfter)
+	dispatcher := outbox.NewDispatcher(db, cfg.OutboxMaxAttempts, cfg.OutboxRetryDelay)
+	dispatcher.Subscribe("webhooks", webhookService.Enqueue)
 
 	// Handlers
 
 	userHandler := user.NewHTTPHandler(userService)
 	accountHandler := account.NewHTTPHandler(accountService)
-	walletHandler := wallet.NewHTTPHandler(walletService)
-	authHandler := auth.NewHTTPHandler(userService, tokenService,jwt, validator)
+	walletHandler := wallet.NewHTTPHandler(walletService, statementArchive, accountRepo, validator)
+	authHandler := auth.NewHTTPHandler(userService, tokenService, sessionService, mfaService, jwt, validator)
 	tokenHandler := token.NewHTTPHandler(tokenService)
-	authMiddleware := httpmw.NewAuthMiddleware(jwt, userService, tokenService)
+	scheduleHandler := schedule.NewHTTPHandler(scheduleService, accountRepo, validator)
+	webhookHandler := webhook.NewHTTPHandler(webhookService, validator)
+	authMiddleware := httpmw.NewAuthMiddleware(jwt, userService, tokenService, sessionService)
+	idempotencyMiddleware := httpmw.NewIdempotency(idempotencyService)
 
 	r := httpx.NewRouter(
 		httpx.Deps{
-			UserHandler:    userHandler,
-			AccountHandler: accountHandler,
-			WalletHandler:  walletHandler,
-			Validator:      validator,
-			RateLimiter:    rateLimiter,
-			AuthHandler:    authHandler,
-			AuthMiddleWare: authMiddleware,
-			TokensHandler: tokenHandler,
+			UserHandler:     userHandler,
+			AccountHandler:  accountHandler,
+			WalletHandler:   walletHandler,
+			Validator:       validator,
+			RateLimiter:     rateLimiter,
+			AuthHandler:     authHandler,
+			AuthMiddleWare:  authMiddleware,
+			TokensHandler:   tokenHandler,
+			ScheduleHandler: scheduleHandler,
+			WebhookHandler:  webhookHandler,
+			Idempotency:     idempotencyMiddleware,
 		},
 	)
 
@@ -81,6 +114,18 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Procesos en segundo plano: se cortan con bgCancel al apagar.
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	defer bgCancel()
+
+	var bg sync.WaitGroup
+	bg.Go(func() { walletService.RunHoldSweeper(bgCtx, cfg.HoldSweepInterval) })
+	bg.Go(func() { scheduleService.Run(bgCtx, cfg.SchedulerInterval) })
+	bg.Go(func() { idempotencyService.RunPurger(bgCtx, cfg.IdempotencyPurgeInterval) })
+	bg.Go(func() { dispatcher.Run(bgCtx, cfg.OutboxInterval) })
+	bg.Go(func() { webhookService.RunSender(bgCtx, cfg.WebhookInterval) })
+	bg.Go(func() { statementArchive.Run(bgCtx, cfg.MonthlyStatementsInterval) })
+
 	go func() {
 		log.Printf("API escuchando en %s", cfg.HTTPAddr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -93,6 +138,8 @@ func main() {
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
 
+	bgCancel()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 
 	defer cancel()
@@ -101,6 +148,32 @@ func main() {
 		log.Printf("Shutdown error: %v", err)
 	}
 
+	// Esperamos que los jobs terminen la pasada en curso (una transferencia programada a medio
+	// ejecutar no se corta), con el mismo límite que el apagado del server.
+	done := make(chan struct{})
+	go func() {
+		bg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Println("jobs en segundo plano no terminaron a tiempo")
+	}
+
 	log.Println("Apago limpio")
 
 }
+
+// fxRates elige el proveedor de tipos de cambio: un archivo fijo para desarrollo o la tabla fx_rates.
+func fxRates(cfg config.Config, db *gorm.DB) wallet.FXRateProvider {
+	if cfg.FXRatesFile == "" {
+		return wallet.NewDBRateProvider(db)
+	}
+
+	p, err := wallet.NewFileRateProvider(cfg.FXRatesFile)
+	if err != nil {
+		log.Fatalf("fx rates: %v", err)
+	}
+	return p
+}
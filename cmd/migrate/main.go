@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/joho/godotenv"
+	"github.com/sebaactis/wallet-go-api/internal/platform/config"
+	"github.com/sebaactis/wallet-go-api/internal/platform/database"
+	"github.com/sebaactis/wallet-go-api/internal/platform/migrate"
+)
+
+const usage = `uso: migrate <comando> [args]
+
+comandos:
+  up                aplica todas las migraciones pendientes
+  down N            revierte las últimas N migraciones (por defecto 1)
+  status            lista migraciones y su estado
+  force V applied|pending
+                    resuelve la migración V que quedó sucia (falló a la mitad) una vez
+                    arreglado el esquema a mano: la marca aplicada o la deja pendiente
+  create [-dir D] NOMBRE
+                    crea el par up/down vacío para cada dialecto
+`
+
+func main() {
+	dir := flag.String("dir", "internal/platform/migrate/migrations", "directorio de migraciones (solo para create)")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage); flag.PrintDefaults() }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if args[0] == "create" {
+		fs := flag.NewFlagSet("create", flag.ExitOnError)
+		fs.StringVar(dir, "dir", *dir, "directorio de migraciones")
+		_ = fs.Parse(args[1:])
+		if fs.NArg() < 1 {
+			log.Fatal("create: falta el nombre")
+		}
+		files, err := migrate.Create(*dir, fs.Arg(0))
+		if err != nil {
+			log.Fatalf("create: %v", err)
+		}
+		for _, f := range files {
+			fmt.Println(f)
+		}
+		return
+	}
+
+	_ = godotenv.Load()
+	cfg := config.Load()
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("open db %v", err)
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		if err := database.Migrate(db); err != nil {
+			log.Fatalf("up: %v", err)
+		}
+
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if n, err = strconv.Atoi(args[1]); err != nil || n <= 0 {
+				log.Fatalf("down: N debe ser un entero positivo")
+			}
+		}
+
+		m, err := migrate.New(db)
+		if err != nil {
+			log.Fatalf("down: %v", err)
+		}
+		if _, err := m.Down(ctx, n); err != nil {
+			log.Fatalf("down: %v", err)
+		}
+
+	case "status":
+		m, err := migrate.New(db)
+		if err != nil {
+			log.Fatalf("status: %v", err)
+		}
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatalf("status: %v", err)
+		}
+
+		for _, s := range statuses {
+			state := "pending"
+			switch {
+			case s.Dirty:
+				state = "DIRTY (failed partway, see force)"
+			case s.Missing:
+				state = "applied (file missing)"
+			case s.Modified:
+				state = "applied (MODIFIED)"
+			case s.Applied:
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, state)
+		}
+
+	case "force":
+		if len(args) != 3 || (args[2] != "applied" && args[2] != "pending") {
+			log.Fatal("force: uso: force V applied|pending")
+		}
+		v, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || v <= 0 {
+			log.Fatalf("force: V debe ser un entero positivo")
+		}
+
+		m, err := migrate.New(db)
+		if err != nil {
+			log.Fatalf("force: %v", err)
+		}
+		if err := m.Force(ctx, v, args[2] == "applied"); err != nil {
+			log.Fatalf("force: %v", err)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
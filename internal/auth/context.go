@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/sebaactis/wallet-go-api/internal/authz"
+)
+
+type ctxKey string
+
+const ctxSessionID ctxKey = "auth.session_id"
+
+// WithIdentity guarda en el contexto el usuario, el rol y la sesión del access token ya validado.
+func WithIdentity(ctx context.Context, claims *Claims) context.Context {
+	ctx = authz.WithSubject(ctx, authz.Subject{UserID: claims.UserID(), Role: claims.Role})
+	return context.WithValue(ctx, ctxSessionID, claims.SessionID)
+}
+
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	s, ok := authz.SubjectFromContext(ctx)
+	return s.UserID, ok
+}
+
+func SessionIDFromContext(ctx context.Context) string {
+	sid, _ := ctx.Value(ctxSessionID).(string)
+	return sid
+}
@@ -12,6 +12,23 @@ type LoginResponse struct {
 	RefreshToken string `json:"refreshToken"`
 }
 
+// MFAChallengeResponse es la respuesta del primer paso del login cuando el usuario tiene 2FA:
+// todavía no hay sesión, solo un token mfa_pending para canjear junto con el código.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+	ExpiresIn   int    `json:"expiresIn"`
+}
+
+type LoginMFARequest struct {
+	MFAToken string `json:"mfaToken" validate:"required"`
+	Code     string `json:"code" validate:"required,min=6,max=32"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
 type RecoveryPasswordRequest struct {
 	Email string `json:"email"`
 }
@@ -30,4 +47,5 @@ const (
 	TokenTypeAccess        TokenType = "access"
 	TokenTypeRefresh       TokenType = "refresh"
 	TokenTypeResetPassword TokenType = "resetPassword"
+	TokenTypeMFAPending    TokenType = "mfa_pending"
 )
@@ -5,10 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/sebaactis/wallet-go-api/internal/entities/mfa"
+	"github.com/sebaactis/wallet-go-api/internal/entities/session"
 	"github.com/sebaactis/wallet-go-api/internal/entities/token"
 	"github.com/sebaactis/wallet-go-api/internal/entities/user"
 	"github.com/sebaactis/wallet-go-api/internal/httputil"
@@ -19,12 +24,14 @@ import (
 type HTTPHandler struct {
 	users     *user.Service
 	tokens    *token.Service
+	sessions  *session.Service
+	mfa       *mfa.Service
 	jwt       *JWT
 	validator validation.StructValidator
 }
 
-func NewHTTPHandler(users *user.Service, tokens *token.Service, jwt *JWT, validator validation.StructValidator) *HTTPHandler {
-	return &HTTPHandler{users: users, tokens: tokens, jwt: jwt, validator: validator}
+func NewHTTPHandler(users *user.Service, tokens *token.Service, sessions *session.Service, mfa *mfa.Service, jwt *JWT, validator validation.StructValidator) *HTTPHandler {
+	return &HTTPHandler{users: users, tokens: tokens, sessions: sessions, mfa: mfa, jwt: jwt, validator: validator}
 }
 
 func (h *HTTPHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -40,16 +47,221 @@ func (h *HTTPHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokens, err := h.generateTokens(r.Context(), user)
+	mfaEnabled, err := h.mfa.Enabled(r.Context(), user.ID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "internal error", nil)
+		return
+	}
+
+	if mfaEnabled {
+		h.startMFAChallenge(w, r, user)
+		return
+	}
+
+	h.completeLogin(w, r, user)
+}
+
+// completeLogin abre la sesión: emite el par access/refresh de una familia nueva y setea las cookies.
+func (h *HTTPHandler) completeLogin(w http.ResponseWriter, r *http.Request, user *user.User) {
+	familyID := NewTokenID()
+
+	tokens, err := h.generateTokens(r.Context(), user, familyID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "cannot generate tokens", nil)
+		return
+	}
+
+	if _, err := h.sessions.Start(r.Context(), user.ID, familyID, r.UserAgent(), clientIP(r), time.Now().Add(h.jwt.GetTTL(TokenTypeRefresh))); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "cannot start session", nil)
+		return
+	}
+
+	h.setTokenCookie(w, "accessToken", tokens.AccessToken, TokenTypeAccess)
+	h.setTokenCookie(w, "refreshToken", tokens.RefreshToken, TokenTypeRefresh)
+	h.respondWithTokens(w, user, tokens)
+}
+
+// POST /v1/auth/refresh
+func (h *HTTPHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	refreshToken := h.readRefreshToken(r)
+	if refreshToken == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "refresh token required", nil)
+		return
+	}
+
+	claims, err := h.jwt.ParseClaims(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		h.clearCookie(w, "refreshToken")
+		httputil.WriteError(w, http.StatusUnauthorized, "session expired, please login again", nil)
+		return
+	}
+
+	previous, err := h.tokens.Rotate(r.Context(), claims.ID)
+	if err != nil {
+		h.clearCookie(w, "accessToken")
+		h.clearCookie(w, "refreshToken")
+
+		if errors.Is(err, token.ErrTokenReused) {
+			if claims.SessionID != "" {
+				_ = h.sessions.End(r.Context(), claims.SessionID)
+			}
+			httputil.WriteError(w, http.StatusUnauthorized, "refresh token already used, session revoked", nil)
+			return
+		}
+		httputil.WriteError(w, http.StatusUnauthorized, "invalid refresh token", nil)
+		return
+	}
+
+	user, err := h.users.GetByID(r.Context(), claims.UserID())
+	if err != nil {
+		httputil.WriteError(w, http.StatusUnauthorized, "invalid refresh token", nil)
+		return
+	}
+
+	familyID := previous.FamilyID
+	if familyID == "" {
+		familyID = NewTokenID()
+	}
+
+	tokens, err := h.generateTokens(r.Context(), user, familyID)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "cannot generate tokens", nil)
 		return
 	}
+
+	if err := h.sessions.Extend(r.Context(), familyID, time.Now().Add(h.jwt.GetTTL(TokenTypeRefresh))); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "cannot extend session", nil)
+		return
+	}
+
 	h.setTokenCookie(w, "accessToken", tokens.AccessToken, TokenTypeAccess)
 	h.setTokenCookie(w, "refreshToken", tokens.RefreshToken, TokenTypeRefresh)
 	h.respondWithTokens(w, user, tokens)
 }
 
+// POST /v1/auth/logout
+// Revoca la sesión actual (access y refresh de la misma familia) aunque el access ya haya vencido.
+func (h *HTTPHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	for _, c := range []struct {
+		name      string
+		tokenType TokenType
+	}{
+		{"accessToken", TokenTypeAccess},
+		{"refreshToken", TokenTypeRefresh},
+	} {
+		cookie, err := r.Cookie(c.name)
+		if err != nil {
+			continue
+		}
+
+		claims, err := h.jwt.ParseClaims(cookie.Value, c.tokenType)
+		if err != nil {
+			continue
+		}
+
+		if err := h.tokens.RevokeSession(r.Context(), claims.ID); err != nil && !errors.Is(err, token.ErrTokenNotFound) {
+			httputil.WriteError(w, http.StatusInternalServerError, "cannot revoke session", nil)
+			return
+		}
+
+		if claims.SessionID != "" {
+			if err := h.sessions.End(r.Context(), claims.SessionID); err != nil {
+				httputil.WriteError(w, http.StatusInternalServerError, "cannot revoke session", nil)
+				return
+			}
+		}
+	}
+
+	h.clearCookie(w, "accessToken")
+	h.clearCookie(w, "refreshToken")
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"message": "logged out"})
+}
+
+// POST /v1/auth/logout-all
+// Revoca todos los tokens del usuario, en cualquier dispositivo.
+func (h *HTTPHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	if err := h.tokens.RevokeAllForUser(r.Context(), userID); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "cannot revoke sessions", nil)
+		return
+	}
+
+	if err := h.sessions.EndAllForUser(r.Context(), userID); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "cannot revoke sessions", nil)
+		return
+	}
+
+	h.clearCookie(w, "accessToken")
+	h.clearCookie(w, "refreshToken")
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"message": "logged out from all sessions"})
+}
+
+// GET /v1/me/sessions
+// Lista las sesiones activas del usuario; la del request actual viene marcada como current.
+func (h *HTTPHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	sessions, err := h.sessions.ListActive(r.Context(), userID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "cannot list sessions", nil)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, session.ToResponseMany(sessions, SessionIDFromContext(r.Context())))
+}
+
+// DELETE /v1/me/sessions/{id}
+// Cierra una sesión puntual (por ejemplo, un dispositivo perdido) revocando toda su familia de tokens.
+func (h *HTTPHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid id", nil)
+		return
+	}
+
+	sess, err := h.sessions.Get(r.Context(), userID, uint(id))
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			httputil.WriteError(w, http.StatusNotFound, err.Error(), nil)
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, "cannot revoke session", nil)
+		return
+	}
+
+	if err := h.tokens.RevokeFamily(r.Context(), sess.FamilyID); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "cannot revoke session", nil)
+		return
+	}
+
+	if err := h.sessions.End(r.Context(), sess.FamilyID); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "cannot revoke session", nil)
+		return
+	}
+
+	if sess.FamilyID == SessionIDFromContext(r.Context()) {
+		h.clearCookie(w, "accessToken")
+		h.clearCookie(w, "refreshToken")
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *HTTPHandler) RecoveryPasswordRequest(w http.ResponseWriter, r *http.Request) {
 	var req RecoveryPasswordRequest
 
@@ -101,12 +313,18 @@ func (h *HTTPHandler) UpdatePasswordByRecovery(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	if _, _, _, err := h.jwt.ParseResetPassword(req.Token); err != nil {
+	claims, err := h.jwt.ParseClaims(req.Token, TokenTypeResetPassword)
+	if err != nil {
 		httputil.WriteError(w, http.StatusUnauthorized, err.Error(), nil)
 		return
 	}
 
-	userRecovery, err := h.users.UpdatePasswordByRecovery(r.Context(), (req))
+	if revoked, err := h.tokens.IsRevoked(r.Context(), claims.ID, claims.Expiry()); err != nil || revoked {
+		httputil.WriteError(w, http.StatusUnauthorized, "token revoked", nil)
+		return
+	}
+
+	userRecovery, err := h.users.UpdatePasswordByRecovery(r.Context(), req, claims.ID)
 
 	if err != nil {
 		httputil.WriteError(w, http.StatusBadRequest, err.Error(), nil)
@@ -117,9 +335,6 @@ func (h *HTTPHandler) UpdatePasswordByRecovery(w http.ResponseWriter, r *http.Re
 
 }
 
-
-
-
 // ==================== MÉTODOS PRIVADOS ====================
 
 func (h *HTTPHandler) parseLoginRequest(r *http.Request) (*LoginRequest, error) {
@@ -156,31 +371,17 @@ func (h *HTTPHandler) authenticateUser(ctx context.Context, req *LoginRequest) (
 	return user, nil
 }
 
-func (h *HTTPHandler) generateTokens(ctx context.Context, user *user.User) (*TokenPair, error) {
-	accessToken, err := h.jwt.Sign(user.ID, user.Email, TokenTypeAccess)
+func (h *HTTPHandler) generateTokens(ctx context.Context, user *user.User, familyID string) (*TokenPair, error) {
+	accessToken, err := h.issueToken(ctx, user, TokenTypeAccess, familyID)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := h.jwt.Sign(user.ID, user.Email, TokenTypeRefresh)
+	refreshToken, err := h.issueToken(ctx, user, TokenTypeRefresh, familyID)
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err = h.tokens.Create(ctx, &token.TokenRequest{
-		TokenType: string(TokenTypeAccess),
-		Token:     accessToken,
-	}); err != nil {
-		return nil, err
-	}
-
-	if _, err = h.tokens.Create(ctx, &token.TokenRequest{
-		TokenType: string(TokenTypeRefresh),
-		Token:     refreshToken,
-	}); err != nil {
-		return nil, err
-	}
-
 	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -189,19 +390,32 @@ func (h *HTTPHandler) generateTokens(ctx context.Context, user *user.User) (*Tok
 
 func (h *HTTPHandler) generateTokenRecovery(ctx context.Context, user *user.User) (*string, error) {
 
-	recoveryToken, err := h.jwt.Sign(user.ID, user.Email, TokenTypeResetPassword)
+	recoveryToken, err := h.issueToken(ctx, user, TokenTypeResetPassword, "")
 	if err != nil {
 		return nil, err
 	}
 
+	return &recoveryToken, nil
+}
+
+// issueToken firma el token y lo registra por jti para poder revocarlo después.
+func (h *HTTPHandler) issueToken(ctx context.Context, user *user.User, tokenType TokenType, familyID string) (string, error) {
+	signed, err := h.jwt.Issue(user.ID, user.Email, user.Role, tokenType, familyID)
+	if err != nil {
+		return "", err
+	}
+
 	if _, err = h.tokens.Create(ctx, &token.TokenRequest{
-		TokenType: string(TokenTypeResetPassword),
-		Token:     recoveryToken,
+		UserID:    user.ID,
+		TokenType: string(tokenType),
+		FamilyID:  familyID,
+		JTI:       signed.ID,
+		ExpiresAt: signed.ExpiresAt,
 	}); err != nil {
-		return nil, err
+		return "", err
 	}
 
-	return &recoveryToken, nil
+	return signed.Token, nil
 }
 
 func (h *HTTPHandler) handleLoginError(w http.ResponseWriter, ctx context.Context, err error, user *user.User) {
@@ -234,6 +448,40 @@ func (h *HTTPHandler) setTokenCookie(w http.ResponseWriter, name string, token s
 	})
 }
 
+// El refresh token puede venir en la cookie o, para clientes sin cookies, en el body.
+func (h *HTTPHandler) readRefreshToken(r *http.Request) string {
+	if c, err := r.Cookie("refreshToken"); err == nil && c.Value != "" {
+		return c.Value
+	}
+
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(req.RefreshToken)
+}
+
+// clientIP usa RemoteAddr, que chimw.RealIP ya reemplaza por X-Real-IP / X-Forwarded-For.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func (h *HTTPHandler) clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
 func (h *HTTPHandler) respondWithTokens(w http.ResponseWriter, user *user.User, tokens *TokenPair) {
 	response := LoginResponse{
 		Email:        user.Email,
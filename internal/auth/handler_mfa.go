@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/mfa"
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/httputil"
+)
+
+// POST /v1/login/2fa
+// Segundo paso del login: canjea el token mfa_pending y un código TOTP (o de recuperación) por la sesión.
+func (h *HTTPHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req LoginMFARequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid json", nil)
+		return
+	}
+
+	if fields, ok := h.validator.ValidateStruct(&req); !ok {
+		httputil.WriteError(w, http.StatusBadRequest, "validation error", fields)
+		return
+	}
+
+	claims, err := h.jwt.ParseClaims(strings.TrimSpace(req.MFAToken), TokenTypeMFAPending)
+	if err != nil {
+		httputil.WriteError(w, http.StatusUnauthorized, "two-factor challenge expired, please login again", nil)
+		return
+	}
+
+	if revoked, err := h.tokens.IsRevoked(r.Context(), claims.ID, claims.Expiry()); err != nil || revoked {
+		httputil.WriteError(w, http.StatusUnauthorized, "two-factor challenge expired, please login again", nil)
+		return
+	}
+
+	user, err := h.users.GetByID(r.Context(), claims.UserID())
+	if err != nil {
+		httputil.WriteError(w, http.StatusUnauthorized, "two-factor challenge expired, please login again", nil)
+		return
+	}
+
+	if user.Locked_until.After(time.Now()) {
+		httputil.WriteError(w, http.StatusLocked, "account temporarily locked", nil)
+		return
+	}
+
+	if err := h.mfa.Authenticate(r.Context(), user.ID, req.Code); err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			// Cuenta como intento fallido de login: el bloqueo por intentos también frena la fuerza bruta del código.
+			h.users.IncrementLoginAttempt(r.Context(), user.ID)
+			httputil.WriteError(w, http.StatusUnauthorized, err.Error(), nil)
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, "internal error", nil)
+		return
+	}
+
+	// El challenge es de un solo uso; si otro request ya lo canjeó, este no abre otra sesión.
+	if _, err := h.tokens.Rotate(r.Context(), claims.ID); err != nil {
+		httputil.WriteError(w, http.StatusUnauthorized, "two-factor challenge already used, please login again", nil)
+		return
+	}
+
+	h.completeLogin(w, r, user)
+}
+
+// POST /v1/me/2fa/setup
+func (h *HTTPHandler) SetupMFA(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	user, err := h.users.GetByID(r.Context(), userID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusNotFound, "user not found", nil)
+		return
+	}
+
+	setup, err := h.mfa.Setup(r.Context(), user.ID, user.Email)
+	if err != nil {
+		h.writeMFAError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, setup)
+}
+
+// POST /v1/me/2fa/verify
+func (h *HTTPHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req mfa.VerifyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid json", nil)
+		return
+	}
+
+	if fields, ok := h.validator.ValidateStruct(&req); !ok {
+		httputil.WriteError(w, http.StatusBadRequest, "validation error", fields)
+		return
+	}
+
+	codes, err := h.mfa.Verify(r.Context(), userID, req.Code)
+	if err != nil {
+		h.writeMFAError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, &mfa.VerifyResponse{Enabled: true, RecoveryCodes: codes})
+}
+
+// startMFAChallenge emite el token mfa_pending en lugar de la sesión. No se setean cookies: el
+// cliente lo manda en el body del segundo paso.
+func (h *HTTPHandler) startMFAChallenge(w http.ResponseWriter, r *http.Request, user *user.User) {
+	pending, err := h.issueToken(r.Context(), user, TokenTypeMFAPending, "")
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "cannot generate tokens", nil)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, &MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    pending,
+		ExpiresIn:   int(h.jwt.GetTTL(TokenTypeMFAPending).Seconds()),
+	})
+}
+
+func (h *HTTPHandler) writeMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, mfa.ErrAlreadyEnabled):
+		httputil.WriteError(w, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, mfa.ErrFactorNotFound):
+		httputil.WriteError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, mfa.ErrInvalidCode):
+		httputil.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		httputil.WriteError(w, http.StatusInternalServerError, "internal error", nil)
+	}
+}
@@ -1,18 +1,23 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/sebaactis/wallet-go-api/internal/authz"
 )
 
 type Claims struct {
-	Email     string    `json:"email"`
-	TokenType TokenType `json:"token_type"` // Agregar este campo
-	jwt.RegisteredClaims
+	Email                string     `json:"email"`
+	TokenType            TokenType  `json:"token_type"`    // Agregar este campo
+	SessionID            string     `json:"sid,omitempty"` // familia de tokens del login que lo emitió
+	Role                 authz.Role `json:"role,omitempty"`
+	jwt.RegisteredClaims            // ID viaja como "jti"
 }
 
 type JWT struct {
@@ -21,6 +26,7 @@ type JWT struct {
 	ttlReset     time.Duration
 	ttlNormal    time.Duration
 	ttlRefresh   time.Duration
+	ttlMFA       time.Duration
 }
 
 func NewJWT() *JWT {
@@ -39,6 +45,7 @@ func NewJWT() *JWT {
 	ttlMin := 60
 	ttlMinReset := 15
 	ttlMinRefresh := 1440
+	ttlMinMFA := 5
 
 	if s := os.Getenv("JWT_TTL_MINUTES"); s != "" {
 		if n, err := strconv.Atoi(s); err == nil && n > 0 {
@@ -58,24 +65,50 @@ func NewJWT() *JWT {
 		}
 	}
 
+	if s := os.Getenv("JWT_TTL_MFA_MINUTES"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			ttlMinMFA = n
+		}
+	}
+
 	return &JWT{
 		secret:       []byte(sec),
 		reset_secret: []byte(resetSec),
 		ttlReset:     time.Duration(ttlMinReset) * time.Minute,
 		ttlNormal:    time.Duration(ttlMin) * time.Minute,
-		ttlRefresh:   time.Duration(ttlMinRefresh) * time.Minute}
+		ttlRefresh:   time.Duration(ttlMinRefresh) * time.Minute,
+		ttlMFA:       time.Duration(ttlMinMFA) * time.Minute}
+}
+
+type SignedToken struct {
+	Token     string
+	ID        string // jti
+	ExpiresAt time.Time
 }
 
 func (j *JWT) Sign(userID uint, email string, tokenType TokenType) (string, error) {
+	signed, err := j.Issue(userID, email, authz.RoleUser, tokenType, "")
+	if err != nil {
+		return "", err
+	}
+	return signed.Token, nil
+}
+
+// Issue firma el token y devuelve también su jti y vencimiento, que es lo que se persiste.
+func (j *JWT) Issue(userID uint, email string, role authz.Role, tokenType TokenType, sessionID string) (*SignedToken, error) {
 	now := time.Now()
+	expiresAt := j.getExpiration(tokenType, now)
 
 	claims := Claims{
 		Email:     email,
 		TokenType: tokenType,
+		SessionID: sessionID,
+		Role:      role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        NewTokenID(),
 			Subject:   strconv.FormatUint(uint64(userID), 10),
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(j.getExpiration(tokenType, now)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
 	}
 
@@ -86,18 +119,46 @@ func (j *JWT) Sign(userID uint, email string, tokenType TokenType) (string, erro
 		secret = j.reset_secret
 	}
 
-	return token.SignedString(secret)
+	signed, err := token.SignedString(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedToken{Token: signed, ID: claims.ID, ExpiresAt: expiresAt}, nil
+}
+
+// NewTokenID genera un identificador aleatorio; se usa como jti y como id de familia de tokens.
+func NewTokenID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
 }
 
 func (j *JWT) Parse(tokenIn string, tokenType TokenType) (uint, string, TokenType, error) {
-	return j.parseWithSecret(tokenIn, j.secret, tokenType)
+	claims, err := j.ParseClaims(tokenIn, tokenType)
+	if err != nil {
+		return 0, "", "", err
+	}
+	return claims.UserID(), claims.Email, claims.TokenType, nil
 }
 
 func (j *JWT) ParseResetPassword(tokenIn string) (uint, string, TokenType, error) {
-	return j.parseWithSecret(tokenIn, j.reset_secret, TokenTypeResetPassword)
+	claims, err := j.parseWithSecret(tokenIn, j.reset_secret, TokenTypeResetPassword)
+	if err != nil {
+		return 0, "", "", err
+	}
+	return claims.UserID(), claims.Email, claims.TokenType, nil
 }
 
-func (j *JWT) parseWithSecret(tokenIn string, secret []byte, expectedType TokenType) (uint, string, TokenType, error) {
+// ParseClaims valida firma, tipo y vencimiento y devuelve los claims completos (incluido el jti).
+func (j *JWT) ParseClaims(tokenIn string, tokenType TokenType) (*Claims, error) {
+	if tokenType == TokenTypeResetPassword {
+		return j.parseWithSecret(tokenIn, j.reset_secret, tokenType)
+	}
+	return j.parseWithSecret(tokenIn, j.secret, tokenType)
+}
+
+func (j *JWT) parseWithSecret(tokenIn string, secret []byte, expectedType TokenType) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(
 		tokenIn,
 		&Claims{},
@@ -111,28 +172,39 @@ func (j *JWT) parseWithSecret(tokenIn string, secret []byte, expectedType TokenT
 	)
 
 	if err != nil || !token.Valid {
-		return 0, "", "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
 	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return 0, "", "", errors.New("invalid token claims")
+		return nil, errors.New("invalid token claims")
 	}
 
 	if claims.TokenType != expectedType {
-		return 0, "", "", errors.New("invalid token type")
+		return nil, errors.New("invalid token type")
 	}
 
 	if claims.ExpiresAt != nil && time.Now().After(claims.ExpiresAt.Time) {
-		return 0, "", "", errors.New("token expired")
+		return nil, errors.New("token expired")
 	}
 
-	idU64, err := strconv.ParseUint(claims.Subject, 10, 64)
-	if err != nil {
-		return 0, "", "", errors.New("invalid subject")
+	if _, err := strconv.ParseUint(claims.Subject, 10, 64); err != nil {
+		return nil, errors.New("invalid subject")
 	}
 
-	return uint(idU64), claims.Email, claims.TokenType, nil
+	return claims, nil
+}
+
+func (c *Claims) UserID() uint {
+	id, _ := strconv.ParseUint(c.Subject, 10, 64)
+	return uint(id)
+}
+
+func (c *Claims) Expiry() time.Time {
+	if c.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return c.ExpiresAt.Time
 }
 
 func (j *JWT) getExpiration(tokenType TokenType, now time.Time) time.Time {
@@ -144,15 +216,22 @@ func (j *JWT) getExpiration(tokenType TokenType, now time.Time) time.Time {
 		return now.Add(j.ttlReset)
 	}
 
+	if tokenType == TokenTypeMFAPending {
+		return now.Add(j.ttlMFA)
+	}
+
 	return now.Add(j.ttlRefresh)
 }
 
 func (j *JWT) GetTTL(tokenType TokenType) time.Duration {
 	var ttl time.Duration
 
-	if tokenType == TokenTypeAccess {
+	switch tokenType {
+	case TokenTypeAccess:
 		ttl = j.ttlNormal
-	} else {
+	case TokenTypeMFAPending:
+		ttl = j.ttlMFA
+	default:
 		ttl = j.ttlRefresh
 	}
 
@@ -0,0 +1,139 @@
+// Package authz centraliza las reglas de quién puede hacer qué sobre cada recurso. Los handlers no
+// comparan IDs ni roles por su cuenta: cargan el recurso, arman un Resource con su dueño y llaman a
+// Authorize con la acción correspondiente.
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+type Role string
+
+const (
+	RoleUser    Role = "user"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+
+	// RoleSystem es el del usuario dueño de las cuentas internas del ledger. No se puede asignar
+	// ni iniciar sesión con él.
+	RoleSystem Role = "system"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleSupport, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+var (
+	ErrUnauthenticated = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrUnknownAction   = errors.New("authz: unknown action")
+)
+
+type Action string
+
+const (
+	ReadUser Action = "user:read"
+
+	CreateAccount  Action = "account:create"
+	ReadAccount    Action = "account:read"    // saldo e historial
+	OperateAccount Action = "account:operate" // mover fondos desde la cuenta
+
+	ReverseTransaction Action = "transaction:reverse"
+
+	ManageWebhook Action = "webhook:manage"
+)
+
+// Subject es quien hace el request, tal como quedó en el contexto después de RequireAuth.
+type Subject struct {
+	UserID uint
+	Role   Role
+}
+
+type ctxKey struct{}
+
+// Resource describe lo mínimo que necesitan las políticas: de quién es.
+type Resource struct {
+	OwnerID uint
+}
+
+type Policy func(s Subject, r Resource) bool
+
+func owner(s Subject, r Resource) bool { return r.OwnerID != 0 && s.UserID == r.OwnerID }
+
+func staff(s Subject) bool { return s.Role == RoleSupport || s.Role == RoleAdmin }
+
+func admin(s Subject) bool { return s.Role == RoleAdmin }
+
+// Soporte puede mirar, pero mover plata o crear cuentas es solo del dueño.
+var policies = map[Action]Policy{
+	ReadUser:       func(s Subject, r Resource) bool { return owner(s, r) || staff(s) },
+	CreateAccount:  owner,
+	ReadAccount:    func(s Subject, r Resource) bool { return owner(s, r) || staff(s) },
+	OperateAccount: owner,
+	// El dueño es quien devuelve la plata (el receptor de una transferencia); el resto, solo admin.
+	ReverseTransaction: func(s Subject, r Resource) bool { return owner(s, r) || admin(s) },
+	// Los webhooks llevan el secreto del usuario: ni soporte los ve.
+	ManageWebhook: owner,
+}
+
+func WithSubject(ctx context.Context, s Subject) context.Context {
+	return context.WithValue(ctx, ctxKey{}, s)
+}
+
+// SubjectFromContext devuelve el usuario autenticado; los tokens emitidos antes de los roles
+// cuentan como RoleUser.
+func SubjectFromContext(ctx context.Context) (Subject, bool) {
+	s, ok := ctx.Value(ctxKey{}).(Subject)
+	if !ok || s.UserID == 0 {
+		return Subject{}, false
+	}
+	if s.Role == "" {
+		s.Role = RoleUser
+	}
+	return s, true
+}
+
+// Can evalúa la política sin mirar el contexto.
+func Can(s Subject, action Action, r Resource) (bool, error) {
+	policy, ok := policies[action]
+	if !ok {
+		return false, ErrUnknownAction
+	}
+	return policy(s, r), nil
+}
+
+// Authorize evalúa la política para el usuario del request.
+func Authorize(ctx context.Context, action Action, r Resource) error {
+	s, ok := SubjectFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	allowed, err := Can(s, action, r)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// Status traduce el error de Authorize al código HTTP que corresponde.
+func Status(err error) int {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
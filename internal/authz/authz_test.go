@@ -0,0 +1,151 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+const (
+	ownerID = 7
+	otherID = 8
+)
+
+func TestCan(t *testing.T) {
+	tests := []struct {
+		action Action
+		role   Role
+		owner  bool
+		want   bool
+	}{
+		{ReadUser, RoleUser, true, true},
+		{ReadUser, RoleUser, false, false},
+		{ReadUser, RoleSupport, true, true},
+		{ReadUser, RoleSupport, false, true},
+		{ReadUser, RoleAdmin, true, true},
+		{ReadUser, RoleAdmin, false, true},
+
+		{CreateAccount, RoleUser, true, true},
+		{CreateAccount, RoleUser, false, false},
+		{CreateAccount, RoleSupport, true, true},
+		{CreateAccount, RoleSupport, false, false},
+		{CreateAccount, RoleAdmin, true, true},
+		{CreateAccount, RoleAdmin, false, false},
+
+		{ReadAccount, RoleUser, true, true},
+		{ReadAccount, RoleUser, false, false},
+		{ReadAccount, RoleSupport, true, true},
+		{ReadAccount, RoleSupport, false, true},
+		{ReadAccount, RoleAdmin, true, true},
+		{ReadAccount, RoleAdmin, false, true},
+
+		{OperateAccount, RoleUser, true, true},
+		{OperateAccount, RoleUser, false, false},
+		{OperateAccount, RoleSupport, true, true},
+		{OperateAccount, RoleSupport, false, false},
+		{OperateAccount, RoleAdmin, true, true},
+		{OperateAccount, RoleAdmin, false, false},
+
+		{ReverseTransaction, RoleUser, true, true},
+		{ReverseTransaction, RoleUser, false, false},
+		{ReverseTransaction, RoleSupport, true, true},
+		{ReverseTransaction, RoleSupport, false, false},
+		{ReverseTransaction, RoleAdmin, true, true},
+		{ReverseTransaction, RoleAdmin, false, true},
+
+		{ManageWebhook, RoleUser, true, true},
+		{ManageWebhook, RoleUser, false, false},
+		{ManageWebhook, RoleSupport, true, true},
+		{ManageWebhook, RoleSupport, false, false},
+		{ManageWebhook, RoleAdmin, true, true},
+		{ManageWebhook, RoleAdmin, false, false},
+	}
+
+	covered := map[Action]int{}
+	for _, tt := range tests {
+		covered[tt.action]++
+
+		name := fmt.Sprintf("%s/%s/owner=%v", tt.action, tt.role, tt.owner)
+		t.Run(name, func(t *testing.T) {
+			s := Subject{UserID: otherID, Role: tt.role}
+			if tt.owner {
+				s.UserID = ownerID
+			}
+
+			got, err := Can(s, tt.action, Resource{OwnerID: ownerID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Can = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Una acción nueva sin filas acá es una política sin probar.
+	for action := range policies {
+		if covered[action] != 6 {
+			t.Errorf("action %s has %d rows, want one per role and ownership", action, covered[action])
+		}
+	}
+}
+
+// Un recurso sin dueño conocido no es de nadie, ni siquiera de un subject sin ID.
+func TestCanWithoutOwner(t *testing.T) {
+	for action := range policies {
+		got, err := Can(Subject{Role: RoleUser}, action, Resource{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got {
+			t.Errorf("%s allowed on a resource without owner", action)
+		}
+	}
+}
+
+func TestCanUnknownAction(t *testing.T) {
+	if _, err := Can(Subject{UserID: 1, Role: RoleAdmin}, "account:delete", Resource{OwnerID: 1}); !errors.Is(err, ErrUnknownAction) {
+		t.Errorf("error = %v, want ErrUnknownAction", err)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	res := Resource{OwnerID: ownerID}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		err    error
+		status int
+	}{
+		{"no subject", context.Background(), ErrUnauthenticated, http.StatusUnauthorized},
+		{"subject without id", WithSubject(context.Background(), Subject{Role: RoleAdmin}), ErrUnauthenticated, http.StatusUnauthorized},
+		{"owner", WithSubject(context.Background(), Subject{UserID: ownerID, Role: RoleUser}), nil, 0},
+		{"other user", WithSubject(context.Background(), Subject{UserID: otherID, Role: RoleUser}), ErrForbidden, http.StatusForbidden},
+		// Los tokens anteriores a los roles no traen rol y cuentan como usuario común.
+		{"token without role", WithSubject(context.Background(), Subject{UserID: otherID}), ErrForbidden, http.StatusForbidden},
+		{"support", WithSubject(context.Background(), Subject{UserID: otherID, Role: RoleSupport}), nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(tt.ctx, ReadAccount, res)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Authorize error = %v, want %v", err, tt.err)
+			}
+			if err != nil && Status(err) != tt.status {
+				t.Errorf("Status = %d, want %d", Status(err), tt.status)
+			}
+		})
+	}
+}
+
+func TestRoleValid(t *testing.T) {
+	for role, want := range map[Role]bool{RoleUser: true, RoleSupport: true, RoleAdmin: true, RoleSystem: false, "root": false, "": false} {
+		if got := role.Valid(); got != want {
+			t.Errorf("Role(%q).Valid() = %v, want %v", role, got, want)
+		}
+	}
+}
@@ -1,21 +1,25 @@
 package account
 
+import "github.com/sebaactis/wallet-go-api/internal/money"
+
 type CreateAccountRequest struct {
 	UserID   uint   `json:"userId"   validate:"required"`
 	Currency string `json:"currency" validate:"required,iso4217"`
 }
 
 type AccountResponse struct {
-	ID       uint    `json:"id"`
-	UserID   uint    `json:"userId"`
-	Currency string  `json:"currency"`
-	Balance  float64 `json:"balance"` // menor unidad
+	ID       uint          `json:"id"`
+	UserID   uint          `json:"userId"`
+	Currency string        `json:"currency"`
+	Balance  money.Decimal `json:"balance"`
 }
 
 type BalanceResponse struct {
-	AccountID uint    `json:"accountId"`
-	Currency  string  `json:"currency"`
-	Balance   float64 `json:"balance"`
+	AccountID        uint          `json:"accountId"`
+	Currency         string        `json:"currency"`
+	Balance          money.Decimal `json:"balance"` // igual a ledger_balance, se mantiene por compatibilidad
+	LedgerBalance    money.Decimal `json:"ledger_balance"`
+	AvailableBalance money.Decimal `json:"available_balance"`
 }
 
 func ToResponse(a *Account) *AccountResponse {
@@ -23,6 +27,6 @@ func ToResponse(a *Account) *AccountResponse {
 		ID:       a.ID,
 		UserID:   a.UserID,
 		Currency: a.Currency,
-		Balance:  a.Balance,
+		Balance:  a.BalanceMoney().Decimal(),
 	}
 }
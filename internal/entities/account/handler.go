@@ -7,7 +7,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/sebaactis/wallet-go-api/internal/httpmw"
+	"github.com/sebaactis/wallet-go-api/internal/authz"
 	"github.com/sebaactis/wallet-go-api/internal/httputil"
 )
 
@@ -27,14 +27,8 @@ func (h *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authUser, ok := httpmw.UserIDFromContext(r.Context())
-	if !ok {
-		httputil.WriteError(w, http.StatusBadRequest, "unauthorized", nil)
-		return
-	}
-
-	if req.UserID != authUser {
-		httputil.WriteError(w, http.StatusForbidden, "forbidden", nil)
+	if err := authz.Authorize(r.Context(), authz.CreateAccount, authz.Resource{OwnerID: req.UserID}); err != nil {
+		httputil.WriteError(w, authz.Status(err), err.Error(), nil)
 		return
 	}
 
@@ -69,12 +63,19 @@ func (h *HTTPHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bal := acc.Balance
+	if err := authz.Authorize(r.Context(), authz.ReadAccount, authz.Resource{OwnerID: acc.UserID}); err != nil {
+		httputil.WriteError(w, authz.Status(err), err.Error(), nil)
+		return
+	}
+
+	bal := acc.BalanceMoney()
 
 	resp := BalanceResponse{
-		AccountID: acc.ID,
-		Currency:  acc.Currency,
-		Balance:   bal,
+		AccountID:        acc.ID,
+		Currency:         acc.Currency,
+		Balance:          bal.Decimal(),
+		LedgerBalance:    bal.Decimal(),
+		AvailableBalance: acc.AvailableMoney().Decimal(),
 	}
 
 	json.NewEncoder(w).Encode(resp)
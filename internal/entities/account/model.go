@@ -4,14 +4,43 @@ import (
 	"time"
 
 	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/money"
 )
 
 type Account struct {
-	ID        uint       `json:"id" gorm:"primaryKey"`
-	UserID    uint       `json:"user_id" gorm:"not null;index"`
-	User      *user.User `json:"user" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Currency  string     `json:"currency" gorm:"size:3;not null"`
-	Balance   float64    `json:"balance" gorm:"not null;default:0"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID       uint       `json:"id" gorm:"primaryKey"`
+	UserID   uint       `json:"user_id" gorm:"not null;index"`
+	User     *user.User `json:"user" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Currency string     `json:"currency" gorm:"size:3;not null;uniqueIndex:idx_accounts_system,priority:2"`
+	Balance  int64      `json:"balance" gorm:"not null;default:0"` // unidades menores
+	Held     int64      `json:"held" gorm:"not null;default:0"`    // reservado por holds activos
+	Version  uint64     `json:"version" gorm:"not null;default:0"` // control optimista de concurrencia
+	// Solo en cuentas internas del ledger (ledger.CashInClearing, ...); nil en cuentas de clientes.
+	SystemCode *string `json:"system_code,omitempty" gorm:"size:30;uniqueIndex:idx_accounts_system,priority:1"`
+	// Congelada por reconciliación: no admite operaciones hasta que un admin la libere.
+	FrozenAt     *time.Time `json:"frozen_at,omitempty"`
+	FrozenReason string     `json:"frozen_reason,omitempty" gorm:"size:255"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (a *Account) IsSystem() bool {
+	return a.SystemCode != nil
+}
+
+func (a *Account) IsFrozen() bool {
+	return a.FrozenAt != nil
+}
+
+func (a *Account) BalanceMoney() money.Money {
+	return money.New(a.Balance, a.Currency)
+}
+
+// Available es lo que se puede mover: el saldo contable menos lo reservado por holds.
+func (a *Account) Available() int64 {
+	return a.Balance - a.Held
+}
+
+func (a *Account) AvailableMoney() money.Money {
+	return money.New(a.Available(), a.Currency)
 }
@@ -12,6 +12,10 @@ type Repository struct {
 
 func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
 
+func (r *Repository) withTx(tx *gorm.DB) *Repository {
+	return &Repository{db: tx}
+}
+
 func (r *Repository) Create(ctx context.Context, account *Account) error {
 	return r.db.WithContext(ctx).Create(account).Error
 }
@@ -5,13 +5,15 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
+	"github.com/sebaactis/wallet-go-api/internal/money"
 	"gorm.io/gorm"
 )
 
 var (
 	ErrAccountExists = errors.New("account already exists for user+currency")
 	ErrUserNotFound  = errors.New("user not found")
-	ErrCurrencyISO = errors.New("currency must be 3-letter ISO code")
+	ErrCurrencyISO   = errors.New("currency must be 3-letter ISO code")
 )
 
 type Service struct {
@@ -23,7 +25,6 @@ func NewService(repo *Repository) *Service {
 	return &Service{repo: repo, db: repo.db}
 }
 
-
 func (s *Service) Create(ctx context.Context, accountCreate *CreateAccountRequest) (*Account, error) {
 	accountCreate.Currency = strings.ToUpper(strings.TrimSpace(accountCreate.Currency))
 
@@ -44,7 +45,13 @@ func (s *Service) Create(ctx context.Context, accountCreate *CreateAccountReques
 		Currency: accountCreate.Currency,
 		Balance:  0,
 	}
-	if err := s.repo.Create(ctx, acc); err != nil {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.withTx(tx).Create(ctx, acc); err != nil {
+			return err
+		}
+		return outbox.Append(ctx, tx, outbox.AccountCreated, "account", acc.ID, acc.UserID, ToResponse(acc))
+	})
+	if err != nil {
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
 			return nil, ErrAccountExists
 		}
@@ -53,10 +60,10 @@ func (s *Service) Create(ctx context.Context, accountCreate *CreateAccountReques
 	return acc, nil
 }
 
-func (s *Service) GetBalance(ctx context.Context, accountID uint) (float64, error) {
+func (s *Service) GetBalance(ctx context.Context, accountID uint) (money.Money, error) {
 	acc, err := s.repo.FindByID(ctx, accountID)
 	if err != nil {
-		return 0, err
+		return money.Money{}, err
 	}
-	return acc.Balance, nil
+	return acc.BalanceMoney(), nil
 }
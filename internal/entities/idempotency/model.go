@@ -0,0 +1,25 @@
+package idempotency
+
+import "time"
+
+// Record es una request hecha con Idempotency-Key. Mientras StatusCode es 0 la original sigue en
+// curso; después guarda la respuesta completa para repetirla. RequestHash es el SHA-256 del body
+// y evita que la misma clave se use para otra operación.
+type Record struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;uniqueIndex:idx_idempotency_keys_scope"`
+	Method      string `gorm:"size:10;not null;uniqueIndex:idx_idempotency_keys_scope"`
+	Path        string `gorm:"size:200;not null;uniqueIndex:idx_idempotency_keys_scope"`
+	Key         string `gorm:"column:idem_key;size:255;not null;uniqueIndex:idx_idempotency_keys_scope"`
+	RequestHash string `gorm:"size:64;not null"`
+	StatusCode  int    `gorm:"not null;default:0"`
+	Response    []byte
+	LockedAt    time.Time `gorm:"not null"` // inicio del intento en curso
+	ExpiresAt   time.Time `gorm:"not null;index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (Record) TableName() string { return "idempotency_keys" }
+
+func (r *Record) Completed() bool { return r.StatusCode != 0 }
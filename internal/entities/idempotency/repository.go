@@ -0,0 +1,71 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
+
+// Reserve inserta la clave si no existe. Devuelve false si ya había un registro: lo decide el
+// índice único, así que dos requests simultáneas no pueden reservarla las dos.
+func (r *Repository) Reserve(ctx context.Context, rec *Record) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(rec)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *Repository) Find(ctx context.Context, userID uint, method, path, key string) (*Record, error) {
+	var rec Record
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND method = ? AND path = ? AND idem_key = ?", userID, method, path, key).
+		Limit(1).
+		Find(&rec).Error
+	if err != nil {
+		return nil, err
+	}
+	if rec.ID == 0 {
+		return nil, nil
+	}
+
+	return &rec, nil
+}
+
+// Relock toma un intento abandonado (en curso hace más de staleAfter) con un compare-and-swap
+// sobre locked_at: si dos requests lo intentan a la vez, solo una gana.
+func (r *Repository) Relock(ctx context.Context, rec *Record, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&Record{}).
+		Where("id = ? AND status_code = 0 AND locked_at = ?", rec.ID, rec.LockedAt).
+		Update("locked_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *Repository) Complete(ctx context.Context, id uint, status int, body []byte) error {
+	return r.db.WithContext(ctx).
+		Model(&Record{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"status_code": status, "response": body}).Error
+}
+
+func (r *Repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&Record{}, id).Error
+}
+
+func (r *Repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&Record{})
+	return result.RowsAffected, result.Error
+}
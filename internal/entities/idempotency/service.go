@@ -0,0 +1,128 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// staleAfter es cuánto se espera a una request en curso antes de dar el intento por abandonado
+// (el proceso se cayó a mitad de camino) y dejar que otra lo retome. Tiene que superar con margen
+// el timeout de las requests.
+const staleAfter = time.Minute
+
+var (
+	ErrFingerprintMismatch = errors.New("idempotency key already used with a different request")
+	ErrInFlight            = errors.New("a request with this idempotency key is still in progress")
+)
+
+type Service struct {
+	repo *Repository
+	ttl  time.Duration
+}
+
+// ttl es cuánto se guarda cada clave: pasado ese tiempo se puede volver a usar.
+func NewService(repo *Repository, ttl time.Duration) *Service {
+	return &Service{repo: repo, ttl: ttl}
+}
+
+// Begin reserva la clave para una request. Si devuelve owner, la request se ejecuta y después se
+// llama a Complete o Release; si no, rec es la respuesta guardada de la original para repetirla.
+func (s *Service) Begin(ctx context.Context, userID uint, method, path, key, hash string) (rec *Record, owner bool, err error) {
+	now := time.Now()
+
+	rec = &Record{
+		UserID:      userID,
+		Method:      method,
+		Path:        path,
+		Key:         key,
+		RequestHash: hash,
+		LockedAt:    now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	ok, err := s.repo.Reserve(ctx, rec)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		return rec, true, nil
+	}
+
+	prev, err := s.repo.Find(ctx, userID, method, path, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Vencida pero todavía sin purgar, o borrada entre el insert y la lectura: se reemplaza.
+	if prev == nil || !now.Before(prev.ExpiresAt) {
+		if prev != nil {
+			if err := s.repo.Delete(ctx, prev.ID); err != nil {
+				return nil, false, err
+			}
+		}
+		if ok, err = s.repo.Reserve(ctx, rec); err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return rec, true, nil
+		}
+		return nil, false, ErrInFlight
+	}
+
+	if prev.RequestHash != hash {
+		return nil, false, ErrFingerprintMismatch
+	}
+
+	if !prev.Completed() {
+		if now.Sub(prev.LockedAt) < staleAfter {
+			return nil, false, ErrInFlight
+		}
+		if ok, err = s.repo.Relock(ctx, prev, now); err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			return nil, false, ErrInFlight
+		}
+		prev.LockedAt = now
+		return prev, true, nil
+	}
+
+	return prev, false, nil
+}
+
+// Complete guarda la respuesta de la request original.
+func (s *Service) Complete(ctx context.Context, rec *Record, status int, body []byte) error {
+	return s.repo.Complete(ctx, rec.ID, status, body)
+}
+
+// Release libera la clave sin guardar respuesta, para que el cliente pueda reintentar con la misma.
+func (s *Service) Release(ctx context.Context, rec *Record) error {
+	return s.repo.Delete(ctx, rec.ID)
+}
+
+// RunPurger borra las claves vencidas cada interval hasta que se cancele ctx.
+func (s *Service) RunPurger(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.repo.DeleteExpired(ctx, time.Now())
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("idempotency purger: %v", err)
+			}
+			if n > 0 {
+				log.Printf("idempotency purger: %d keys expired", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Cuentas internas del ledger. Hay una de cada tipo por moneda, todas del usuario de sistema, y
+// son la contrapartida de lo que entra o sale de las cuentas de clientes.
+const (
+	CashInClearing  = "cash_in_clearing"  // contrapartida de depósitos
+	CashOutClearing = "cash_out_clearing" // contrapartida de retiros
+	FeesRevenue     = "fees_revenue"      // comisiones cobradas
+	FX              = "fx"                // posición de cambio: recibe una moneda y entrega la otra
+	Suspense        = "suspense"          // diferencias sin explicar (p. ej. asientos históricos de una sola pata)
+)
+
+var ErrUnbalanced = errors.New("ledger entries do not balance")
+
+// Validate exige que los asientos de una transacción netéen a cero en cada moneda. Ninguna
+// transacción se escribe sin pasar por acá.
+func Validate(entries []*LedgerEntry) error {
+	if len(entries) < 2 {
+		return fmt.Errorf("%w: a transaction needs at least two entries", ErrUnbalanced)
+	}
+
+	sums := map[string]int64{}
+	for _, e := range entries {
+		if e.Amount == 0 || e.Currency == "" {
+			return fmt.Errorf("%w: entry for account %d has no amount or currency", ErrUnbalanced, e.AccountID)
+		}
+		sums[e.Currency] += e.Amount
+	}
+
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%w: %s nets to %d", ErrUnbalanced, currency, sum)
+		}
+	}
+
+	return nil
+}
@@ -13,6 +13,7 @@ type LedgerEntry struct {
 	Transaction   *transaction.Transaction `json:"transaction" gorm:"foreignKey:TransactionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	AccountID     uint                     `json:"account_id" gorm:"not null;index"`
 	Account       *account.Account         `json:"account" gorm:"foreignKey:AccountID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Amount        float64                    `json:"amount" gorm:"not null"`
+	Amount        int64                    `json:"amount" gorm:"not null"` // unidades menores, con signo
+	Currency      string                   `json:"currency" gorm:"size:3;not null"`
 	CreatedAt     time.Time
 }
@@ -0,0 +1,15 @@
+package mfa
+
+type SetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+type VerifyRequest struct {
+	Code string `json:"code" validate:"required,min=6,max=32"`
+}
+
+type VerifyResponse struct {
+	Enabled       bool     `json:"enabled"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
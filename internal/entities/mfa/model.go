@@ -0,0 +1,28 @@
+package mfa
+
+import "time"
+
+// Factor es el secreto TOTP de un usuario. Queda pendiente hasta que se verifica el primer código;
+// recién ahí el login empieza a pedir segundo factor.
+type Factor struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex"`
+	Secret       string     `json:"-" gorm:"size:64;not null"`
+	ConfirmedAt  *time.Time `json:"confirmed_at" gorm:"default:null"`
+	LastUsedStep int64      `json:"-" gorm:"not null;default:0"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// RecoveryCode se guarda solo como hash; el texto plano se muestra una única vez al confirmar.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index;uniqueIndex:idx_recovery_codes_user_code,priority:1"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex:idx_recovery_codes_user_code,priority:2"`
+	UsedAt    *time.Time `json:"used_at" gorm:"default:null"`
+	CreatedAt time.Time
+}
+
+func (f *Factor) Enabled() bool { return f != nil && f.ConfirmedAt != nil }
+
+func (Factor) TableName() string { return "mfa_factors" }
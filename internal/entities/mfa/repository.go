@@ -0,0 +1,77 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrFactorNotFound = errors.New("two-factor authentication not configured")
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
+
+func (r *Repository) FindByUser(ctx context.Context, userID uint) (*Factor, error) {
+	var f Factor
+
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&f).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFactorNotFound
+		}
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// Upsert reemplaza un factor sin confirmar (setup repetido) por uno nuevo.
+func (r *Repository) Upsert(ctx context.Context, f *Factor) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_used_step", "updated_at"}),
+	}).Create(f).Error
+}
+
+// Confirm activa el factor y reemplaza los códigos de recuperación en una sola transacción.
+func (r *Repository) Confirm(ctx context.Context, f *Factor, step int64, at time.Time, codes []*RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Factor{}).
+			Where("id = ? AND confirmed_at IS NULL", f.ID).
+			Updates(map[string]interface{}{"confirmed_at": at, "last_used_step": step})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrAlreadyEnabled
+		}
+
+		if err := tx.Where("user_id = ?", f.UserID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+// AdvanceStep registra el paso usado solo si es posterior al último, así un código no sirve dos veces.
+func (r *Repository) AdvanceStep(ctx context.Context, factorID uint, step int64) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&Factor{}).
+		Where("id = ? AND last_used_step < ?", factorID, step).
+		Update("last_used_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID uint, hash string, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,180 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/platform/totp"
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryAlphabet  = "abcdefghjkmnpqrstuvwxyz23456789" // sin caracteres ambiguos (0/o, 1/l/i)
+
+	// Un paso hacia cada lado tolera unos 30s de desfase entre el reloj del teléfono y el servidor.
+	allowedSkew = 1
+)
+
+var (
+	ErrAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrInvalidCode    = errors.New("invalid two-factor code")
+)
+
+type Service struct {
+	repo   *Repository
+	issuer string
+}
+
+func NewService(repo *Repository) *Service {
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = "Wallet"
+	}
+
+	return &Service{repo: repo, issuer: issuer}
+}
+
+// Enabled indica si el login del usuario requiere segundo factor.
+func (s *Service) Enabled(ctx context.Context, userID uint) (bool, error) {
+	f, err := s.repo.FindByUser(ctx, userID)
+	if errors.Is(err, ErrFactorNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return f.Enabled(), nil
+}
+
+// Setup genera un secreto nuevo sin activarlo. Repetirlo antes de verificar invalida el anterior.
+func (s *Service) Setup(ctx context.Context, userID uint, account string) (*SetupResponse, error) {
+	if f, err := s.repo.FindByUser(ctx, userID); err == nil && f.Enabled() {
+		return nil, ErrAlreadyEnabled
+	} else if err != nil && !errors.Is(err, ErrFactorNotFound) {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Upsert(ctx, &Factor{UserID: userID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	return &SetupResponse{Secret: secret, OTPAuthURI: totp.URI(s.issuer, account, secret)}, nil
+}
+
+// Verify confirma el enrolamiento con el primer código de la app y devuelve los códigos de
+// recuperación en texto plano; no se pueden volver a consultar.
+func (s *Service) Verify(ctx context.Context, userID uint, code string) ([]string, error) {
+	f, err := s.repo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if f.Enabled() {
+		return nil, ErrAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(f.Secret, code, time.Now(), allowedSkew)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	plain := make([]string, recoveryCodeCount)
+	codes := make([]*RecoveryCode, recoveryCodeCount)
+	for i := range plain {
+		c, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		plain[i] = c
+		codes[i] = &RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(c)}
+	}
+
+	if err := s.repo.Confirm(ctx, f, step, time.Now(), codes); err != nil {
+		return nil, err
+	}
+
+	return plain, nil
+}
+
+// Authenticate valida el segundo paso del login: un código TOTP no usado antes o un código de
+// recuperación, que queda consumido.
+func (s *Service) Authenticate(ctx context.Context, userID uint, code string) error {
+	f, err := s.repo.FindByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !f.Enabled() {
+		return ErrFactorNotFound
+	}
+
+	if step, ok := totp.Validate(f.Secret, code, time.Now(), allowedSkew); ok {
+		advanced, err := s.repo.AdvanceStep(ctx, f.ID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
+
+// newRecoveryCode arma un código de 10 caracteres del alfabeto. Los bytes desde el último múltiplo
+// de len(recoveryAlphabet) se descartan: con el módulo directo las primeras letras saldrían más.
+func newRecoveryCode() (string, error) {
+	limit := 256 - 256%len(recoveryAlphabet)
+
+	out := make([]byte, 0, 11)
+	b := make([]byte, 16)
+	for len(out) < 11 {
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		for _, v := range b {
+			if int(v) >= limit {
+				continue
+			}
+			if len(out) == 5 {
+				out = append(out, '-')
+			}
+			out = append(out, recoveryAlphabet[int(v)%len(recoveryAlphabet)])
+			if len(out) == 11 {
+				break
+			}
+		}
+	}
+
+	return string(out), nil
+}
+
+// Los códigos tienen ~49 bits de entropía, así que alcanza con SHA-256 (sin bcrypt) y se pueden
+// buscar por hash directamente.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	if len(normalized) == 10 {
+		normalized = normalized[:5] + "-" + normalized[5:]
+	}
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,51 @@
+package mfa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewRecoveryCode(t *testing.T) {
+	seen := map[string]bool{}
+	counts := map[rune]int{}
+
+	for range 2000 {
+		c, err := newRecoveryCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c) != 11 || c[5] != '-' {
+			t.Fatalf("code %q, want xxxxx-xxxxx", c)
+		}
+		for _, r := range strings.ReplaceAll(c, "-", "") {
+			if !strings.ContainsRune(recoveryAlphabet, r) {
+				t.Fatalf("code %q has %q, outside the alphabet", c, r)
+			}
+			counts[r]++
+		}
+		if seen[c] {
+			t.Fatalf("code %q repeated", c)
+		}
+		seen[c] = true
+	}
+
+	// 20000 caracteres sobre 31 letras: ~645 cada una, ninguna falta ni domina.
+	for _, r := range recoveryAlphabet {
+		if n := counts[r]; n < 500 || n > 800 {
+			t.Errorf("letter %q appeared %d times, want ~645", r, n)
+		}
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	want := hashRecoveryCode("abcde-fghjk")
+
+	for _, in := range []string{"ABCDE-FGHJK", " abcde-fghjk ", "abcdefghjk", "abcde fghjk"} {
+		if got := hashRecoveryCode(in); got != want {
+			t.Errorf("hashRecoveryCode(%q) differs from the canonical form", in)
+		}
+	}
+	if hashRecoveryCode("abcde-fghjm") == want {
+		t.Error("different codes share a hash")
+	}
+}
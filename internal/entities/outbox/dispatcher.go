@@ -0,0 +1,166 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// claimFor es cuánto queda tomado un evento mientras se entrega; si la instancia se cae, otra
+	// lo retoma pasado ese tiempo.
+	claimFor = time.Minute
+	// maxRetryDelay acota la espera entre intentos, que se duplica en cada uno.
+	maxRetryDelay = time.Hour
+)
+
+// Handler recibe un evento. Si devuelve error el evento se reintenta, y como se reintenta para
+// todos los suscriptores, cada uno tiene que tolerar recibirlo más de una vez.
+type Handler func(ctx context.Context, e *Event) error
+
+type subscription struct {
+	name   string
+	types  map[string]bool // nil = todos
+	handle Handler
+}
+
+// Dispatcher entrega los eventos del outbox a los suscriptores en proceso, de a uno y en orden de
+// id: si un evento falla, los siguientes esperan a que se entregue o pase a dead (después de
+// maxAttempts intentos). La entrega es al menos una vez.
+type Dispatcher struct {
+	repo        *Repository
+	maxAttempts int
+	retryDelay  time.Duration
+
+	mu   sync.RWMutex
+	subs []subscription
+}
+
+func NewDispatcher(db *gorm.DB, maxAttempts int, retryDelay time.Duration) *Dispatcher {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &Dispatcher{repo: NewRepository(db), maxAttempts: maxAttempts, retryDelay: retryDelay}
+}
+
+// Subscribe registra handler para los tipos indicados (o todos si no se indica ninguno).
+func (d *Dispatcher) Subscribe(name string, handler Handler, types ...string) {
+	s := subscription{name: name, handle: handler}
+	if len(types) > 0 {
+		s.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+
+	d.mu.Lock()
+	d.subs = append(d.subs, s)
+	d.mu.Unlock()
+}
+
+// Run despacha cada interval hasta que se cancele ctx.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.Dispatch(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("outbox: %v", err)
+			}
+		}
+	}
+}
+
+// Dispatch entrega los eventos pendientes hasta vaciar la cola o toparse con uno que todavía no
+// toca reintentar. Devuelve cuántos se entregaron.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	delivered := 0
+
+	for ctx.Err() == nil {
+		now := time.Now()
+
+		e, err := d.repo.Head(ctx)
+		if err != nil {
+			return delivered, err
+		}
+		if e == nil || e.NextAttemptAt.After(now) {
+			return delivered, nil
+		}
+
+		ok, err := d.repo.Claim(ctx, e, now, now.Add(claimFor))
+		if err != nil {
+			return delivered, err
+		}
+		if !ok {
+			// Lo está entregando otra instancia.
+			return delivered, nil
+		}
+
+		if err := d.deliver(ctx, e); err != nil {
+			attempts := e.Attempts + 1
+			if attempts >= d.maxAttempts {
+				log.Printf("outbox: event %d (%s) dead after %d attempts: %v", e.ID, e.Type, attempts, err)
+				if err := d.repo.MarkFailed(ctx, e, StatusDead, now, err.Error()); err != nil {
+					return delivered, err
+				}
+				continue
+			}
+
+			wait := d.retryDelay << (attempts - 1)
+			if wait <= 0 || wait > maxRetryDelay {
+				wait = maxRetryDelay
+			}
+			if err := d.repo.MarkFailed(ctx, e, StatusPending, now.Add(wait), err.Error()); err != nil {
+				return delivered, err
+			}
+			return delivered, nil
+		}
+
+		if err := d.repo.MarkDelivered(ctx, e, time.Now()); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	return delivered, ctx.Err()
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, e *Event) error {
+	d.mu.RLock()
+	subs := d.subs
+	d.mu.RUnlock()
+
+	for _, s := range subs {
+		if s.types != nil && !s.types[e.Type] {
+			continue
+		}
+		if err := call(ctx, s, e); err != nil {
+			return fmt.Errorf("%s: %w", s.name, err)
+		}
+	}
+
+	return nil
+}
+
+// call aísla al dispatcher de un suscriptor que entra en pánico: cuenta como un intento fallido.
+func call(ctx context.Context, s subscription, e *Event) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return s.handle(ctx, e)
+}
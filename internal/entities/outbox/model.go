@@ -0,0 +1,66 @@
+package outbox
+
+import "time"
+
+// Tipos de evento de dominio. El nombre es <agregado>.<hecho> y es lo que ven los suscriptores.
+const (
+	TransactionPosted = "transaction.posted"
+
+	HoldCreated  = "hold.created"
+	HoldCaptured = "hold.captured"
+	HoldVoided   = "hold.voided"
+	HoldExpired  = "hold.expired"
+
+	AccountCreated  = "account.created"
+	AccountFrozen   = "account.frozen"
+	AccountUnfrozen = "account.unfrozen"
+
+	UserCreated        = "user.created"
+	UserLocked         = "user.locked"
+	UserUnlocked       = "user.unlocked"
+	UserRoleChanged    = "user.role_changed"
+	UserKYCTierChanged = "user.kyc_tier_changed"
+)
+
+// Types son todos los tipos de evento que se publican.
+var Types = []string{
+	TransactionPosted,
+	HoldCreated, HoldCaptured, HoldVoided, HoldExpired,
+	AccountCreated, AccountFrozen, AccountUnfrozen,
+	UserCreated, UserLocked, UserUnlocked, UserRoleChanged, UserKYCTierChanged,
+}
+
+func KnownType(t string) bool {
+	for _, known := range Types {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+// Event es un hecho de dominio escrito en la misma transacción que lo produjo. UserID es el dueño
+// del agregado (quien puede enterarse por webhook); Payload es JSON.
+type Event struct {
+	ID            uint       `json:"id" gorm:"primaryKey;index:idx_outbox_status,priority:2"`
+	Type          string     `json:"type" gorm:"size:50;not null"`
+	AggregateType string     `json:"aggregate_type" gorm:"size:30;not null"`
+	AggregateID   uint       `json:"aggregate_id" gorm:"not null"`
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"`
+	Status        string     `json:"status" gorm:"size:20;not null;default:pending;index:idx_outbox_status,priority:1"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	LastError     string     `json:"last_error" gorm:"size:500"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time
+}
+
+func (Event) TableName() string { return "outbox" }
@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrEventNotFound = errors.New("event not found")
+
+// Append escribe un evento con db, que tiene que ser la transacción de la operación que lo
+// produjo: si la operación hace rollback, el evento no existe.
+func Append(ctx context.Context, db *gorm.DB, eventType, aggregateType string, aggregateID, userID uint, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return db.WithContext(ctx).Create(&Event{
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		UserID:        userID,
+		Payload:       string(raw),
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}).Error
+}
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
+
+// Head devuelve el evento pendiente más viejo, o nil si no hay.
+func (r *Repository) Head(ctx context.Context) (*Event, error) {
+	var e Event
+
+	err := r.db.WithContext(ctx).
+		Where("status = ?", StatusPending).
+		Order("id").
+		Limit(1).
+		Find(&e).Error
+	if err != nil {
+		return nil, err
+	}
+	if e.ID == 0 {
+		return nil, nil
+	}
+
+	return &e, nil
+}
+
+// Claim toma el evento hasta until con un compare-and-swap: con varias instancias despachando,
+// solo una lo entrega.
+func (r *Repository) Claim(ctx context.Context, e *Event, now, until time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&Event{}).
+		Where("id = ? AND status = ? AND (locked_until IS NULL OR locked_until < ?)", e.ID, StatusPending, now).
+		Update("locked_until", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *Repository) MarkDelivered(ctx context.Context, e *Event, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&Event{}).
+		Where("id = ?", e.ID).
+		Updates(map[string]interface{}{
+			"status":       StatusDelivered,
+			"attempts":     e.Attempts + 1,
+			"delivered_at": at,
+			"locked_until": nil,
+			"last_error":   "",
+		}).Error
+}
+
+// MarkFailed registra un intento fallido: el evento vuelve a la cola para next o, si status es
+// StatusDead, queda fuera de ella.
+func (r *Repository) MarkFailed(ctx context.Context, e *Event, status string, next time.Time, lastErr string) error {
+	if len(lastErr) > 500 {
+		lastErr = lastErr[:500]
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&Event{}).
+		Where("id = ?", e.ID).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"attempts":        e.Attempts + 1,
+			"next_attempt_at": next,
+			"locked_until":    nil,
+			"last_error":      lastErr,
+		}).Error
+}
+
+// Requeue devuelve a la cola un evento muerto, con los intentos en cero.
+func (r *Repository) Requeue(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).
+		Model(&Event{}).
+		Where("id = ? AND status = ?", id, StatusDead).
+		Updates(map[string]interface{}{
+			"status":          StatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"last_error":      "",
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEventNotFound
+	}
+	return nil
+}
+
+func (r *Repository) Dead(ctx context.Context, limit int) ([]*Event, error) {
+	events := []*Event{}
+	err := r.db.WithContext(ctx).Where("status = ?", StatusDead).Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
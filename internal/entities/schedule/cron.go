@@ -0,0 +1,199 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+// Cron es una expresión de 5 campos (minuto hora día-del-mes mes día-de-la-semana) con la sintaxis
+// habitual: *, listas (1,15), rangos (1-5) y pasos (*/15, 9-17/2). También acepta @hourly,
+// @daily, @weekly, @monthly y @yearly.
+type Cron struct {
+	minute, hour, dom, month, dow uint64 // bitsets
+	hourStar, domStar, dowStar    bool
+}
+
+var cronMacros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	if m, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = m
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields", ErrInvalidCron)
+	}
+
+	var (
+		c   Cron
+		err error
+	)
+
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+
+	// 7 también es domingo.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	c.hourStar = fields[1] == "*"
+	c.domStar = fields[2] == "*"
+	c.dowStar = fields[4] == "*"
+
+	return &c, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%w: bad step %q", ErrInvalidCron, part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			a, err1 := strconv.Atoi(bounds[0])
+			b, err2 := strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil || a > b {
+				return 0, fmt.Errorf("%w: bad range %q", ErrInvalidCron, part)
+			}
+			lo, hi = a, b
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("%w: bad value %q", ErrInvalidCron, part)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max // "5/15" = desde 5 cada 15
+			}
+		}
+
+		if lo < min || hi > max {
+			return 0, fmt.Errorf("%w: %q out of range %d-%d", ErrInvalidCron, part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next devuelve la primera ocurrencia estrictamente posterior a t, en la zona horaria de t.
+// Devuelve el tiempo cero si no hay ninguna en los próximos 5 años (p. ej. "0 0 30 2 *").
+//
+// En los cambios de horario cuenta la hora del reloj: una hora que el adelanto se saltea no ocurre
+// ese día, y una que el atraso repite ocurre una sola vez, la primera (salvo con hora "*", que
+// corre cada hora real).
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = startOfDay(t.Year(), t.Month()+1, 1, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = startOfDay(t.Year(), t.Month(), t.Day()+1, t.Location())
+			continue
+		}
+		// Se avanza en tiempo absoluto: time.Date normaliza una hora que no existe hacia atrás y el
+		// ciclo no avanzaría nunca.
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 || (!c.hourStar && repeatedWallTime(t)) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// startOfDay devuelve el primer momento del día. Donde la hora se adelanta a la medianoche, las
+// 00:00 no existen y time.Date las lleva al día anterior: el día empieza con el cambio de hora.
+func startOfDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+
+	want := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC).Before(want) {
+		_, t = t.ZoneBounds()
+	}
+	return t
+}
+
+// repeatedWallTime indica si t es la segunda vez que el reloj marca esa hora: cae en el tramo que
+// se repite cuando se atrasa la hora.
+func repeatedWallTime(t time.Time) bool {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return false
+	}
+
+	_, before := start.Add(-time.Second).Zone()
+	_, after := t.Zone()
+	return before > after && t.Sub(start) < time.Duration(before-after)*time.Second
+}
+
+// Como en cron clásico: si día del mes y día de la semana están ambos restringidos, alcanza con
+// que coincida uno de los dos.
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case c.domStar && c.dowStar:
+		return true
+	case c.domStar:
+		return dow
+	case c.dowStar:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,131 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr string
+		ok   bool
+	}{
+		{"* * * * *", true},
+		{"0 9 * * 1-5", true},
+		{"*/15 9-17/2 1,15 * *", true},
+		{"5/15 * * * *", true},
+		{"0 0 * * 7", true},
+		{"@daily", true},
+		{"@HOURLY", true},
+		{"  30 2 * * *  ", true},
+
+		{"", false},
+		{"* * * *", false},
+		{"* * * * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"* * * * 8", false},
+		{"*/0 * * * *", false},
+		{"5-1 * * * *", false},
+		{"a * * * *", false},
+		{"1-x * * * *", false},
+		{"@every 5m", false},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseCron(tt.expr)
+		if tt.ok && err != nil {
+			t.Errorf("ParseCron(%q) = %v, want ok", tt.expr, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("ParseCron(%q) = %v, want ErrInvalidCron", tt.expr, err)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	load := func(name string) *time.Location {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return loc
+	}
+	utc := time.UTC
+	ny := load("America/New_York")
+	santiago := load("America/Santiago")
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  []time.Time // ocurrencias seguidas a partir de after
+	}{
+		{"every minute is strictly after", "* * * * *", time.Date(2026, 1, 1, 10, 0, 30, 0, utc),
+			[]time.Time{time.Date(2026, 1, 1, 10, 1, 0, 0, utc), time.Date(2026, 1, 1, 10, 2, 0, 0, utc)}},
+		{"weekdays at nine", "0 9 * * 1-5", time.Date(2026, 1, 2, 9, 0, 0, 0, utc), // viernes
+			[]time.Time{time.Date(2026, 1, 5, 9, 0, 0, 0, utc), time.Date(2026, 1, 6, 9, 0, 0, 0, utc)}},
+		{"steps", "*/20 9-10 * * *", time.Date(2026, 1, 1, 9, 45, 0, 0, utc),
+			[]time.Time{time.Date(2026, 1, 1, 10, 0, 0, 0, utc), time.Date(2026, 1, 1, 10, 20, 0, 0, utc)}},
+		{"day of month or day of week", "0 0 13 * 5", time.Date(2026, 3, 1, 0, 0, 0, 0, utc),
+			[]time.Time{time.Date(2026, 3, 6, 0, 0, 0, 0, utc), time.Date(2026, 3, 13, 0, 0, 0, 0, utc), time.Date(2026, 3, 20, 0, 0, 0, 0, utc)}},
+		{"sunday as 7", "0 12 * * 7", time.Date(2026, 1, 1, 0, 0, 0, 0, utc),
+			[]time.Time{time.Date(2026, 1, 4, 12, 0, 0, 0, utc)}},
+		{"end of month", "0 0 31 * *", time.Date(2026, 1, 31, 0, 0, 0, 0, utc),
+			[]time.Time{time.Date(2026, 3, 31, 0, 0, 0, 0, utc)}},
+		{"leap day", "0 0 29 2 *", time.Date(2026, 1, 1, 0, 0, 0, 0, utc),
+			[]time.Time{time.Date(2028, 2, 29, 0, 0, 0, 0, utc)}},
+		{"never", "0 0 30 2 *", time.Date(2026, 1, 1, 0, 0, 0, 0, utc),
+			[]time.Time{{}}},
+
+		// 2026-03-08, Nueva York: de 01:59 EST se pasa a 03:00 EDT.
+		{"spring forward skips the missing hour", "30 2 * * *", time.Date(2026, 3, 8, 0, 0, 0, 0, ny),
+			[]time.Time{time.Date(2026, 3, 9, 2, 30, 0, 0, ny)}},
+		{"spring forward keeps later hours", "0 3 * * *", time.Date(2026, 3, 8, 0, 0, 0, 0, ny),
+			[]time.Time{time.Date(2026, 3, 8, 3, 0, 0, 0, ny), time.Date(2026, 3, 9, 3, 0, 0, 0, ny)}},
+		{"spring forward hourly", "0 * * * *", time.Date(2026, 3, 8, 0, 30, 0, 0, ny),
+			[]time.Time{time.Date(2026, 3, 8, 1, 0, 0, 0, ny), time.Date(2026, 3, 8, 3, 0, 0, 0, ny)}},
+
+		// 2026-11-01, Nueva York: de 01:59 EDT se vuelve a 01:00 EST.
+		{"fall back runs the repeated hour once", "0 1 * * *", time.Date(2026, 11, 1, 0, 30, 0, 0, ny),
+			[]time.Time{time.Date(2026, 11, 1, 5, 0, 0, 0, utc), time.Date(2026, 11, 2, 1, 0, 0, 0, ny)}},
+		{"fall back hourly runs both", "0 * * * *", time.Date(2026, 11, 1, 0, 30, 0, 0, ny),
+			[]time.Time{time.Date(2026, 11, 1, 5, 0, 0, 0, utc), time.Date(2026, 11, 1, 6, 0, 0, 0, utc), time.Date(2026, 11, 1, 7, 0, 0, 0, utc)}},
+
+		// 2026-09-06, Santiago: la medianoche no existe, el día empieza a la 01:00.
+		{"missing midnight", "0 12 * * *", time.Date(2026, 9, 5, 13, 0, 0, 0, santiago),
+			[]time.Time{time.Date(2026, 9, 6, 12, 0, 0, 0, santiago)}},
+		{"missing midnight skips it", "0 0 * * *", time.Date(2026, 9, 5, 13, 0, 0, 0, santiago),
+			[]time.Time{time.Date(2026, 9, 7, 0, 0, 0, 0, santiago)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			after := tt.after
+			for i, want := range tt.want {
+				done := make(chan time.Time, 1)
+				go func() { done <- c.Next(after) }()
+
+				var got time.Time
+				select {
+				case got = <-done:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("Next(%v) did not return", after)
+				}
+
+				if !got.Equal(want) {
+					t.Fatalf("occurrence %d after %v = %v, want %v", i+1, after, got, want)
+				}
+				after = got
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/httputil"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+)
+
+// CreateScheduleRequest: sin Cron ni Interval es un pago único en RunAt. Con Cron ("0 9 1 * *") o
+// Interval (duración de Go, p. ej. "168h") se repite hasta EndAt, si lo hay.
+type CreateScheduleRequest struct {
+	FromAccountID uint          `json:"fromAccountId" validate:"required,nefield=ToAccountID"`
+	ToAccountID   uint          `json:"toAccountId"   validate:"required"`
+	Amount        money.Decimal `json:"amount"        validate:"required,amount"`
+	Currency      string        `json:"currency"      validate:"required,iso4217"`
+	RunAt         *time.Time    `json:"runAt"`
+	Cron          string        `json:"cron"     validate:"omitempty,max=100"`
+	Interval      string        `json:"interval" validate:"omitempty,max=20"`
+	Timezone      string        `json:"timezone" validate:"omitempty,max=64"`
+	EndAt         *time.Time    `json:"endAt"`
+}
+
+// UpdateScheduleRequest solo toca los campos presentes. Status acepta active o paused.
+type UpdateScheduleRequest struct {
+	Amount   *money.Decimal `json:"amount"`
+	Cron     *string        `json:"cron"`
+	Interval *string        `json:"interval"`
+	Timezone *string        `json:"timezone"`
+	EndAt    *time.Time     `json:"endAt"`
+	Status   *string        `json:"status"`
+}
+
+type ScheduleResponse struct {
+	ID                uint          `json:"id"`
+	FromAccountID     uint          `json:"fromAccountId"`
+	ToAccountID       uint          `json:"toAccountId"`
+	Amount            money.Decimal `json:"amount"`
+	Currency          string        `json:"currency"`
+	Cron              string        `json:"cron,omitempty"`
+	Interval          string        `json:"interval,omitempty"`
+	Timezone          string        `json:"timezone"`
+	EndAt             *string       `json:"endAt"`
+	NextRunAt         *string       `json:"nextRunAt"`
+	RetryAt           *string       `json:"retryAt"`
+	Attempts          int           `json:"attempts"`
+	Status            string        `json:"status"`
+	Runs              int           `json:"runs"`
+	LastRunAt         *string       `json:"lastRunAt"`
+	LastTransactionID *uint         `json:"lastTransactionId"`
+	LastError         string        `json:"lastError,omitempty"`
+	CreatedAt         string        `json:"createdAt"`
+}
+
+func ToResponse(s *Schedule) *ScheduleResponse {
+	resp := &ScheduleResponse{
+		ID:                s.ID,
+		FromAccountID:     s.FromAccountID,
+		ToAccountID:       s.ToAccountID,
+		Amount:            money.New(s.Amount, s.Currency).Decimal(),
+		Currency:          s.Currency,
+		Cron:              s.Cron,
+		Timezone:          s.Timezone,
+		EndAt:             formatOptional(s.EndAt),
+		NextRunAt:         formatOptional(s.NextRunAt),
+		RetryAt:           formatOptional(s.RetryAt),
+		Attempts:          s.Attempts,
+		Status:            s.Status,
+		Runs:              s.Runs,
+		LastRunAt:         formatOptional(s.LastRunAt),
+		LastTransactionID: s.LastTxID,
+		LastError:         s.LastError,
+		CreatedAt:         httputil.FormatDate(&s.CreatedAt),
+	}
+
+	if s.IntervalSeconds > 0 {
+		resp.Interval = (time.Duration(s.IntervalSeconds) * time.Second).String()
+	}
+
+	return resp
+}
+
+func ToResponseMany(schedules []*Schedule) []*ScheduleResponse {
+	response := make([]*ScheduleResponse, len(schedules))
+
+	for i, s := range schedules {
+		response[i] = ToResponse(s)
+	}
+
+	return response
+}
+
+func formatOptional(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := httputil.FormatDate(t)
+	return &s
+}
@@ -0,0 +1,162 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sebaactis/wallet-go-api/internal/authz"
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/entities/wallet"
+	"github.com/sebaactis/wallet-go-api/internal/httputil"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+	"github.com/sebaactis/wallet-go-api/internal/validation"
+)
+
+type HTTPHandler struct {
+	service   *Service
+	accrepo   *account.Repository
+	validator validation.StructValidator
+}
+
+func NewHTTPHandler(service *Service, accrepo *account.Repository, validator validation.StructValidator) *HTTPHandler {
+	return &HTTPHandler{service: service, accrepo: accrepo, validator: validator}
+}
+
+// POST /v1/wallet/schedules
+func (h *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateScheduleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid json", nil)
+		return
+	}
+
+	if fields, ok := h.validator.ValidateStruct(&req); !ok {
+		httputil.WriteError(w, http.StatusBadRequest, "validation error", fields)
+		return
+	}
+
+	acc, err := h.accrepo.FindByID(r.Context(), req.FromAccountID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusNotFound, "account not found", nil)
+		return
+	}
+
+	if err := authz.Authorize(r.Context(), authz.OperateAccount, authz.Resource{OwnerID: acc.UserID}); err != nil {
+		httputil.WriteError(w, authz.Status(err), err.Error(), nil)
+		return
+	}
+
+	sch, err := h.service.Create(r.Context(), acc.UserID, &req)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, ToResponse(sch))
+}
+
+// GET /v1/wallet/schedules
+func (h *HTTPHandler) List(w http.ResponseWriter, r *http.Request) {
+	subject, ok := authz.SubjectFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	schedules, err := h.service.List(r.Context(), subject.UserID)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, ToResponseMany(schedules))
+}
+
+// GET /v1/wallet/schedules/{id}
+func (h *HTTPHandler) Get(w http.ResponseWriter, r *http.Request) {
+	sch, ok := h.load(w, r, authz.ReadAccount)
+	if !ok {
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, ToResponse(sch))
+}
+
+// PATCH /v1/wallet/schedules/{id}
+func (h *HTTPHandler) Update(w http.ResponseWriter, r *http.Request) {
+	sch, ok := h.load(w, r, authz.OperateAccount)
+	if !ok {
+		return
+	}
+
+	var req UpdateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid json", nil)
+		return
+	}
+
+	sch, err := h.service.Update(r.Context(), sch, &req)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, ToResponse(sch))
+}
+
+// DELETE /v1/wallet/schedules/{id}
+func (h *HTTPHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	sch, ok := h.load(w, r, authz.OperateAccount)
+	if !ok {
+		return
+	}
+
+	sch, err := h.service.Cancel(r.Context(), sch)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, ToResponse(sch))
+}
+
+func (h *HTTPHandler) load(w http.ResponseWriter, r *http.Request, action authz.Action) (*Schedule, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid id", nil)
+		return nil, false
+	}
+
+	sch, err := h.service.Get(r.Context(), uint(id))
+	if err != nil {
+		writeErr(w, err)
+		return nil, false
+	}
+
+	if err := authz.Authorize(r.Context(), action, authz.Resource{OwnerID: sch.UserID}); err != nil {
+		httputil.WriteError(w, authz.Status(err), err.Error(), nil)
+		return nil, false
+	}
+
+	return sch, true
+}
+
+func writeErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrScheduleNotFound), errors.Is(err, wallet.ErrAccountNotFound):
+		httputil.WriteError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrNotModifiable):
+		httputil.WriteError(w, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, ErrInvalidSchedule), errors.Is(err, ErrInvalidCron),
+		errors.Is(err, wallet.ErrCurrencyMismatch), errors.Is(err, wallet.ErrSameAccount),
+		errors.Is(err, wallet.ErrNegativeAmount),
+		errors.Is(err, money.ErrInvalidAmount), errors.Is(err, money.ErrTooManyDecimals), errors.Is(err, money.ErrOverflow):
+		httputil.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		httputil.WriteError(w, http.StatusInternalServerError, "internal error", nil)
+	}
+}
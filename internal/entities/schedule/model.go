@@ -0,0 +1,73 @@
+package schedule
+
+import "time"
+
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+	StatusFailed    = "failed"
+)
+
+// Schedule es una transferencia programada: única (solo RunAt), recurrente por expresión cron o
+// cada IntervalSeconds. NextRunAt es la ocurrencia pendiente; queda en nil cuando no hay más.
+type Schedule struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	FromAccountID   uint       `json:"from_account_id" gorm:"not null"`
+	ToAccountID     uint       `json:"to_account_id" gorm:"not null"`
+	Amount          int64      `json:"amount" gorm:"not null"` // unidades menores
+	Currency        string     `json:"currency" gorm:"size:3;not null"`
+	Cron            string     `json:"cron" gorm:"size:100"`
+	IntervalSeconds int64      `json:"interval_seconds" gorm:"not null;default:0"`
+	Timezone        string     `json:"timezone" gorm:"size:64;not null;default:UTC"`
+	StartAt         time.Time  `json:"start_at" gorm:"not null"`
+	EndAt           *time.Time `json:"end_at"`
+	NextRunAt       *time.Time `json:"next_run_at" gorm:"index"`
+	RetryAt         *time.Time `json:"retry_at"` // reintento de la ocurrencia actual por falta de fondos
+	Attempts        int        `json:"attempts" gorm:"not null;default:0"`
+	Status          string     `json:"status" gorm:"size:20;not null;index"`
+	Runs            int        `json:"runs" gorm:"not null;default:0"`
+	LastRunAt       *time.Time `json:"last_run_at"`
+	LastTxID        *uint      `json:"last_transaction_id" gorm:"column:last_transaction_id"`
+	LastError       string     `json:"last_error" gorm:"size:255"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (s *Schedule) Recurring() bool {
+	return s.Cron != "" || s.IntervalSeconds > 0
+}
+
+// next calcula la ocurrencia siguiente a after, o nil si ya no quedan.
+func (s *Schedule) next(after time.Time) (*time.Time, error) {
+	var t time.Time
+
+	switch {
+	case s.Cron != "":
+		c, err := ParseCron(s.Cron)
+		if err != nil {
+			return nil, err
+		}
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		t = c.Next(after.In(loc))
+		if t.IsZero() {
+			return nil, nil
+		}
+	case s.IntervalSeconds > 0:
+		t = after.Add(time.Duration(s.IntervalSeconds) * time.Second)
+	default:
+		return nil, nil
+	}
+
+	if s.EndAt != nil && t.After(*s.EndAt) {
+		return nil, nil
+	}
+
+	t = t.UTC()
+	return &t, nil
+}
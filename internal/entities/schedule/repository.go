@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
+
+func (r *Repository) Create(ctx context.Context, s *Schedule) error {
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+func (r *Repository) FindByID(ctx context.Context, id uint) (*Schedule, error) {
+	var s Schedule
+
+	if err := r.db.WithContext(ctx).First(&s, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *Repository) FindByUser(ctx context.Context, userID uint) ([]*Schedule, error) {
+	schedules := []*Schedule{}
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Find(&schedules).Error
+
+	return schedules, err
+}
+
+func (r *Repository) Save(ctx context.Context, s *Schedule) error {
+	return r.db.WithContext(ctx).Save(s).Error
+}
+
+// Due devuelve las programaciones activas cuya ocurrencia (o reintento) ya venció.
+func (r *Repository) Due(ctx context.Context, now time.Time, limit int) ([]*Schedule, error) {
+	var schedules []*Schedule
+
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_run_at <= ? AND (retry_at IS NULL OR retry_at <= ?)", StatusActive, now, now).
+		Order("next_run_at").
+		Limit(limit).
+		Find(&schedules).Error
+
+	return schedules, err
+}
+
+// Advance guarda el resultado de una ejecución solo si nadie la procesó antes: la condición sobre
+// next_run_at y attempts hace de compare-and-swap entre instancias.
+func (r *Repository) Advance(ctx context.Context, s *Schedule, occurrence time.Time, attempts int, updates map[string]interface{}) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&Schedule{}).
+		Where("id = ? AND next_run_at = ? AND attempts = ? AND status = ?", s.ID, occurrence, attempts, StatusActive).
+		Updates(updates)
+
+	return result.RowsAffected == 1, result.Error
+}
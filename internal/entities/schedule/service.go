@@ -0,0 +1,419 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/entities/wallet"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+)
+
+const (
+	minInterval  = time.Minute
+	dueBatchSize = 50
+)
+
+var (
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	ErrNotModifiable   = errors.New("schedule can no longer be modified")
+)
+
+type Service struct {
+	repo     *Repository
+	wallet   *wallet.Service
+	accounts *account.Repository
+
+	maxAttempts int
+	retryDelay  time.Duration
+}
+
+// maxAttempts y retryDelay gobiernan los reintentos por falta de fondos: la ocurrencia se
+// reintenta hasta maxAttempts veces con espera creciente y, si sigue sin fondos, se saltea.
+func NewService(repo *Repository, wallet *wallet.Service, accounts *account.Repository, maxAttempts int, retryDelay time.Duration) *Service {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	return &Service{repo: repo, wallet: wallet, accounts: accounts, maxAttempts: maxAttempts, retryDelay: retryDelay}
+}
+
+func (s *Service) Create(ctx context.Context, userID uint, req *CreateScheduleRequest) (*Schedule, error) {
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+
+	amount, err := req.Amount.Money(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.IsPositive() {
+		return nil, wallet.ErrNegativeAmount
+	}
+	if req.FromAccountID == req.ToAccountID {
+		return nil, wallet.ErrSameAccount
+	}
+
+	if err := s.checkAccounts(ctx, req.FromAccountID, req.ToAccountID, req.Currency); err != nil {
+		return nil, err
+	}
+
+	sch := &Schedule{
+		UserID:        userID,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        amount.Amount,
+		Currency:      req.Currency,
+		Status:        StatusActive,
+		EndAt:         req.EndAt,
+	}
+
+	if err := applyRule(sch, req.Cron, req.Interval, req.Timezone); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	switch {
+	case req.RunAt != nil:
+		if !req.RunAt.After(now) {
+			return nil, fmt.Errorf("%w: runAt must be in the future", ErrInvalidSchedule)
+		}
+		first := req.RunAt.UTC().Truncate(time.Second)
+		sch.NextRunAt = &first
+	case sch.Cron != "":
+		if sch.NextRunAt, err = sch.next(now); err != nil {
+			return nil, err
+		}
+	case sch.IntervalSeconds > 0:
+		first := now.UTC().Truncate(time.Second)
+		sch.NextRunAt = &first
+	default:
+		return nil, fmt.Errorf("%w: runAt is required for one-off schedules", ErrInvalidSchedule)
+	}
+
+	if sch.NextRunAt == nil || (sch.EndAt != nil && sch.NextRunAt.After(*sch.EndAt)) {
+		return nil, fmt.Errorf("%w: no occurrence before endAt", ErrInvalidSchedule)
+	}
+	sch.StartAt = *sch.NextRunAt
+
+	if err := s.repo.Create(ctx, sch); err != nil {
+		return nil, err
+	}
+
+	return sch, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uint) (*Schedule, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *Service) List(ctx context.Context, userID uint) ([]*Schedule, error) {
+	return s.repo.FindByUser(ctx, userID)
+}
+
+func (s *Service) Update(ctx context.Context, sch *Schedule, req *UpdateScheduleRequest) (*Schedule, error) {
+	if sch.Status != StatusActive && sch.Status != StatusPaused {
+		return nil, ErrNotModifiable
+	}
+
+	if req.Amount != nil {
+		amount, err := req.Amount.Money(sch.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if !amount.IsPositive() {
+			return nil, wallet.ErrNegativeAmount
+		}
+		sch.Amount = amount.Amount
+	}
+
+	ruleChanged := req.Cron != nil || req.Interval != nil || req.Timezone != nil
+	if ruleChanged {
+		cron, interval, tz := sch.Cron, "", sch.Timezone
+		if sch.IntervalSeconds > 0 {
+			interval = (time.Duration(sch.IntervalSeconds) * time.Second).String()
+		}
+		if req.Cron != nil {
+			cron, interval = *req.Cron, ""
+		}
+		if req.Interval != nil {
+			interval, cron = *req.Interval, ""
+		}
+		if req.Timezone != nil {
+			tz = *req.Timezone
+		}
+		if err := applyRule(sch, cron, interval, tz); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.EndAt != nil {
+		sch.EndAt = req.EndAt
+	}
+
+	if req.Status != nil {
+		switch *req.Status {
+		case StatusActive, StatusPaused:
+			sch.Status = *req.Status
+		default:
+			return nil, fmt.Errorf("%w: status must be active or paused", ErrInvalidSchedule)
+		}
+	}
+
+	// Una regla nueva recalcula la próxima ocurrencia desde ahora; si se reanuda una programación
+	// con la ocurrencia vencida, se corre en el próximo ciclo.
+	if ruleChanged && sch.Recurring() {
+		next, err := sch.next(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		sch.NextRunAt = next
+		sch.Attempts, sch.RetryAt = 0, nil
+	}
+
+	if sch.NextRunAt == nil || (sch.EndAt != nil && sch.NextRunAt.After(*sch.EndAt)) {
+		return nil, fmt.Errorf("%w: no occurrence before endAt", ErrInvalidSchedule)
+	}
+
+	if err := s.repo.Save(ctx, sch); err != nil {
+		return nil, err
+	}
+
+	return sch, nil
+}
+
+func (s *Service) Cancel(ctx context.Context, sch *Schedule) (*Schedule, error) {
+	if sch.Status != StatusActive && sch.Status != StatusPaused {
+		return nil, ErrNotModifiable
+	}
+
+	sch.Status = StatusCancelled
+	sch.NextRunAt, sch.RetryAt = nil, nil
+
+	if err := s.repo.Save(ctx, sch); err != nil {
+		return nil, err
+	}
+
+	return sch, nil
+}
+
+// RunDue ejecuta las ocurrencias vencidas. Si ctx se cancela deja de tomar nuevas, pero la que
+// está en curso termina (la transferencia no se corta a mitad de camino).
+func (s *Service) RunDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.Due(ctx, now, dueBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	executed := 0
+	for _, sch := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if s.execute(context.WithoutCancel(ctx), sch, now) {
+			executed++
+		}
+	}
+
+	return executed, nil
+}
+
+// Run corre el scheduler cada interval hasta que se cancele ctx. Con interval 0 no corre.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunDue(ctx, time.Now()); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("scheduler: %v", err)
+			}
+		}
+	}
+}
+
+// OccurrenceKey es la Idempotency-Key de una ocurrencia: si dos instancias (o un reintento tras
+// una caída) ejecutan la misma, wallet devuelve la transferencia ya hecha en vez de duplicarla.
+func OccurrenceKey(sch *Schedule, occurrence time.Time) string {
+	return fmt.Sprintf("schedule:%d:%d", sch.ID, occurrence.Unix())
+}
+
+func (s *Service) execute(ctx context.Context, sch *Schedule, now time.Time) bool {
+	occurrence := *sch.NextRunAt
+
+	t, err := s.wallet.Transfer(ctx, &wallet.TransferRequest{
+		FromAccountID: sch.FromAccountID,
+		ToAccountID:   sch.ToAccountID,
+		Amount:        money.New(sch.Amount, sch.Currency).Decimal(),
+		Currency:      sch.Currency,
+	}, OccurrenceKey(sch, occurrence))
+
+	updates := map[string]interface{}{}
+
+	switch {
+	case err == nil:
+		updates["runs"] = sch.Runs + 1
+		updates["last_run_at"] = now
+		updates["last_transaction_id"] = t.ID
+		updates["last_error"] = ""
+		s.advance(sch, occurrence, now, updates)
+
+	case errors.Is(err, wallet.ErrInsufficientFunds), errors.Is(err, wallet.ErrAccountFrozen):
+		// Una cuenta congelada puede descongelarse: se reintenta como la falta de fondos.
+		reason := "insufficient funds"
+		if errors.Is(err, wallet.ErrAccountFrozen) {
+			reason = "account frozen"
+		}
+
+		attempts := sch.Attempts + 1
+		if attempts < s.maxAttempts {
+			retryAt := now.Add(s.retryDelay << (attempts - 1))
+			updates["attempts"] = attempts
+			updates["retry_at"] = retryAt
+			updates["last_error"] = reason + ", retry scheduled"
+		} else {
+			updates["last_error"] = fmt.Sprintf("%s after %d attempts, occurrence skipped", reason, attempts)
+			s.advance(sch, occurrence, now, updates)
+			if !sch.Recurring() {
+				// una única que nunca se pudo ejecutar no está "completada"
+				updates["status"] = StatusFailed
+			}
+		}
+
+	case errors.Is(err, wallet.ErrLimitExceeded):
+		var limitErr *wallet.LimitError
+		if errors.As(err, &limitErr) && limitErr.ResetsAt != nil {
+			// El cupo vuelve cuando se reinicia el período: se reintenta entonces, sin gastar intentos.
+			updates["retry_at"] = *limitErr.ResetsAt
+			updates["last_error"] = lastError(err.Error() + ", retry scheduled")
+		} else {
+			// Supera el máximo por operación: con el mismo monto no va a pasar nunca.
+			updates["status"] = StatusFailed
+			updates["next_run_at"] = nil
+			updates["retry_at"] = nil
+			updates["last_error"] = lastError(err.Error())
+		}
+
+	case isPermanent(err):
+		updates["status"] = StatusFailed
+		updates["next_run_at"] = nil
+		updates["retry_at"] = nil
+		updates["last_error"] = lastError(err.Error())
+
+	default:
+		// Error transitorio (base caída, contexto) o sin clasificar: se reintenta más tarde sin gastar
+		// intentos. Tiene que salir de las vencidas, o con dueBatchSize de estas no correría ninguna otra.
+		log.Printf("scheduler: schedule %d: %v", sch.ID, err)
+		updates["retry_at"] = now.Add(max(s.retryDelay, minInterval))
+		updates["last_error"] = lastError(err.Error() + ", retry scheduled")
+	}
+
+	ok, err := s.repo.Advance(ctx, sch, occurrence, sch.Attempts, updates)
+	if err != nil {
+		log.Printf("scheduler: schedule %d: %v", sch.ID, err)
+		return false
+	}
+
+	return ok
+}
+
+// advance mueve la programación a la próxima ocurrencia. Las que se perdieron mientras el proceso
+// estuvo caído no se recuperan: se ejecuta una sola vez y se sigue desde ahora.
+func (s *Service) advance(sch *Schedule, occurrence, now time.Time, updates map[string]interface{}) {
+	next, err := sch.next(occurrence)
+	for err == nil && next != nil && !next.After(now) {
+		next, err = sch.next(*next)
+	}
+
+	updates["attempts"] = 0
+	updates["retry_at"] = nil
+
+	if err != nil || next == nil {
+		updates["next_run_at"] = nil
+		updates["status"] = StatusCompleted
+		return
+	}
+
+	updates["next_run_at"] = next.Truncate(time.Second)
+}
+
+func (s *Service) checkAccounts(ctx context.Context, fromID, toID uint, currency string) error {
+	for _, id := range []uint{fromID, toID} {
+		acc, err := s.accounts.FindByID(ctx, id)
+		if err != nil {
+			return wallet.ErrAccountNotFound
+		}
+		if acc.Currency != currency {
+			return wallet.ErrCurrencyMismatch
+		}
+	}
+	return nil
+}
+
+func applyRule(sch *Schedule, cron, interval, tz string) error {
+	cron, interval, tz = strings.TrimSpace(cron), strings.TrimSpace(interval), strings.TrimSpace(tz)
+
+	if cron != "" && interval != "" {
+		return fmt.Errorf("%w: use either cron or interval, not both", ErrInvalidSchedule)
+	}
+
+	if tz == "" {
+		tz = "UTC"
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, tz)
+	}
+
+	sch.Cron, sch.IntervalSeconds, sch.Timezone = "", 0, tz
+
+	if cron != "" {
+		if _, err := ParseCron(cron); err != nil {
+			return err
+		}
+		sch.Cron = cron
+	}
+
+	if interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < minInterval {
+			return fmt.Errorf("%w: interval must be a duration of at least %s", ErrInvalidSchedule, minInterval)
+		}
+		sch.IntervalSeconds = int64(d / time.Second)
+	}
+
+	return nil
+}
+
+// lastError recorta un mensaje a lo que entra en last_error, sin cortar una runa: si no entrara, el
+// resultado no se guardaría y la programación seguiría vencida.
+func lastError(msg string) string {
+	const size = 255
+	if len(msg) <= size {
+		return msg
+	}
+	n := size
+	for n > 0 && !utf8.RuneStart(msg[n]) {
+		n--
+	}
+	return msg[:n]
+}
+
+func isPermanent(err error) bool {
+	return errors.Is(err, wallet.ErrAccountNotFound) ||
+		errors.Is(err, wallet.ErrCurrencyMismatch) ||
+		errors.Is(err, wallet.ErrSameAccount) ||
+		errors.Is(err, wallet.ErrNegativeAmount) ||
+		errors.Is(err, money.ErrInvalidAmount) ||
+		errors.Is(err, money.ErrTooManyDecimals) ||
+		errors.Is(err, money.ErrOverflow)
+}
@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/entities/wallet"
+	"github.com/sebaactis/wallet-go-api/internal/platform/config"
+	"github.com/sebaactis/wallet-go-api/internal/platform/database"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "schedules.db") + "?_pragma=foreign_keys(ON)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	db, err := database.Open(config.Config{Driver: "sqlite", DSN: dsn, MaxOpenConns: 8, MaxIdleConns: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// Las programaciones que fallan sin ejecutarse (cuenta congelada o un error sin clasificar) salen
+// de las vencidas hasta su reintento: un lote entero de ellas no frena a las demás.
+func TestRunDueMovesFailingSchedulesOutOfTheBatch(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	walletService := wallet.NewService(db, nil, 0, 0)
+	svc := NewService(NewRepository(db), walletService, account.NewRepository(db), 3, time.Hour)
+
+	u := &user.User{Name: "ana", Email: "ana@x.io", Password: "x"}
+	if err := db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+	frozenAt := time.Now()
+	frozen := &account.Account{UserID: u.ID, Currency: "USD", FrozenAt: &frozenAt, FrozenReason: "reconciliation"}
+	from := &account.Account{UserID: u.ID, Currency: "USD"}
+	to := &account.Account{UserID: u.ID, Currency: "USD"}
+	for _, a := range []*account.Account{frozen, from, to} {
+		if err := db.Create(a).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := walletService.Deposit(ctx, &wallet.DepositRequest{AccountID: from.ID, Amount: "100", Currency: "USD"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	add := func(fromID uint, runAt time.Time) *Schedule {
+		sch := &Schedule{UserID: u.ID, FromAccountID: fromID, ToAccountID: to.ID, Amount: 100, Currency: "USD",
+			Timezone: "UTC", StartAt: runAt, NextRunAt: &runAt, Status: StatusActive}
+		if err := db.Create(sch).Error; err != nil {
+			t.Fatal(err)
+		}
+		return sch
+	}
+
+	for i := range dueBatchSize {
+		add(frozen.ID, now.Add(-2*time.Hour+time.Duration(i)*time.Second))
+	}
+	healthy := add(from.ID, now.Add(-time.Hour))
+
+	for range 2 {
+		if _, err := svc.RunDue(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := svc.Get(ctx, healthy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != StatusCompleted || got.Runs != 1 {
+		t.Errorf("healthy schedule status %s runs %d, want completed with 1", got.Status, got.Runs)
+	}
+
+	var stuck []*Schedule
+	if err := db.Where("from_account_id = ?", frozen.ID).Find(&stuck).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, sch := range stuck {
+		if sch.RetryAt == nil || !sch.RetryAt.After(now) || sch.Attempts != 1 || sch.LastError != "account frozen, retry scheduled" {
+			t.Fatalf("frozen schedule %d: retry %v attempts %d error %q, want a retry after now", sch.ID, sch.RetryAt, sch.Attempts, sch.LastError)
+		}
+	}
+
+	// Un error que no está clasificado (acá, una tabla que falta) también se reprograma.
+	broken := add(from.ID, now.Add(-time.Minute))
+	if err := db.Exec("ALTER TABLE fee_schedules RENAME TO fee_schedules_gone").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.RunDue(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = svc.Get(ctx, broken.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got.RetryAt == nil || !got.RetryAt.After(now) || got.Attempts != 0 || got.Status != StatusActive {
+		t.Errorf("broken schedule retry %v attempts %d status %s, want a retry after now without spending attempts", got.RetryAt, got.Attempts, got.Status)
+	}
+}
@@ -0,0 +1,35 @@
+package session
+
+import "github.com/sebaactis/wallet-go-api/internal/httputil"
+
+type SessionResponse struct {
+	ID         uint   `json:"id"`
+	UserAgent  string `json:"userAgent"`
+	IP         string `json:"ip"`
+	Current    bool   `json:"current"`
+	CreatedAt  string `json:"createdAt"`
+	LastSeenAt string `json:"lastSeenAt"`
+	ExpiresAt  string `json:"expiresAt"`
+}
+
+func ToResponse(s *Session, currentFamilyID string) *SessionResponse {
+	return &SessionResponse{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		Current:    currentFamilyID != "" && s.FamilyID == currentFamilyID,
+		CreatedAt:  httputil.FormatDate(&s.CreatedAt),
+		LastSeenAt: httputil.FormatDate(&s.LastSeenAt),
+		ExpiresAt:  httputil.FormatDate(&s.ExpiresAt),
+	}
+}
+
+func ToResponseMany(sessions []*Session, currentFamilyID string) []*SessionResponse {
+	response := make([]*SessionResponse, len(sessions))
+
+	for i, s := range sessions {
+		response[i] = ToResponse(s, currentFamilyID)
+	}
+
+	return response
+}
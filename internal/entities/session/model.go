@@ -0,0 +1,17 @@
+package session
+
+import "time"
+
+// Session es un login: agrupa los tokens de una misma familia y guarda desde dónde se usa.
+type Session struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID   string     `json:"family_id" gorm:"size:64;not null;uniqueIndex"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255"`
+	IP         string     `json:"ip" gorm:"size:64"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"default:null"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,92 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
+
+func (r *Repository) Create(ctx context.Context, s *Session) error {
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+func (r *Repository) FindActiveByUser(ctx context.Context, userID uint, now time.Time) ([]*Session, error) {
+	sessions := []*Session{}
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *Repository) FindByID(ctx context.Context, id uint) (*Session, error) {
+	var s Session
+
+	if err := r.db.WithContext(ctx).First(&s, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *Repository) FindByFamily(ctx context.Context, familyID string) (*Session, error) {
+	var s Session
+
+	if err := r.db.WithContext(ctx).Where("family_id = ?", familyID).First(&s).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *Repository) Touch(ctx context.Context, familyID string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("last_seen_at", at).Error
+}
+
+func (r *Repository) Extend(ctx context.Context, familyID string, at, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{
+			"last_seen_at": at,
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+func (r *Repository) RevokeByFamily(ctx context.Context, familyID string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+func (r *Repository) RevokeByUser(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Solo se escribe last_seen_at si pasó al menos este tiempo desde la última vez, para no sumar
+// un UPDATE a cada request autenticado.
+const touchEvery = time.Minute
+
+type Service struct {
+	repo *Repository
+
+	mu        sync.Mutex
+	lastTouch map[string]time.Time
+	lastPrune time.Time
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo, lastTouch: make(map[string]time.Time)}
+}
+
+func (s *Service) Start(ctx context.Context, userID uint, familyID, userAgent, ip string, expiresAt time.Time) (*Session, error) {
+	userAgent = truncate(userAgent, 255)
+
+	now := time.Now()
+	sess := &Session{
+		UserID:     userID,
+		FamilyID:   familyID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}
+
+	if err := s.repo.Create(ctx, sess); err != nil {
+		return nil, err
+	}
+
+	return sess, nil
+}
+
+func (s *Service) ListActive(ctx context.Context, userID uint) ([]*Session, error) {
+	return s.repo.FindActiveByUser(ctx, userID, time.Now())
+}
+
+// Get devuelve la sesión solo si pertenece al usuario; si no, responde como si no existiera.
+func (s *Service) Get(ctx context.Context, userID, id uint) (*Session, error) {
+	sess, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sess.UserID != userID || sess.RevokedAt != nil {
+		return nil, ErrSessionNotFound
+	}
+	return sess, nil
+}
+
+func (s *Service) Touch(ctx context.Context, familyID string) {
+	if familyID == "" {
+		return
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	if last, ok := s.lastTouch[familyID]; ok && now.Sub(last) < touchEvery {
+		s.mu.Unlock()
+		return
+	}
+	s.lastTouch[familyID] = now
+	s.prune(now)
+	s.mu.Unlock()
+
+	_ = s.repo.Touch(ctx, familyID, now)
+}
+
+// Extend se llama al rotar el refresh token: la sesión vive lo que vive su último refresh.
+func (s *Service) Extend(ctx context.Context, familyID string, expiresAt time.Time) error {
+	return s.repo.Extend(ctx, familyID, time.Now(), expiresAt)
+}
+
+func (s *Service) End(ctx context.Context, familyID string) error {
+	s.forget(familyID)
+	return s.repo.RevokeByFamily(ctx, familyID, time.Now())
+}
+
+func (s *Service) EndAllForUser(ctx context.Context, userID uint) error {
+	return s.repo.RevokeByUser(ctx, userID, time.Now())
+}
+
+// prune saca las sesiones que no se tocaron en touchEvery: su próximo Touch escribe igual, y sin
+// esto el mapa crece con cada sesión que expira o se abandona sin logout. Corre a lo sumo una vez
+// por touchEvery; se llama con s.mu tomado.
+func (s *Service) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < touchEvery {
+		return
+	}
+	s.lastPrune = now
+
+	for familyID, last := range s.lastTouch {
+		if now.Sub(last) >= touchEvery {
+			delete(s.lastTouch, familyID)
+		}
+	}
+}
+
+func (s *Service) forget(familyID string) {
+	s.mu.Lock()
+	delete(s.lastTouch, familyID)
+	s.mu.Unlock()
+}
+
+// truncate corta s a n bytes como máximo sin partir un carácter multibyte.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/platform/config"
+	"github.com/sebaactis/wallet-go-api/internal/platform/database"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "sessions.db") + "?_pragma=foreign_keys(ON)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	db, err := database.Open(config.Config{Driver: "sqlite", DSN: dsn, MaxOpenConns: 8, MaxIdleConns: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// Las sesiones que dejan de tocarse (expiradas, abandonadas sin logout) no quedan para siempre
+// en memoria.
+func TestTouchPrunesStaleSessions(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(NewRepository(openTestDB(t)))
+
+	old := time.Now().Add(-2 * touchEvery)
+	for i := range 1000 {
+		svc.lastTouch[fmt.Sprintf("stale-%d", i)] = old
+	}
+	svc.lastTouch["recent"] = time.Now()
+
+	svc.Touch(ctx, "active")
+
+	if len(svc.lastTouch) != 2 {
+		t.Errorf("lastTouch has %d entries after touch, want recent and active", len(svc.lastTouch))
+	}
+	for _, familyID := range []string{"recent", "active"} {
+		if _, ok := svc.lastTouch[familyID]; !ok {
+			t.Errorf("lastTouch lost %q", familyID)
+		}
+	}
+}
+
+func TestStartTruncatesUserAgentOnRuneBoundary(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	svc := NewService(NewRepository(db))
+
+	u := &user.User{Name: "ana", Email: "ana@x.io", Password: "x"}
+	if err := db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 254 bytes ASCII y después "é" (2 bytes): cortar en 255 la partiría.
+	ua := strings.Repeat("a", 254) + "é" + "tail"
+	sess, err := svc.Start(ctx, u.ID, "family", ua, "127.0.0.1", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stored Session
+	if err := db.First(&stored, sess.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.UserAgent != strings.Repeat("a", 254) || !utf8.ValidString(stored.UserAgent) {
+		t.Errorf("user agent = %q (%d bytes), want the 254 bytes before the cut rune", stored.UserAgent, len(stored.UserAgent))
+	}
+}
@@ -0,0 +1,87 @@
+package token
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	cacheSweepEvery = 1024
+	// activeTTL es cuánto se confía en un "no revocado" sin volver a la base: con varias
+	// instancias, es lo que tarda en verse una revocación hecha en otra.
+	activeTTL = 30 * time.Second
+)
+
+type cacheEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// revocationCache guarda el estado de cada jti para no consultar la tabla tokens en cada request.
+// Una revocación es definitiva y se guarda hasta que el token vence; un token activo, como mucho
+// activeTTL. Las revocaciones hechas por este proceso lo actualizan al momento.
+type revocationCache struct {
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	writes  int
+}
+
+func newRevocationCache() *revocationCache {
+	return &revocationCache{entries: make(map[string]cacheEntry)}
+}
+
+func (c *revocationCache) get(jti string) (revoked bool, ok bool) {
+	c.mu.RLock()
+	e, found := c.entries[jti]
+	c.mu.RUnlock()
+
+	if !found {
+		return false, false
+	}
+
+	if time.Now().After(e.expiresAt) {
+		c.mu.Lock()
+		delete(c.entries, jti)
+		c.mu.Unlock()
+		return false, false
+	}
+
+	return e.revoked, true
+}
+
+func (c *revocationCache) set(jti string, revoked bool, expiresAt time.Time) {
+	now := time.Now()
+	if jti == "" || !now.Before(expiresAt) {
+		return
+	}
+	if !revoked && expiresAt.After(now.Add(activeTTL)) {
+		expiresAt = now.Add(activeTTL)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[jti] = cacheEntry{revoked: revoked, expiresAt: expiresAt}
+
+	c.writes++
+	if c.writes%cacheSweepEvery == 0 {
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+}
+
+// markRevoked solo toca entradas existentes: las que no están se van a leer de la base.
+func (c *revocationCache) markRevoked(jtis ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, jti := range jtis {
+		if e, ok := c.entries[jti]; ok {
+			e.revoked = true
+			c.entries[jti] = e
+		}
+	}
+}
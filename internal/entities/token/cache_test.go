@@ -0,0 +1,35 @@
+package token
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevocationCacheLifetime(t *testing.T) {
+	c := newRevocationCache()
+	exp := time.Now().Add(time.Hour)
+
+	c.set("active", false, exp)
+	c.set("revoked", true, exp)
+
+	// Un token activo se vuelve a consultar pronto; uno revocado, no hasta que vence.
+	if got := c.entries["active"].expiresAt; got.After(time.Now().Add(activeTTL)) {
+		t.Errorf("active entry cached until %v, want at most %v", got, activeTTL)
+	}
+	if got := c.entries["revoked"].expiresAt; !got.Equal(exp) {
+		t.Errorf("revoked entry cached until %v, want %v", got, exp)
+	}
+
+	c.markRevoked("active", "unknown")
+	if revoked, ok := c.get("active"); !ok || !revoked {
+		t.Errorf("get(active) after markRevoked = %v, %v; want true, true", revoked, ok)
+	}
+	if _, ok := c.get("unknown"); ok {
+		t.Error("markRevoked added an entry that was not cached")
+	}
+
+	c.set("expired", false, time.Now().Add(-time.Second))
+	if _, ok := c.get("expired"); ok {
+		t.Error("an expired token was cached")
+	}
+}
@@ -1,27 +1,40 @@
 package token
 
-import "github.com/sebaactis/wallet-go-api/internal/httputil"
+import (
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/httputil"
+)
 
 type TokenRequest struct {
-	TokenType string `json:"token_type" validate:"required,max=30"`
-	Token     string `json:"token" validate:"required,max=1000"`
+	UserID    uint      `json:"user_id" validate:"required"`
+	TokenType string    `json:"token_type" validate:"required,max=30"`
+	FamilyID  string    `json:"family_id" validate:"omitempty,max=64"`
+	JTI       string    `json:"jti" validate:"required,max=64"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 type TokenResponse struct {
+	UserID      uint   `json:"user_id"`
 	TokenType   string `json:"token_type"`
-	Token       string `json:"token"`
 	RevokedDate string `json:"revoked_date"`
 	IsRevoked   bool   `json:"is_revoked"`
+	FamilyID    string `json:"family_id"`
+	JTI         string `json:"jti"`
+	ExpiresAt   string `json:"expires_at"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 }
 
 func ToResponse(t *Token) *TokenResponse {
 	return &TokenResponse{
+		UserID:      t.UserID,
 		TokenType:   t.TokenType,
-		Token:       t.Token,
 		RevokedDate: t.Revoked_Date.Format("2006-01-02 15:04:05"),
 		IsRevoked:   t.Is_Revoked,
+		FamilyID:    t.FamilyID,
+		JTI:         t.JTI,
+		ExpiresAt:   httputil.FormatDate(&t.ExpiresAt),
 		CreatedAt:   httputil.FormatDate(&t.CreatedAt),
 		UpdatedAt:   httputil.FormatDate(&t.UpdatedAt),
 	}
@@ -4,10 +4,13 @@ import "time"
 
 type Token struct {
 	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"index;default:null"`
 	TokenType    string    `json:"token_type" gorm:"size:30;not null"`
-	Token        string    `json:"token" gorm:"size:1000;not null"`
 	Revoked_Date time.Time `json:"revoked_date" gorm:"default:null"`
 	Is_Revoked   bool      `json:"is_revoked" gorm:"default:false"`
+	FamilyID     string    `json:"family_id" gorm:"size:64;index"` // tokens emitidos desde un mismo login
+	JTI          string    `json:"jti" gorm:"column:jti;size:64;uniqueIndex"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"default:null"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -8,6 +8,8 @@ import (
 	"gorm.io/gorm"
 )
 
+var ErrTokenNotFound = errors.New("token not found")
+
 type Repository struct {
 	db *gorm.DB
 }
@@ -33,40 +35,96 @@ func (r *Repository) GetAll(ctx context.Context) ([]*Token, error) {
 	return tokens, nil
 }
 
-func (r *Repository) GetByToken(ctx context.Context, tokenIn string) (*Token, error) {
-    var token Token
+func (r *Repository) GetByJTI(ctx context.Context, jti string) (*Token, error) {
+	var token Token
 
-    err := r.db.WithContext(ctx).Where("token = ?", tokenIn).First(&token).Error
-    if err != nil {
-        if errors.Is(err, gorm.ErrRecordNotFound) {
-            return nil, errors.New("token not found")
-        }
-        return nil, errors.New("unexpected error")
-    }
+	err := r.db.WithContext(ctx).Where("jti = ?", jti).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
 
-    return &token, nil
+	return &token, nil
 }
 
-func (r *Repository) Update(ctx context.Context, token string, updates map[string]interface{}) error {
+func (r *Repository) RevokeByJTI(ctx context.Context, jti string) error {
 	result := r.db.WithContext(ctx).
 		Model(&Token{}).
-		Where("token = ?", token).
-		Updates(updates)
+		Where("jti = ?", jti).
+		Updates(map[string]interface{}{
+			"revoked_date": time.Now(),
+			"is_revoked":   true,
+		})
 
 	if result.Error != nil {
 		return result.Error
 	}
 
 	if result.RowsAffected == 0 {
-		return errors.New("Token not found")
+		return ErrTokenNotFound
 	}
 
 	return nil
-} 
+}
 
-func (r *Repository) RevokeToken(ctx context.Context, token string) error {
-	return r.Update(ctx, token, map[string]interface{}{
-		"revoked_date": time.Now(),
-		"is_revoked": true,
+// RevokeIfActive revoca el token solo si seguía activo; false indica que ya estaba revocado.
+func (r *Repository) RevokeIfActive(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&Token{}).
+		Where("id = ? AND is_revoked = ?", id, false).
+		Updates(map[string]interface{}{
+			"revoked_date": time.Now(),
+			"is_revoked":   true,
+		})
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily revoca los tokens activos de la familia y devuelve sus jti.
+func (r *Repository) RevokeFamily(ctx context.Context, familyID string) ([]string, error) {
+	var jtis []string
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Token{}).
+			Where("family_id = ? AND is_revoked = ?", familyID, false).
+			Pluck("jti", &jtis).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&Token{}).
+			Where("family_id = ? AND is_revoked = ?", familyID, false).
+			Updates(map[string]interface{}{
+				"revoked_date": time.Now(),
+				"is_revoked":   true,
+			}).Error
+	})
+
+	return jtis, err
+}
+
+func (r *Repository) RevokeByUser(ctx context.Context, userID uint) ([]string, error) {
+	var jtis []string
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Token{}).
+			Where("user_id = ? AND is_revoked = ?", userID, false).
+			Pluck("jti", &jtis).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&Token{}).
+			Where("user_id = ? AND is_revoked = ?", userID, false).
+			Updates(map[string]interface{}{
+				"revoked_date": time.Now(),
+				"is_revoked":   true,
+			}).Error
 	})
+
+	return jtis, err
 }
@@ -8,13 +8,16 @@ import (
 	"github.com/sebaactis/wallet-go-api/internal/validation"
 )
 
+var ErrTokenReused = errors.New("refresh token reuse detected")
+
 type Service struct {
 	repository *Repository
 	validator  validation.StructValidator
+	cache      *revocationCache
 }
 
 func NewService(repository *Repository, v validation.StructValidator) *Service {
-	return &Service{repository: repository, validator: v}
+	return &Service{repository: repository, validator: v, cache: newRevocationCache()}
 }
 
 func (s *Service) Create(ctx context.Context, tokenRequest *TokenRequest) (*Token, error) {
@@ -23,8 +26,11 @@ func (s *Service) Create(ctx context.Context, tokenRequest *TokenRequest) (*Toke
 	}
 
 	tokenCreate := &Token{
+		UserID:    tokenRequest.UserID,
 		TokenType: tokenRequest.TokenType,
-		Token:     tokenRequest.Token,
+		FamilyID:  tokenRequest.FamilyID,
+		JTI:       tokenRequest.JTI,
+		ExpiresAt: tokenRequest.ExpiresAt,
 	}
 
 	token, err := s.repository.Create(ctx, tokenCreate)
@@ -33,6 +39,8 @@ func (s *Service) Create(ctx context.Context, tokenRequest *TokenRequest) (*Toke
 		return nil, err
 	}
 
+	s.cache.set(token.JTI, false, token.ExpiresAt)
+
 	return token, nil
 }
 
@@ -45,9 +53,33 @@ func (s *Service) GetAll(ctx context.Context) ([]*Token, error) {
 	return tokens, nil
 }
 
-func (s *Service) RevokeToken(ctx context.Context, token string) error {
+// IsRevoked indica si el token con ese jti ya no es válido. Un jti que no está en la base también
+// cuenta como revocado: todos los tokens que emitimos se persisten al firmarse.
+func (s *Service) IsRevoked(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	if jti == "" {
+		return true, nil
+	}
+
+	if revoked, ok := s.cache.get(jti); ok {
+		return revoked, nil
+	}
+
+	t, err := s.repository.GetByJTI(ctx, jti)
+	if errors.Is(err, ErrTokenNotFound) {
+		s.cache.set(jti, true, expiresAt)
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	s.cache.set(jti, t.Is_Revoked, expiresAt)
+	return t.Is_Revoked, nil
+}
+
+func (s *Service) RevokeToken(ctx context.Context, jti string) error {
 
-	tokenCheck, err := s.repository.GetByToken(ctx, token)
+	tokenCheck, err := s.repository.GetByJTI(ctx, jti)
 
 	if err != nil {
 		return err
@@ -57,5 +89,76 @@ func (s *Service) RevokeToken(ctx context.Context, token string) error {
 		return errors.New("the token is revoked")
 	}
 
-	return s.repository.RevokeToken(ctx, token)
+	if err := s.repository.RevokeByJTI(ctx, jti); err != nil {
+		return err
+	}
+
+	s.cache.markRevoked(jti)
+	return nil
+}
+
+// Rotate consume un refresh token: lo revoca y devuelve su registro para emitir el siguiente par
+// en la misma familia. Si el token ya estaba revocado alguien lo está reutilizando (por ejemplo,
+// un token robado que ya rotó), y se revoca la familia completa.
+func (s *Service) Rotate(ctx context.Context, jti string) (*Token, error) {
+	current, err := s.repository.GetByJTI(ctx, jti)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := s.repository.RevokeIfActive(ctx, current.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !rotated {
+		if current.FamilyID != "" {
+			if err := s.RevokeFamily(ctx, current.FamilyID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrTokenReused
+	}
+
+	s.cache.markRevoked(current.JTI)
+	return current, nil
+}
+
+func (s *Service) RevokeFamily(ctx context.Context, familyID string) error {
+	jtis, err := s.repository.RevokeFamily(ctx, familyID)
+	if err != nil {
+		return err
+	}
+
+	s.cache.markRevoked(jtis...)
+	return nil
+}
+
+// RevokeSession revoca la sesión a la que pertenece el token: su familia completa (access y
+// refresh emitidos desde el mismo login) o solo el token si es anterior a las familias.
+func (s *Service) RevokeSession(ctx context.Context, jti string) error {
+	t, err := s.repository.GetByJTI(ctx, jti)
+	if err != nil {
+		return err
+	}
+
+	if t.FamilyID == "" {
+		if err := s.repository.RevokeByJTI(ctx, jti); err != nil {
+			return err
+		}
+		s.cache.markRevoked(jti)
+		return nil
+	}
+
+	return s.RevokeFamily(ctx, t.FamilyID)
+}
+
+func (s *Service) RevokeAllForUser(ctx context.Context, userID uint) error {
+	jtis, err := s.repository.RevokeByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	s.cache.markRevoked(jtis...)
+	return nil
 }
@@ -4,17 +4,57 @@ import (
 	"time"
 
 	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/money"
 )
 
 type Transaction struct {
-	ID            uint            `json:"id" gorm:"primaryKey"`
-	Type          string          `json:"type" gorm:"size:20;not null"`
-	Reference     *string          `json:"reference" gorm:"size:100;index:idx_tx_ref,unique,where:reference IS NOT NULL"`
-	FromAccountID *uint           `json:"from_account_id"`
-	ToAccountID   *uint           `json:"to_account_id"`
-	FromAccount   *account.Account `json:"from_account" gorm:"foreignKey:FromAccountID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	ToAccount     *account.Account `json:"to_account" gorm:"foreignKey:ToAccountID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Amount        float64         `json:"amount" gorm:"type:decimal(10,2);not null"`
-	Currency      string          `json:"currency" gorm:"size:3;not null"`
+	ID             uint             `json:"id" gorm:"primaryKey"`
+	Type           string           `json:"type" gorm:"size:20;not null"`
+	Reference      *string          `json:"reference" gorm:"size:100"`
+	FromAccountID  *uint            `json:"from_account_id"`
+	ToAccountID    *uint            `json:"to_account_id"`
+	FromAccount    *account.Account `json:"from_account" gorm:"foreignKey:FromAccountID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ToAccount      *account.Account `json:"to_account" gorm:"foreignKey:ToAccountID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Amount         int64            `json:"amount" gorm:"not null"` // unidades menores
+	Currency       string           `json:"currency" gorm:"size:3;not null"`
+	ToAmount       int64            `json:"to_amount" gorm:"not null;default:0"` // entre monedas: lo acreditado, en ToCurrency
+	ToCurrency     string           `json:"to_currency" gorm:"size:3"`
+	FXRate         string           `json:"fx_rate" gorm:"column:fx_rate;size:32"` // unidades de ToCurrency por unidad de Currency
+	FXQuoteID      *uint            `json:"fx_quote_id" gorm:"column:fx_quote_id"`
+	Fee            int64            `json:"fee" gorm:"not null;default:0"` // comisión cobrada al origen, en Currency, aparte de Amount
+	Fees           []FeeLine        `json:"fees,omitempty" gorm:"foreignKey:TransactionID"`
+	ReversesID     *uint            `json:"reverses_id" gorm:"index"`                  // en type=reversal, la transacción que compensa
+	ReversedAmount int64            `json:"reversed_amount" gorm:"not null;default:0"` // en la original, cuánto ya se revirtió
+	CreatedAt      time.Time
+}
+
+func (t *Transaction) Money() money.Money {
+	return money.New(t.Amount, t.Currency)
+}
+
+// IsFX indica si la transacción convirtió entre monedas.
+func (t *Transaction) IsFX() bool {
+	return t.ToCurrency != "" && t.ToCurrency != t.Currency
+}
+
+// Reversible es lo que todavía se puede devolver de la transacción.
+func (t *Transaction) Reversible() int64 {
+	if t.Type == "reversal" {
+		return 0
+	}
+	return t.Amount - t.ReversedAmount
+}
+
+// FeeLine es un componente de la comisión de una transacción (fijo, porcentaje, tramo, ajuste
+// por mínimo o máximo). La suma de las líneas es Transaction.Fee.
+type FeeLine struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	TransactionID uint   `json:"transaction_id" gorm:"not null;index"`
+	Component     string `json:"component" gorm:"size:20;not null"`
+	Description   string `json:"description" gorm:"size:100"`
+	Amount        int64  `json:"amount" gorm:"not null"` // unidades menores; los ajustes por máximo son negativos
+	Currency      string `json:"currency" gorm:"size:3;not null"`
 	CreatedAt     time.Time
 }
+
+func (FeeLine) TableName() string { return "transaction_fees" }
@@ -3,6 +3,7 @@ package user
 import (
 	"time"
 
+	"github.com/sebaactis/wallet-go-api/internal/authz"
 	"github.com/sebaactis/wallet-go-api/internal/httputil"
 )
 
@@ -14,13 +15,15 @@ type UserCreate struct {
 }
 
 type UserResponse struct {
-	ID            uint      `json:"id"`
-	Name          string    `json:"name"`
-	Email         string    `json:"email"`
-	LoginAttempts int       `json:"login_attempt"`
-	LockedUntil   time.Time `json:"locked_until"`
-	CreatedAt     string    `json:"created_at"`
-	UpdatedAt     string    `json:"updated_at"`
+	ID            uint       `json:"id"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email"`
+	Role          authz.Role `json:"role"`
+	KYCTier       int        `json:"kyc_tier"`
+	LoginAttempts int        `json:"login_attempt"`
+	LockedUntil   time.Time  `json:"locked_until"`
+	CreatedAt     string     `json:"created_at"`
+	UpdatedAt     string     `json:"updated_at"`
 }
 
 type UserRecoveryPassword struct {
@@ -35,6 +38,8 @@ func ToResponse(u *User) *UserResponse {
 		ID:            u.ID,
 		Name:          u.Name,
 		Email:         u.Email,
+		Role:          u.Role,
+		KYCTier:       u.KYCTier,
 		LockedUntil:   u.Locked_until,
 		LoginAttempts: u.LoginAttempt,
 		CreatedAt:     httputil.FormatDate(&u.CreatedAt),
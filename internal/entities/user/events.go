@@ -0,0 +1,28 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/authz"
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
+	"gorm.io/gorm"
+)
+
+// UserEvent es el payload de los eventos user.*: el estado del usuario después del cambio.
+type UserEvent struct {
+	UserID      uint       `json:"userId"`
+	Email       string     `json:"email"`
+	Role        authz.Role `json:"role"`
+	KYCTier     int        `json:"kyc_tier"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+}
+
+func publish(ctx context.Context, tx *gorm.DB, eventType string, u *User) error {
+	ev := UserEvent{UserID: u.ID, Email: u.Email, Role: u.Role, KYCTier: u.KYCTier}
+	if !u.Locked_until.IsZero() {
+		ev.LockedUntil = &u.Locked_until
+	}
+
+	return outbox.Append(ctx, tx, eventType, "user", u.ID, u.ID, ev)
+}
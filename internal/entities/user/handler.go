@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/sebaactis/wallet-go-api/internal/authz"
 	"github.com/sebaactis/wallet-go-api/internal/httputil"
 	"github.com/sebaactis/wallet-go-api/internal/validation"
 )
@@ -61,6 +62,11 @@ func (h *HTTPHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := authz.Authorize(r.Context(), authz.ReadUser, authz.Resource{OwnerID: uint(id)}); err != nil {
+		httputil.WriteError(w, authz.Status(err), err.Error(), nil)
+		return
+	}
+
 	u, err := h.service.GetByID(r.Context(), uint(id))
 
 	if err != nil {
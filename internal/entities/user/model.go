@@ -1,14 +1,20 @@
 package user
 
-import "time"
+import (
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/authz"
+)
 
 type User struct {
-	ID           uint      `json:"id" gorm:"primaryKey"`
-	Name         string    `json:"name" gorm:"size:30;not null"`
-	Email        string    `json:"email" gorm:"size:30;not null;uniqueIndex"`
-	Password     string    `json:"password" gorm:"size:30;not null"`
-	LoginAttempt int       `json:"login_attempt" gorm:"default:0"`
-	Locked_until time.Time `json:"locked_until" gorm:"default:null"`
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Name         string     `json:"name" gorm:"size:30;not null"`
+	Email        string     `json:"email" gorm:"size:30;not null;uniqueIndex"`
+	Password     string     `json:"password" gorm:"size:30;not null"`
+	Role         authz.Role `json:"role" gorm:"size:20;not null;default:user"`
+	KYCTier      int        `json:"kyc_tier" gorm:"column:kyc_tier;not null;default:0"` // nivel de verificación; define los límites por defecto
+	LoginAttempt int        `json:"login_attempt" gorm:"default:0"`
+	Locked_until time.Time  `json:"locked_until" gorm:"default:null"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -14,6 +14,10 @@ type Repository struct {
 
 func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
 
+func (r *Repository) withTx(tx *gorm.DB) *Repository {
+	return &Repository{db: tx}
+}
+
 func (r *Repository) Create(ctx context.Context, user *User) error {
 	return r.db.WithContext(ctx).Create(user).Error
 }
@@ -107,20 +111,14 @@ func (r *Repository) IncrementLoginAttempt(ctx context.Context, id uint) (int, e
 		return 0, err
 	}
 
-	if user.LoginAttempt >= 5 {
-		r.LockedUser(ctx, id)
-	}
-
 	return user.LoginAttempt, nil
 }
 
-func (r *Repository) LockedUser(ctx context.Context, id uint) error {
-	now := time.Now()
-
+func (r *Repository) LockedUser(ctx context.Context, id uint, until time.Time) error {
 	if err := r.db.WithContext(ctx).
 		Model(&User{}).
 		Where("id = ?", id).
-		Update("locked_until", now.Add(15*time.Minute)); err != nil {
+		Update("locked_until", until); err != nil {
 		return err.Error
 	}
 
@@ -140,4 +138,8 @@ func (r *Repository) UnlockUser(ctx context.Context, id uint) error {
 	return nil
 }
 
-var ErrDuplicateEmail = errors.New("email already in use")
+var (
+	ErrDuplicateEmail = errors.New("email already in use")
+	ErrInvalidRole    = errors.New("invalid role")
+	ErrInvalidKYCTier = errors.New("invalid kyc tier")
+)
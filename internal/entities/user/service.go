@@ -4,18 +4,26 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
+	"github.com/sebaactis/wallet-go-api/internal/authz"
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
 	"github.com/sebaactis/wallet-go-api/internal/entities/token"
 	"github.com/sebaactis/wallet-go-api/internal/validation"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+const (
+	maxLoginAttempts = 5
+	lockDuration     = 15 * time.Minute
+)
+
 type Service struct {
-	repository  *Repository
+	repository   *Repository
 	tokenService *token.Service
-	validator       validation.StructValidator
-	db              *gorm.DB
+	validator    validation.StructValidator
+	db           *gorm.DB
 }
 
 func NewService(repository *Repository, tokenService *token.Service, v validation.StructValidator) *Service {
@@ -48,9 +56,16 @@ func (s *Service) Create(ctx context.Context, user *UserCreate) (*User, error) {
 		Name:     name,
 		Email:    email,
 		Password: string(passwordHash),
+		Role:     authz.RoleUser,
 	}
 
-	if err := s.repository.Create(ctx, newUser); err != nil {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.repository.withTx(tx).Create(ctx, newUser); err != nil {
+			return err
+		}
+		return publish(ctx, tx, outbox.UserCreated, newUser)
+	})
+	if err != nil {
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
 			return nil, ErrDuplicateEmail
 		}
@@ -73,15 +88,97 @@ func (s *Service) FindAll(ctx context.Context) ([]*User, error) {
 	return s.repository.FindAll(ctx)
 }
 
+// IncrementLoginAttempt suma un intento fallido y, a partir de maxLoginAttempts, bloquea al
+// usuario por lockDuration.
 func (s *Service) IncrementLoginAttempt(ctx context.Context, id uint) (int, error) {
-	return s.repository.IncrementLoginAttempt(ctx, id)
+	var attempts int
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		r := s.repository.withTx(tx)
+
+		var err error
+		if attempts, err = r.IncrementLoginAttempt(ctx, id); err != nil {
+			return err
+		}
+		if attempts < maxLoginAttempts {
+			return nil
+		}
+
+		until := time.Now().Add(lockDuration)
+		if err := r.LockedUser(ctx, id, until); err != nil {
+			return err
+		}
+
+		u, err := r.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return publish(ctx, tx, outbox.UserLocked, u)
+	})
+
+	return attempts, err
+}
+
+// SetRole cambia el rol y revoca los tokens del usuario: el rol viaja en el access token, así que
+// sin revocar seguiría valiendo el anterior hasta que venza.
+func (s *Service) SetRole(ctx context.Context, id uint, role authz.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.update(ctx, tx, id, map[string]interface{}{"role": role}, outbox.UserRoleChanged)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.tokenService.RevokeAllForUser(ctx, id)
+}
+
+// SetKYCTier cambia el nivel de KYC, que decide qué límites de transacciones por nivel le aplican.
+func (s *Service) SetKYCTier(ctx context.Context, id uint, tier int) error {
+	if tier < 0 {
+		return ErrInvalidKYCTier
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.update(ctx, tx, id, map[string]interface{}{"kyc_tier": tier}, outbox.UserKYCTierChanged)
+	})
 }
 
 func (s *Service) UnlockUser(ctx context.Context, id uint) error {
-	return s.repository.UnlockUser(ctx, id)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		r := s.repository.withTx(tx)
+
+		if err := r.UnlockUser(ctx, id); err != nil {
+			return err
+		}
+
+		u, err := r.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return publish(ctx, tx, outbox.UserUnlocked, u)
+	})
+}
+
+// update aplica updates y publica eventType con el usuario ya modificado, en la transacción tx.
+func (s *Service) update(ctx context.Context, tx *gorm.DB, id uint, updates map[string]interface{}, eventType string) error {
+	r := s.repository.withTx(tx)
+
+	if err := r.Update(ctx, id, updates); err != nil {
+		return err
+	}
+
+	u, err := r.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return publish(ctx, tx, eventType, u)
 }
 
-func (s *Service) UpdatePasswordByRecovery(ctx context.Context, req UserRecoveryPassword) (*User, error) {
+func (s *Service) UpdatePasswordByRecovery(ctx context.Context, req UserRecoveryPassword, tokenID string) (*User, error) {
 
 	if fields, ok := s.validator.ValidateStruct(req); !ok {
 		return nil, &validation.ValidationError{Fields: fields}
@@ -103,7 +200,7 @@ func (s *Service) UpdatePasswordByRecovery(ctx context.Context, req UserRecovery
 		return nil, err
 	}
 
-	if err = s.tokenService.RevokeToken(ctx, req.Token); err != nil {
+	if err = s.tokenService.RevokeToken(ctx, tokenID); err != nil {
 		return nil, err
 	}
 
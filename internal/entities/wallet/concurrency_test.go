@@ -0,0 +1,127 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/platform/config"
+	"github.com/sebaactis/wallet-go-api/internal/platform/database"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "wallet.db") + "?_pragma=foreign_keys(ON)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	db, err := database.Open(config.Config{Driver: "sqlite", DSN: dsn, MaxOpenConns: 8, MaxIdleConns: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// Muchas goroutines depositan, retiran y transfieren sobre la misma cuenta a la vez. Ninguna
+// operación se pierde ni se aplica dos veces: cada saldo es la suma de sus asientos, cada
+// transacción balancea y ningún saldo queda negativo.
+func TestConcurrentOperationsKeepLedgerAndBalanceInSync(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	u := &user.User{Name: "ana", Email: "ana@x.io", Password: "x"}
+	if err := db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+	a := &account.Account{UserID: u.ID, Currency: "USD"}
+	b := &account.Account{UserID: u.ID, Currency: "USD"}
+	if err := db.Create(a).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(b).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService(db, nil, 0, 0)
+	if _, err := svc.Deposit(ctx, &DepositRequest{AccountID: a.ID, Amount: "100", Currency: "USD"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, ops = 24, 10
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+		ok = 1 // el depósito inicial
+	)
+	for i := range workers {
+		wg.Go(func() {
+			for j := range ops {
+				var err error
+				switch (i + j) % 3 {
+				case 0:
+					_, err = svc.Withdraw(ctx, &WithdrawRequest{AccountID: a.ID, Amount: "3.01", Currency: "USD"}, "")
+				case 1:
+					_, err = svc.Deposit(ctx, &DepositRequest{AccountID: a.ID, Amount: "0.50", Currency: "USD"}, "")
+				case 2:
+					_, err = svc.Transfer(ctx, &TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: "2.25", Currency: "USD"}, "")
+				}
+
+				switch {
+				case err == nil:
+					mu.Lock()
+					ok++
+					mu.Unlock()
+				case errors.Is(err, ErrInsufficientFunds):
+					// esperado una vez que la cuenta se vacía
+				default:
+					t.Errorf("worker %d op %d: %v", i, j, err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	var txs int64
+	db.Raw("SELECT COUNT(*) FROM transactions").Scan(&txs)
+	if txs != int64(ok) {
+		t.Errorf("%d transactions recorded, want %d successful operations", txs, ok)
+	}
+
+	var drift []struct {
+		ID      uint
+		Balance int64
+		Ledger  int64
+	}
+	db.Raw(`SELECT a.id, a.balance, COALESCE(SUM(le.amount), 0) AS ledger
+		FROM accounts a LEFT JOIN ledger_entries le ON le.account_id = a.id
+		GROUP BY a.id, a.balance
+		HAVING a.balance <> COALESCE(SUM(le.amount), 0)`).Scan(&drift)
+	for _, d := range drift {
+		t.Errorf("account %d balance %d, ledger sum %d", d.ID, d.Balance, d.Ledger)
+	}
+
+	var unbalanced int64
+	db.Raw(`SELECT COUNT(*) FROM (SELECT transaction_id FROM ledger_entries
+		GROUP BY transaction_id, currency HAVING SUM(amount) <> 0) x`).Scan(&unbalanced)
+	if unbalanced != 0 {
+		t.Errorf("%d transactions do not balance", unbalanced)
+	}
+
+	for _, acc := range []*account.Account{a, b} {
+		var balance int64
+		db.Raw("SELECT balance FROM accounts WHERE id = ?", acc.ID).Scan(&balance)
+		if balance < 0 {
+			t.Errorf("account %d balance %d is negative", acc.ID, balance)
+		}
+	}
+}
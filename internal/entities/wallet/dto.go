@@ -1,28 +1,247 @@
 package wallet
 
+import (
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
+	"github.com/sebaactis/wallet-go-api/internal/httputil"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+)
+
 type DepositRequest struct {
-	AccountID uint    `json:"accountId" validate:"required"`
-	Amount    float64 `json:"amount"    validate:"required,gt=0"`
-	Currency  string  `json:"currency"  validate:"required,iso4217"`
+	AccountID uint          `json:"accountId" validate:"required"`
+	Amount    money.Decimal `json:"amount"    validate:"required,amount"`
+	Currency  string        `json:"currency"  validate:"required,iso4217"`
 }
 
 type WithdrawRequest struct {
-	AccountID uint    `json:"accountId" validate:"required"`
-	Amount    float64 `json:"amount"    validate:"required,gt=0"`
-	Currency  string  `json:"currency"  validate:"required,iso4217"`
+	AccountID uint          `json:"accountId" validate:"required"`
+	Amount    money.Decimal `json:"amount"    validate:"required,amount"`
+	Currency  string        `json:"currency"  validate:"required,iso4217"`
 }
 
 type TransferRequest struct {
-	FromAccountID uint    `json:"fromAccountId" validate:"required,nefield=ToAccountID"`
-	ToAccountID   uint    `json:"toAccountId"   validate:"required"`
-	Amount        float64 `json:"amount"        validate:"required,gt=0"`
-	Currency      string  `json:"currency"      validate:"required,iso4217"`
+	FromAccountID uint          `json:"fromAccountId" validate:"required,nefield=ToAccountID"`
+	ToAccountID   uint          `json:"toAccountId"   validate:"required"`
+	Amount        money.Decimal `json:"amount"        validate:"required,amount"`
+	Currency      string        `json:"currency"      validate:"required,iso4217"` // moneda de la cuenta origen
+	QuoteID       *uint         `json:"quoteId"`                                   // entre monedas: cotización a usar; sin ella, tipo vigente
 }
 
 type TxResponse struct {
-	TransactionID uint    `json:"transactionId"`
-	Type          string  `json:"type"`
-	Reference     *string `json:"reference"`
-	Amount        float64 `json:"amount"`
-	Currency      string  `json:"currency"`
+	TransactionID         uint           `json:"transactionId"`
+	Type                  string         `json:"type"`
+	Reference             *string        `json:"reference"`
+	Amount                money.Decimal  `json:"amount"`
+	Currency              string         `json:"currency"`
+	ConvertedAmount       *money.Decimal `json:"convertedAmount,omitempty"`
+	ConvertedCurrency     string         `json:"convertedCurrency,omitempty"`
+	FXRate                string         `json:"fxRate,omitempty"`
+	QuoteID               *uint          `json:"quoteId,omitempty"`
+	Fee                   *money.Decimal `json:"fee,omitempty"`
+	FeeBreakdown          []FeeResponse  `json:"feeBreakdown,omitempty"`
+	TotalDebited          *money.Decimal `json:"totalDebited,omitempty"`
+	ReversesTransactionID *uint          `json:"reversesTransactionId,omitempty"`
+}
+
+type FeeResponse struct {
+	Component   string        `json:"component"`
+	Description string        `json:"description"`
+	Amount      money.Decimal `json:"amount"`
+}
+
+func ToTxResponse(t *transaction.Transaction) TxResponse {
+	resp := TxResponse{
+		TransactionID:         t.ID,
+		Type:                  t.Type,
+		Reference:             t.Reference,
+		Amount:                t.Money().Decimal(),
+		Currency:              t.Currency,
+		ReversesTransactionID: t.ReversesID,
+	}
+
+	if t.IsFX() {
+		converted := money.New(t.ToAmount, t.ToCurrency).Decimal()
+		resp.ConvertedAmount = &converted
+		resp.ConvertedCurrency = t.ToCurrency
+		resp.FXRate = t.FXRate
+		resp.QuoteID = t.FXQuoteID
+	}
+
+	if t.Fee != 0 {
+		fee := money.New(t.Fee, t.Currency).Decimal()
+		total := money.New(t.Amount+t.Fee, t.Currency).Decimal()
+		resp.Fee = &fee
+		resp.TotalDebited = &total
+		for _, l := range t.Fees {
+			resp.FeeBreakdown = append(resp.FeeBreakdown, FeeResponse{
+				Component:   l.Component,
+				Description: l.Description,
+				Amount:      money.New(l.Amount, l.Currency).Decimal(),
+			})
+		}
+	}
+
+	return resp
+}
+
+type QuoteRequest struct {
+	FromCurrency string        `json:"fromCurrency" validate:"required,iso4217"`
+	ToCurrency   string        `json:"toCurrency"   validate:"required,iso4217"`
+	Amount       money.Decimal `json:"amount"       validate:"required,amount"` // en FromCurrency
+}
+
+type QuoteResponse struct {
+	QuoteID         uint          `json:"quoteId"`
+	FromCurrency    string        `json:"fromCurrency"`
+	ToCurrency      string        `json:"toCurrency"`
+	Amount          money.Decimal `json:"amount"`
+	ConvertedAmount money.Decimal `json:"convertedAmount"`
+	MidRate         string        `json:"midRate"`
+	Rate            string        `json:"rate"`
+	SpreadBps       int           `json:"spreadBps"`
+	ExpiresAt       string        `json:"expiresAt"`
+	Used            bool          `json:"used"`
+	TransactionID   *uint         `json:"transactionId"`
+}
+
+func ToQuoteResponse(q *FXQuote) QuoteResponse {
+	return QuoteResponse{
+		QuoteID:         q.ID,
+		FromCurrency:    q.FromCurrency,
+		ToCurrency:      q.ToCurrency,
+		Amount:          money.New(q.Amount, q.FromCurrency).Decimal(),
+		ConvertedAmount: money.New(q.ConvertedAmount, q.ToCurrency).Decimal(),
+		MidRate:         q.MidRate,
+		Rate:            q.Rate,
+		SpreadBps:       q.SpreadBps,
+		ExpiresAt:       httputil.FormatDate(&q.ExpiresAt),
+		Used:            q.UsedAt != nil,
+		TransactionID:   q.TransactionID,
+	}
+}
+
+type ReverseRequest struct {
+	Amount *money.Decimal `json:"amount"` // vacío = todo lo que queda por revertir
+}
+
+type HoldRequest struct {
+	FromAccountID    uint          `json:"fromAccountId" validate:"required,nefield=ToAccountID"`
+	ToAccountID      uint          `json:"toAccountId"   validate:"required"`
+	Amount           money.Decimal `json:"amount"        validate:"required,amount"`
+	Currency         string        `json:"currency"      validate:"required,iso4217"`
+	ExpiresInSeconds int64         `json:"expiresInSeconds" validate:"omitempty,min=1"`
+}
+
+type CaptureRequest struct {
+	Amount *money.Decimal `json:"amount"` // vacío = captura total
+}
+
+type HoldResponse struct {
+	HoldID         uint          `json:"holdId"`
+	FromAccountID  uint          `json:"fromAccountId"`
+	ToAccountID    uint          `json:"toAccountId"`
+	Amount         money.Decimal `json:"amount"`
+	CapturedAmount money.Decimal `json:"capturedAmount"`
+	Currency       string        `json:"currency"`
+	Status         string        `json:"status"`
+	Reference      *string       `json:"reference"`
+	TransactionID  *uint         `json:"transactionId"`
+	ExpiresAt      string        `json:"expiresAt"`
+}
+
+func ToHoldResponse(h *Hold) HoldResponse {
+	return HoldResponse{
+		HoldID:         h.ID,
+		FromAccountID:  h.FromAccountID,
+		ToAccountID:    h.ToAccountID,
+		Amount:         money.New(h.Amount, h.Currency).Decimal(),
+		CapturedAmount: money.New(h.CapturedAmount, h.Currency).Decimal(),
+		Currency:       h.Currency,
+		Status:         h.Status,
+		Reference:      h.Reference,
+		TransactionID:  h.TransactionID,
+		ExpiresAt:      httputil.FormatDate(&h.ExpiresAt),
+	}
+}
+
+type HistoryFilter struct {
+	Types          []string
+	From           *time.Time
+	To             *time.Time
+	MinAmount      *money.Decimal
+	MaxAmount      *money.Decimal
+	CounterpartyID *uint
+	Cursor         string
+	Limit          int
+}
+
+type HistoryItem struct {
+	EntryID               uint          `json:"entryId"`
+	TransactionID         uint          `json:"transactionId"`
+	Type                  string        `json:"type"`
+	Reference             *string       `json:"reference"`
+	Amount                money.Decimal `json:"amount"`
+	Currency              string        `json:"currency"`
+	CounterpartyAccountID *uint         `json:"counterpartyAccountId"`
+	BalanceAfter          money.Decimal `json:"balanceAfter"`
+	CreatedAt             string        `json:"createdAt"`
+}
+
+type HistoryResponse struct {
+	Items      []HistoryItem `json:"items"`
+	NextCursor *string       `json:"nextCursor"`
+}
+
+type DiscrepancyResponse struct {
+	AccountID     uint          `json:"accountId"`
+	UserID        uint          `json:"userId"`
+	Currency      string        `json:"currency"`
+	SystemCode    *string       `json:"systemCode,omitempty"`
+	Balance       money.Decimal `json:"balance"`
+	LedgerBalance money.Decimal `json:"ledgerBalance"`
+	Difference    money.Decimal `json:"difference"`
+	Transactions  []uint        `json:"transactions"`
+	Frozen        bool          `json:"frozen"`
+}
+
+type ReconciliationResponse struct {
+	CheckedAt              string                `json:"checkedAt"`
+	AccountsChecked        int64                 `json:"accountsChecked"`
+	OK                     bool                  `json:"ok"`
+	Discrepancies          []DiscrepancyResponse `json:"discrepancies"`
+	UnbalancedTransactions []uint                `json:"unbalancedTransactions"`
+}
+
+func ToReconciliationResponse(r *ReconciliationReport) ReconciliationResponse {
+	resp := ReconciliationResponse{
+		CheckedAt:              httputil.FormatDate(&r.CheckedAt),
+		AccountsChecked:        r.AccountsChecked,
+		OK:                     r.OK(),
+		Discrepancies:          []DiscrepancyResponse{},
+		UnbalancedTransactions: r.UnbalancedTransactions,
+	}
+	if resp.UnbalancedTransactions == nil {
+		resp.UnbalancedTransactions = []uint{}
+	}
+
+	for _, d := range r.Discrepancies {
+		txs := d.Transactions
+		if txs == nil {
+			txs = []uint{}
+		}
+		resp.Discrepancies = append(resp.Discrepancies, DiscrepancyResponse{
+			AccountID:     d.AccountID,
+			UserID:        d.UserID,
+			Currency:      d.Currency,
+			SystemCode:    d.SystemCode,
+			Balance:       money.New(d.Balance, d.Currency).Decimal(),
+			LedgerBalance: money.New(d.LedgerBalance, d.Currency).Decimal(),
+			Difference:    money.New(d.Difference(), d.Currency).Decimal(),
+			Transactions:  txs,
+			Frozen:        d.Frozen,
+		})
+	}
+
+	return resp
 }
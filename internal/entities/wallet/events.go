@@ -0,0 +1,50 @@
+package wallet
+
+import (
+	"context"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
+	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+)
+
+// TransactionEvent es el payload de transaction.posted. Se publica uno por cada cuenta de cliente
+// que mueve la transacción, para su dueño: una transferencia da uno de débito y uno de crédito.
+// Amount es lo que se movió en esa cuenta, en su moneda (comisión incluida en el débito).
+type TransactionEvent struct {
+	AccountID   uint          `json:"accountId"`
+	Direction   string        `json:"direction"` // debit | credit
+	Amount      money.Decimal `json:"amount"`
+	Currency    string        `json:"currency"`
+	Transaction TxResponse    `json:"transaction"`
+	PostedAt    time.Time     `json:"postedAt"`
+}
+
+type AccountEvent struct {
+	AccountID uint   `json:"accountId"`
+	Currency  string `json:"currency"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// publishPosted publica transaction.posted para acc, que se movió delta (con signo) en t.
+func publishPosted(ctx context.Context, r *Repository, t *transaction.Transaction, acc *account.Account, delta int64) error {
+	direction := "credit"
+	if delta < 0 {
+		direction, delta = "debit", -delta
+	}
+
+	return r.Publish(ctx, outbox.TransactionPosted, "transaction", t.ID, acc.UserID, TransactionEvent{
+		AccountID:   acc.ID,
+		Direction:   direction,
+		Amount:      money.New(delta, acc.Currency).Decimal(),
+		Currency:    acc.Currency,
+		Transaction: ToTxResponse(t),
+		PostedAt:    t.CreatedAt,
+	})
+}
+
+func publishHold(ctx context.Context, r *Repository, eventType string, h *Hold, owner *account.Account) error {
+	return r.Publish(ctx, eventType, "hold", h.ID, owner.UserID, ToHoldResponse(h))
+}
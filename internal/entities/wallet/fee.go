@@ -0,0 +1,209 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+	"gorm.io/gorm"
+)
+
+const (
+	FeeFlat       = "flat"
+	FeePercentage = "percentage"
+	FeeTiered     = "tiered"
+)
+
+var ErrInvalidFeeSchedule = errors.New("invalid fee schedule")
+
+// FeeSchedule define la comisión de un tipo de transacción (withdraw, transfer) en una moneda.
+// Los montos van en unidades menores y los porcentajes en puntos básicos (150 = 1,50 %). MinFee y
+// MaxFee en 0 significan sin tope.
+type FeeSchedule struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	TxType        string    `json:"tx_type" gorm:"size:20;not null;uniqueIndex:idx_fee_schedules_type_currency"`
+	Currency      string    `json:"currency" gorm:"size:3;not null;uniqueIndex:idx_fee_schedules_type_currency"`
+	Kind          string    `json:"kind" gorm:"size:20;not null"`
+	FlatAmount    int64     `json:"flat_amount" gorm:"not null;default:0"`
+	PercentageBps int64     `json:"percentage_bps" gorm:"not null;default:0"`
+	MinFee        int64     `json:"min_fee" gorm:"not null;default:0"`
+	MaxFee        int64     `json:"max_fee" gorm:"not null;default:0"`
+	Active        bool      `json:"active" gorm:"not null"`
+	Tiers         []FeeTier `json:"tiers" gorm:"foreignKey:ScheduleID;constraint:OnDelete:CASCADE"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// FeeTier es un tramo de una comisión escalonada: aplica a montos hasta UpTo inclusive (nil = sin
+// tope). Se elige un solo tramo, el primero que contiene el monto; no es progresiva.
+type FeeTier struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	ScheduleID    uint   `json:"schedule_id" gorm:"not null;index"`
+	UpTo          *int64 `json:"up_to"`
+	FlatAmount    int64  `json:"flat_amount" gorm:"not null;default:0"`
+	PercentageBps int64  `json:"percentage_bps" gorm:"not null;default:0"`
+}
+
+func (s *FeeSchedule) Validate() error {
+	if s.TxType != "withdraw" && s.TxType != "transfer" {
+		return fmt.Errorf("%w: type must be withdraw or transfer", ErrInvalidFeeSchedule)
+	}
+	if s.FlatAmount < 0 || s.PercentageBps < 0 || s.MinFee < 0 || s.MaxFee < 0 {
+		return fmt.Errorf("%w: amounts must be >= 0", ErrInvalidFeeSchedule)
+	}
+	if s.MaxFee > 0 && s.MinFee > s.MaxFee {
+		return fmt.Errorf("%w: min fee is greater than max fee", ErrInvalidFeeSchedule)
+	}
+
+	switch s.Kind {
+	case FeeFlat, FeePercentage:
+		if len(s.Tiers) > 0 {
+			return fmt.Errorf("%w: only tiered fees have tiers", ErrInvalidFeeSchedule)
+		}
+	case FeeTiered:
+		if len(s.Tiers) == 0 {
+			return fmt.Errorf("%w: tiered fee without tiers", ErrInvalidFeeSchedule)
+		}
+		open := 0
+		for _, t := range s.Tiers {
+			if t.FlatAmount < 0 || t.PercentageBps < 0 || (t.UpTo != nil && *t.UpTo <= 0) {
+				return fmt.Errorf("%w: invalid tier", ErrInvalidFeeSchedule)
+			}
+			if t.UpTo == nil {
+				open++
+			}
+		}
+		if open > 1 {
+			return fmt.Errorf("%w: only one tier can be open-ended", ErrInvalidFeeSchedule)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be flat, percentage or tiered", ErrInvalidFeeSchedule)
+	}
+
+	return nil
+}
+
+// Compute calcula la comisión sobre amount y la devuelve desglosada. Los porcentajes se redondean
+// al centavo (o la unidad menor que corresponda) más cercano.
+func (s *FeeSchedule) Compute(amount int64) ([]transaction.FeeLine, int64) {
+	var lines []transaction.FeeLine
+
+	add := func(component, description string, value int64) {
+		if value != 0 {
+			lines = append(lines, transaction.FeeLine{Component: component, Description: description, Amount: value, Currency: s.Currency})
+		}
+	}
+
+	switch s.Kind {
+	case FeeFlat:
+		add(FeeFlat, "flat fee", s.FlatAmount)
+	case FeePercentage:
+		add(FeePercentage, bpsLabel(s.PercentageBps), percentOf(amount, s.PercentageBps))
+	case FeeTiered:
+		if t := s.tierFor(amount); t != nil {
+			label := "tier"
+			if t.UpTo != nil {
+				label = "tier up to " + money.Format(*t.UpTo, s.Currency)
+			}
+			add(FeeTiered, label+" flat", t.FlatAmount)
+			add(FeeTiered, label+" "+bpsLabel(t.PercentageBps), percentOf(amount, t.PercentageBps))
+		}
+	}
+
+	var total int64
+	for _, l := range lines {
+		total += l.Amount
+	}
+
+	if s.MinFee > 0 && total < s.MinFee {
+		add("minimum", "minimum fee adjustment", s.MinFee-total)
+		total = s.MinFee
+	}
+	if s.MaxFee > 0 && total > s.MaxFee {
+		add("maximum", "maximum fee adjustment", s.MaxFee-total)
+		total = s.MaxFee
+	}
+
+	return lines, total
+}
+
+func (s *FeeSchedule) tierFor(amount int64) *FeeTier {
+	tiers := make([]*FeeTier, len(s.Tiers))
+	for i := range s.Tiers {
+		tiers[i] = &s.Tiers[i]
+	}
+	sort.SliceStable(tiers, func(i, j int) bool {
+		if tiers[i].UpTo == nil || tiers[j].UpTo == nil {
+			return tiers[j].UpTo == nil && tiers[i].UpTo != nil
+		}
+		return *tiers[i].UpTo < *tiers[j].UpTo
+	})
+
+	for _, t := range tiers {
+		if t.UpTo == nil || amount <= *t.UpTo {
+			return t
+		}
+	}
+	return nil
+}
+
+func percentOf(amount, bps int64) int64 {
+	n := new(big.Int).Mul(big.NewInt(amount), big.NewInt(bps))
+	n.Add(n, big.NewInt(5000))
+	return n.Quo(n, big.NewInt(10000)).Int64()
+}
+
+func bpsLabel(bps int64) string {
+	return fmt.Sprintf("%d.%02d%%", bps/100, bps%100)
+}
+
+// fees resuelve la comisión de una operación; sin esquema activo para el tipo y la moneda no
+// se cobra nada.
+func fees(ctx context.Context, r *Repository, txType, currency string, amount int64) ([]transaction.FeeLine, int64, error) {
+	schedule, err := r.FeeSchedule(ctx, txType, currency)
+	if err != nil {
+		return nil, 0, err
+	}
+	if schedule == nil {
+		return nil, 0, nil
+	}
+
+	lines, total := schedule.Compute(amount)
+	return lines, total, nil
+}
+
+// SetFeeSchedule da de alta o reemplaza el esquema de comisiones del tipo y la moneda.
+func (s *Service) SetFeeSchedule(ctx context.Context, schedule *FeeSchedule) error {
+	schedule.TxType = strings.ToLower(strings.TrimSpace(schedule.TxType))
+	schedule.Currency = strings.ToUpper(strings.TrimSpace(schedule.Currency))
+
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var prev FeeSchedule
+		if err := tx.Where("tx_type = ? AND currency = ?", schedule.TxType, schedule.Currency).Limit(1).Find(&prev).Error; err != nil {
+			return err
+		}
+		if prev.ID != 0 {
+			if err := tx.Where("schedule_id = ?", prev.ID).Delete(&FeeTier{}).Error; err != nil {
+				return err
+			}
+			schedule.ID = prev.ID
+			schedule.CreatedAt = prev.CreatedAt
+		}
+
+		for i := range schedule.Tiers {
+			schedule.Tiers[i].ID = 0
+		}
+
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(schedule).Error
+	})
+}
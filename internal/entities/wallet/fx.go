@@ -0,0 +1,217 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/money"
+)
+
+// rateDecimals es la precisión con la que se guardan y aplican los tipos de cambio.
+const rateDecimals = 10
+
+var (
+	ErrRateUnavailable  = errors.New("exchange rate not available")
+	ErrQuoteNotFound    = errors.New("quote not found")
+	ErrQuoteExpired     = errors.New("quote expired")
+	ErrQuoteUsed        = errors.New("quote already used")
+	ErrQuoteMismatch    = errors.New("quote does not match the transfer")
+	ErrFXAmountTooSmall = errors.New("converted amount rounds to zero")
+)
+
+// FXRateProvider da el tipo de cambio medio (sin spread): cuántas unidades de quote vale una
+// unidad de base, ambas en unidades mayores.
+type FXRateProvider interface {
+	Rate(ctx context.Context, base, quote string) (*big.Rat, error)
+}
+
+// FXQuote congela un tipo de cambio, ya con el spread aplicado, para una conversión puntual.
+// Se usa una sola vez: la transferencia que la consume queda en TransactionID.
+type FXQuote struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	FromCurrency    string     `json:"from_currency" gorm:"size:3;not null"`
+	ToCurrency      string     `json:"to_currency" gorm:"size:3;not null"`
+	Amount          int64      `json:"amount" gorm:"not null"`           // en FromCurrency
+	ConvertedAmount int64      `json:"converted_amount" gorm:"not null"` // en ToCurrency
+	MidRate         string     `json:"mid_rate" gorm:"size:32;not null"`
+	Rate            string     `json:"rate" gorm:"size:32;not null"`
+	SpreadBps       int        `json:"spread_bps" gorm:"not null"`
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt          *time.Time `json:"used_at"`
+	TransactionID   *uint      `json:"transaction_id"`
+	CreatedAt       time.Time
+}
+
+func (FXQuote) TableName() string { return "fx_quotes" }
+
+// CreateQuote cotiza amount de from a to con el spread configurado y deja el tipo congelado
+// durante quoteTTL.
+func (s *Service) CreateQuote(ctx context.Context, userID uint, req *QuoteRequest) (*FXQuote, error) {
+	from := strings.ToUpper(strings.TrimSpace(req.FromCurrency))
+	to := strings.ToUpper(strings.TrimSpace(req.ToCurrency))
+	if from == to {
+		return nil, ErrCurrencyMismatch
+	}
+
+	amount, err := parseAmount(req.Amount, from)
+	if err != nil {
+		return nil, err
+	}
+
+	mid, rate, err := s.clientRate(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := convert(amount.Amount, from, to, rate)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &FXQuote{
+		UserID:          userID,
+		FromCurrency:    from,
+		ToCurrency:      to,
+		Amount:          amount.Amount,
+		ConvertedAmount: converted,
+		MidRate:         mid.FloatString(rateDecimals),
+		Rate:            rate.FloatString(rateDecimals),
+		SpreadBps:       s.spreadBps,
+		ExpiresAt:       time.Now().Add(s.quoteTTL),
+	}
+
+	if err := s.repo.CreateQuote(ctx, q); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+func (s *Service) GetQuote(ctx context.Context, id uint) (*FXQuote, error) {
+	return s.repo.FindQuote(ctx, id)
+}
+
+// clientRate devuelve el tipo medio y el que se le aplica al cliente (medio menos el spread),
+// este último truncado a rateDecimals para que lo que se muestra sea exactamente lo que se usa.
+func (s *Service) clientRate(ctx context.Context, from, to string) (mid, rate *big.Rat, err error) {
+	if s.rates == nil {
+		return nil, nil, ErrRateUnavailable
+	}
+
+	mid, err = s.rates.Rate(ctx, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mid == nil || mid.Sign() <= 0 {
+		return nil, nil, ErrRateUnavailable
+	}
+
+	rate = new(big.Rat).Mul(mid, big.NewRat(int64(10000-s.spreadBps), 10000))
+	rate, err = parseRate(truncate(rate, rateDecimals))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mid, rate, nil
+}
+
+// fxLeg resuelve cuánto recibe la cuenta destino en una transferencia entre monedas: con la
+// cotización indicada (que se valida acá y se marca usada al final) o al tipo vigente.
+type fxLeg struct {
+	Converted int64
+	Rate      string
+	Quote     *FXQuote
+}
+
+func (s *Service) resolveFX(ctx context.Context, r *Repository, ownerID uint, req *TransferRequest, amount int64, to string) (*fxLeg, error) {
+	if req.QuoteID == nil {
+		_, rate, err := s.clientRate(ctx, req.Currency, to)
+		if err != nil {
+			return nil, err
+		}
+		converted, err := convert(amount, req.Currency, to, rate)
+		if err != nil {
+			return nil, err
+		}
+		return &fxLeg{Converted: converted, Rate: rate.FloatString(rateDecimals)}, nil
+	}
+
+	q, err := r.FindQuote(ctx, *req.QuoteID)
+	if err != nil {
+		return nil, err
+	}
+	if q.UserID != ownerID {
+		return nil, ErrQuoteNotFound
+	}
+	if q.FromCurrency != req.Currency || q.ToCurrency != to || q.Amount != amount {
+		return nil, ErrQuoteMismatch
+	}
+	if q.UsedAt != nil {
+		return nil, ErrQuoteUsed
+	}
+	if time.Now().After(q.ExpiresAt) {
+		return nil, ErrQuoteExpired
+	}
+
+	return &fxLeg{Converted: q.ConvertedAmount, Rate: q.Rate, Quote: q}, nil
+}
+
+// convert pasa amount (unidades menores de from) a unidades menores de to, truncando a favor
+// de la casa.
+func convert(amount int64, from, to string, rate *big.Rat) (int64, error) {
+	v := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+
+	shift := money.Exponent(to) - money.Exponent(from)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil)
+	if shift >= 0 {
+		v.Mul(v, new(big.Rat).SetInt(scale))
+	} else {
+		v.Quo(v, new(big.Rat).SetInt(scale))
+	}
+
+	out := new(big.Int).Quo(v.Num(), v.Denom())
+	if !out.IsInt64() {
+		return 0, money.ErrOverflow
+	}
+	if out.Sign() <= 0 {
+		return 0, ErrFXAmountTooSmall
+	}
+
+	return out.Int64(), nil
+}
+
+// scaleAmount devuelve part/total de value, truncado hacia cero. Sirve para llevar una reversión
+// parcial a cada asiento de la original, incluida la pata convertida de una transferencia FX.
+func scaleAmount(value, part, total int64) int64 {
+	if total == 0 {
+		return 0
+	}
+	n := new(big.Int).Mul(big.NewInt(value), big.NewInt(part))
+	return n.Quo(n, big.NewInt(total)).Int64()
+}
+
+func parseRate(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() <= 0 {
+		return nil, ErrRateUnavailable
+	}
+	return r, nil
+}
+
+func truncate(r *big.Rat, decimals int) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	n := new(big.Int).Mul(r.Num(), scale)
+	n.Quo(n, r.Denom())
+	return new(big.Rat).SetFrac(n, scale).FloatString(decimals)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
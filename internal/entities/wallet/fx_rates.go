@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FXRate es un tipo de cambio medio cargado a mano (admin set-rate) para el proveedor de base.
+type FXRate struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	Base      string `json:"base" gorm:"size:3;not null;uniqueIndex:idx_fx_rates_pair"`
+	Quote     string `json:"quote" gorm:"size:3;not null;uniqueIndex:idx_fx_rates_pair"`
+	Rate      string `json:"rate" gorm:"size:32;not null"`
+	UpdatedAt time.Time
+}
+
+func (FXRate) TableName() string { return "fx_rates" }
+
+// DBRateProvider lee los tipos de la tabla fx_rates. Si solo está cargado el par inverso, usa
+// su recíproco.
+type DBRateProvider struct {
+	db *gorm.DB
+}
+
+func NewDBRateProvider(db *gorm.DB) *DBRateProvider {
+	return &DBRateProvider{db: db}
+}
+
+func (p *DBRateProvider) Rate(ctx context.Context, base, quote string) (*big.Rat, error) {
+	return lookupRate(base, quote, func(b, q string) (string, bool) {
+		var row FXRate
+		if err := p.db.WithContext(ctx).Where("base = ? AND quote = ?", b, q).First(&row).Error; err != nil {
+			return "", false
+		}
+		return row.Rate, true
+	})
+}
+
+// SetRate da de alta o actualiza el tipo medio de un par.
+func (p *DBRateProvider) SetRate(ctx context.Context, base, quote, rate string) error {
+	base, quote = strings.ToUpper(strings.TrimSpace(base)), strings.ToUpper(strings.TrimSpace(quote))
+	if base == quote {
+		return ErrCurrencyMismatch
+	}
+	r, err := parseRate(rate)
+	if err != nil {
+		return fmt.Errorf("invalid rate %q", rate)
+	}
+
+	row := &FXRate{Base: base, Quote: quote, Rate: r.FloatString(rateDecimals)}
+
+	return p.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base"}, {Name: "quote"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(row).Error
+}
+
+// FileRateProvider sirve tipos fijos desde un JSON de la forma {"USD/ARS": "1050.25", ...};
+// pensado para desarrollo local.
+type FileRateProvider struct {
+	rates map[string]string
+}
+
+func NewFileRateProvider(path string) (*FileRateProvider, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rates map[string]string
+	if err := json.Unmarshal(raw, &rates); err != nil {
+		return nil, fmt.Errorf("fx rates file %s: %w", path, err)
+	}
+
+	p := &FileRateProvider{rates: make(map[string]string, len(rates))}
+	for pair, rate := range rates {
+		if _, err := parseRate(rate); err != nil {
+			return nil, fmt.Errorf("fx rates file %s: invalid rate for %s", path, pair)
+		}
+		p.rates[strings.ToUpper(strings.TrimSpace(pair))] = rate
+	}
+
+	return p, nil
+}
+
+func (p *FileRateProvider) Rate(_ context.Context, base, quote string) (*big.Rat, error) {
+	return lookupRate(base, quote, func(b, q string) (string, bool) {
+		r, ok := p.rates[b+"/"+q]
+		return r, ok
+	})
+}
+
+func lookupRate(base, quote string, find func(base, quote string) (string, bool)) (*big.Rat, error) {
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+
+	if s, ok := find(base, quote); ok {
+		return parseRate(s)
+	}
+
+	if s, ok := find(quote, base); ok {
+		r, err := parseRate(s)
+		if err != nil {
+			return nil, err
+		}
+		return r.Inv(r), nil
+	}
+
+	return nil, ErrRateUnavailable
+}
@@ -2,35 +2,80 @@ package wallet
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/sebaactis/wallet-go-api/internal/authz"
 	"github.com/sebaactis/wallet-go-api/internal/entities/account"
-	"github.com/sebaactis/wallet-go-api/internal/httpmw"
 	"github.com/sebaactis/wallet-go-api/internal/httputil"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+	"github.com/sebaactis/wallet-go-api/internal/validation"
 )
 
 type HTTPHandler struct {
-	service *Service
-	accrepo *account.Repository
+	service    *Service
+	statements *StatementArchive
+	accrepo    *account.Repository
+	validator  validation.StructValidator
 }
 
-func NewHTTPHandler(service *Service) *HTTPHandler { return &HTTPHandler{service: service} }
+func NewHTTPHandler(service *Service, statements *StatementArchive, accrepo *account.Repository, validator validation.StructValidator) *HTTPHandler {
+	return &HTTPHandler{service: service, statements: statements, accrepo: accrepo, validator: validator}
+}
+
+// validate normaliza las monedas (el cliente puede mandarlas en minúsculas) y aplica los tags
+// validate del request. Si falla ya respondió 400.
+func (h *HTTPHandler) validate(w http.ResponseWriter, req any, currencies ...*string) bool {
+	for _, c := range currencies {
+		*c = strings.ToUpper(strings.TrimSpace(*c))
+	}
 
+	if fields, ok := h.validator.ValidateStruct(req); !ok {
+		httputil.WriteError(w, http.StatusBadRequest, "validation error", fields)
+		return false
+	}
+	return true
+}
+
+// idemRef arma la referencia con la que el servicio deduplica en la base, como segunda barrera
+// detrás del middleware de idempotencia. La clave del cliente queda acotada al usuario, el método
+// y la ruta, igual que en el store: la misma clave de otro usuario u otra operación no puede
+// devolver una transacción ajena. A diferencia del store, la referencia no vence: la misma clave
+// reusada después de IDEMPOTENCY_TTL devuelve la transacción original en lugar de crear otra.
 func idemRef(r *http.Request) string {
-	return r.Header.Get("Idempotency-Key")
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		return ""
+	}
+
+	subject, _ := authz.SubjectFromContext(r.Context())
+	sum := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + " " + key))
+	return fmt.Sprintf("idem:%d:%x", subject.UserID, sum[:16])
 }
 
-func (h *HTTPHandler) ensureOwner(ctx context.Context, accountID, userID uint) error {
+// authorizeAccount carga la cuenta y evalúa la política de authz para la acción pedida.
+func (h *HTTPHandler) authorizeAccount(ctx context.Context, accountID uint, action authz.Action) error {
 	acc, err := h.accrepo.FindByID(ctx, accountID)
 	if err != nil {
-		return err
+		return ErrAccountNotFound
 	}
-	if acc.UserID != userID {
-		return errors.New("forbidden")
+	return authz.Authorize(ctx, action, authz.Resource{OwnerID: acc.UserID})
+}
+
+func writeAuthzErr(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrAccountNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "account not found", nil)
+		return
 	}
-	return nil
+	httputil.WriteError(w, authz.Status(err), err.Error(), nil)
 }
 
 // POST /v1/wallet/deposit
@@ -42,18 +87,12 @@ func (h *HTTPHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authUser, ok := httpmw.UserIDFromContext(r.Context())
-	if !ok {
-		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+	if !h.validate(w, &req, &req.Currency) {
 		return
 	}
 
-	if err := h.ensureOwner(r.Context(), req.AccountID, authUser); err != nil {
-		if err.Error() == "forbidden" {
-			httputil.WriteError(w, http.StatusForbidden, "forbidden", nil)
-			return
-		}
-		httputil.WriteError(w, http.StatusNotFound, "account not found", nil)
+	if err := h.authorizeAccount(r.Context(), req.AccountID, authz.OperateAccount); err != nil {
+		writeAuthzErr(w, err)
 		return
 	}
 
@@ -64,7 +103,7 @@ func (h *HTTPHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(TxResponse{TransactionID: t.ID, Type: t.Type, Reference: t.Reference, Amount: t.Amount, Currency: t.Currency})
+	json.NewEncoder(w).Encode(ToTxResponse(t))
 }
 
 func (h *HTTPHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
@@ -75,18 +114,12 @@ func (h *HTTPHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authUser, ok := httpmw.UserIDFromContext(r.Context())
-	if !ok {
-		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+	if !h.validate(w, &req, &req.Currency) {
 		return
 	}
 
-	if err := h.ensureOwner(r.Context(), req.AccountID, authUser); err != nil {
-		if err.Error() == "forbidden" {
-			httputil.WriteError(w, http.StatusForbidden, "forbidden", nil)
-			return
-		}
-		httputil.WriteError(w, http.StatusNotFound, "account not found", nil)
+	if err := h.authorizeAccount(r.Context(), req.AccountID, authz.OperateAccount); err != nil {
+		writeAuthzErr(w, err)
 		return
 	}
 
@@ -97,7 +130,7 @@ func (h *HTTPHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(TxResponse{TransactionID: t.ID, Type: t.Type, Reference: t.Reference, Amount: t.Amount, Currency: t.Currency})
+	json.NewEncoder(w).Encode(ToTxResponse(t))
 }
 
 // POST /v1/wallet/transfer
@@ -109,35 +142,268 @@ func (h *HTTPHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authUser, ok := httpmw.UserIDFromContext(r.Context())
+	if !h.validate(w, &req, &req.Currency) {
+		return
+	}
+
+	if err := h.authorizeAccount(r.Context(), req.FromAccountID, authz.OperateAccount); err != nil {
+		writeAuthzErr(w, err)
+		return
+	}
+
+	t, err := h.service.Transfer(r.Context(), &req, idemRef(r))
+
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(ToTxResponse(t))
+}
+
+// POST /v1/wallet/transactions/{id}/reverse
+func (h *HTTPHandler) Reverse(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid id", nil)
+		return
+	}
+
+	var req ReverseRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	orig, err := h.service.GetTransaction(r.Context(), uint(id))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	// Revierte quien devuelve la plata: el receptor de una transferencia. Depósitos y retiros
+	// no tienen contraparte, así que quedan para admin.
+	resource := authz.Resource{}
+	if orig.Type == "transfer" && orig.ToAccountID != nil {
+		acc, err := h.accrepo.FindByID(r.Context(), *orig.ToAccountID)
+		if err != nil {
+			writeAuthzErr(w, ErrAccountNotFound)
+			return
+		}
+		resource.OwnerID = acc.UserID
+	}
+
+	if err := authz.Authorize(r.Context(), authz.ReverseTransaction, resource); err != nil {
+		writeAuthzErr(w, err)
+		return
+	}
+
+	t, err := h.service.Reverse(r.Context(), orig.ID, req.Amount, idemRef(r))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ToTxResponse(t))
+}
+
+// POST /v1/wallet/holds
+func (h *HTTPHandler) CreateHold(w http.ResponseWriter, r *http.Request) {
+	var req HoldRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+
+	if !h.validate(w, &req, &req.Currency) {
+		return
+	}
+
+	if err := h.authorizeAccount(r.Context(), req.FromAccountID, authz.OperateAccount); err != nil {
+		writeAuthzErr(w, err)
+		return
+	}
+
+	hold, err := h.service.CreateHold(r.Context(), &req, idemRef(r))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ToHoldResponse(hold))
+}
+
+// GET /v1/wallet/holds/{id}
+func (h *HTTPHandler) GetHold(w http.ResponseWriter, r *http.Request) {
+	hold, ok := h.loadHold(w, r, authz.ReadAccount)
 	if !ok {
-		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
 		return
 	}
 
-	if err := h.ensureOwner(r.Context(), req.FromAccountID, authUser); err != nil {
-		if err.Error() == "forbidden" {
-			httputil.WriteError(w, http.StatusForbidden, "forbidden", nil)
+	json.NewEncoder(w).Encode(ToHoldResponse(hold))
+}
+
+// POST /v1/wallet/holds/{id}/capture
+func (h *HTTPHandler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	hold, ok := h.loadHold(w, r, authz.OperateAccount)
+	if !ok {
+		return
+	}
+
+	var req CaptureRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
 			return
 		}
-		httputil.WriteError(w, http.StatusNotFound, "account not found", nil)
+	}
+
+	hold, t, err := h.service.CaptureHold(r.Context(), hold.ID, req.Amount)
+	if err != nil {
+		writeErr(w, err)
 		return
 	}
 
-	t, err := h.service.Transfer(r.Context(), &req, idemRef(r))
+	json.NewEncoder(w).Encode(map[string]any{
+		"hold":        ToHoldResponse(hold),
+		"transaction": ToTxResponse(t),
+	})
+}
+
+// POST /v1/wallet/holds/{id}/void
+func (h *HTTPHandler) VoidHold(w http.ResponseWriter, r *http.Request) {
+	hold, ok := h.loadHold(w, r, authz.OperateAccount)
+	if !ok {
+		return
+	}
+
+	hold, err := h.service.VoidHold(r.Context(), hold.ID)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(ToHoldResponse(hold))
+}
+
+// POST /v1/fx/quotes
+func (h *HTTPHandler) CreateQuote(w http.ResponseWriter, r *http.Request) {
+	var req QuoteRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+
+	if !h.validate(w, &req, &req.FromCurrency, &req.ToCurrency) {
+		return
+	}
+
+	subject, ok := authz.SubjectFromContext(r.Context())
+	if !ok {
+		writeAuthzErr(w, authz.ErrUnauthenticated)
+		return
+	}
+
+	q, err := h.service.CreateQuote(r.Context(), subject.UserID, &req)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ToQuoteResponse(q))
+}
+
+// GET /v1/fx/quotes/{id}
+func (h *HTTPHandler) GetQuote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid id", nil)
+		return
+	}
+
+	q, err := h.service.GetQuote(r.Context(), uint(id))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	// Las cotizaciones son de quien las pidió; para otro usuario no existen.
+	if err := authz.Authorize(r.Context(), authz.ReadAccount, authz.Resource{OwnerID: q.UserID}); err != nil {
+		writeErr(w, ErrQuoteNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(ToQuoteResponse(q))
+}
+
+// POST /v1/reconciliation?freeze=true
+func (h *HTTPHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	freeze, _ := strconv.ParseBool(r.URL.Query().Get("freeze"))
 
+	report, err := h.service.Reconcile(r.Context(), freeze)
 	if err != nil {
 		writeErr(w, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(TxResponse{TransactionID: t.ID, Type: t.Type, Reference: t.Reference, Amount: t.Amount, Currency: t.Currency})
+	httputil.WriteJSON(w, http.StatusOK, ToReconciliationResponse(report))
+}
+
+// POST /v1/accounts/{id}/unfreeze
+func (h *HTTPHandler) Unfreeze(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid id", nil)
+		return
+	}
+
+	if err := h.service.Unfreeze(r.Context(), uint(id)); err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadHold busca el hold de la URL y verifica la acción sobre la cuenta de origen.
+func (h *HTTPHandler) loadHold(w http.ResponseWriter, r *http.Request, action authz.Action) (*Hold, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid id", nil)
+		return nil, false
+	}
+
+	hold, err := h.service.GetHold(r.Context(), uint(id))
+	if err != nil {
+		writeErr(w, err)
+		return nil, false
+	}
+
+	if err := h.authorizeAccount(r.Context(), hold.FromAccountID, action); err != nil {
+		writeAuthzErr(w, err)
+		return nil, false
+	}
+
+	return hold, true
 }
 
 func writeErr(w http.ResponseWriter, err error) {
+	var limitErr *LimitError
+
 	switch {
 	case errors.Is(err, ErrNegativeAmount):
 		http.Error(w, `{"error":"amount must be > 0"}`, http.StatusBadRequest)
+	case errors.Is(err, money.ErrInvalidAmount), errors.Is(err, money.ErrTooManyDecimals):
+		http.Error(w, `{"error":"invalid amount for currency"}`, http.StatusBadRequest)
+	case errors.Is(err, money.ErrOverflow):
+		http.Error(w, `{"error":"amount out of range"}`, http.StatusBadRequest)
 	case errors.Is(err, ErrCurrencyMismatch):
 		http.Error(w, `{"error":"currency mismatch"}`, http.StatusBadRequest)
 	case errors.Is(err, ErrInsufficientFunds):
@@ -146,7 +412,287 @@ func writeErr(w http.ResponseWriter, err error) {
 		http.Error(w, `{"error":"account not found"}`, http.StatusNotFound)
 	case errors.Is(err, ErrSameAccount):
 		http.Error(w, `{"error":"same account"}`, http.StatusBadRequest)
+	case errors.Is(err, ErrAccountFrozen):
+		httputil.WriteError(w, http.StatusLocked, err.Error(), nil)
+	case errors.Is(err, ErrConcurrentUpdate):
+		http.Error(w, `{"error":"account busy, retry"}`, http.StatusConflict)
+	case errors.Is(err, ErrTransactionNotFound):
+		httputil.WriteError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrNotReversible), errors.Is(err, ErrAlreadyReversed):
+		httputil.WriteError(w, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, ErrReversalExceeds):
+		httputil.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ErrHoldNotFound):
+		httputil.WriteError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrHoldNotActive), errors.Is(err, ErrHoldExpired):
+		httputil.WriteError(w, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, ErrCaptureExceedsHold), errors.Is(err, ErrInvalidHoldTTL):
+		httputil.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ErrQuoteNotFound):
+		httputil.WriteError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrQuoteExpired), errors.Is(err, ErrQuoteUsed):
+		httputil.WriteError(w, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, ErrQuoteMismatch), errors.Is(err, ErrFXAmountTooSmall):
+		httputil.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ErrRateUnavailable):
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error(), nil)
+	case errors.As(err, &limitErr):
+		details := map[string]string{"scope": limitErr.Scope, "kind": limitErr.Kind, "limit": limitErr.Limit()}
+		if limitErr.ResetsAt != nil {
+			details["resetsAt"] = limitErr.ResetsAt.Format(time.RFC3339)
+		}
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error(), details)
 	default:
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 	}
 }
+
+// GET /v1/accounts/{id}/transactions
+func (h *HTTPHandler) History(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid id", nil)
+		return
+	}
+
+	if err := h.authorizeAccount(r.Context(), uint(id), authz.ReadAccount); err != nil {
+		writeAuthzErr(w, err)
+		return
+	}
+
+	filter, fields := parseHistoryFilter(r)
+	if len(fields) > 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid filters", fields)
+		return
+	}
+
+	page, err := h.service.History(r.Context(), uint(id), filter)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid cursor", nil)
+			return
+		}
+		writeErr(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, page)
+}
+
+// statementWriteTimeout reemplaza al WriteTimeout del servidor mientras se manda un extracto.
+const statementWriteTimeout = 10 * time.Minute
+
+var statementContentTypes = map[string]string{
+	StatementCSV:     "text/csv; charset=utf-8",
+	StatementOFX:     "application/x-ofx",
+	StatementCamt053: "application/xml",
+}
+
+var statementExtensions = map[string]string{
+	StatementCSV:     "csv",
+	StatementOFX:     "ofx",
+	StatementCamt053: "xml",
+}
+
+// GET /v1/accounts/{id}/statements?from=&to=&format=csv|ofx|camt053
+// Sin from arranca el primer día del mes; sin to llega hasta ahora.
+func (h *HTTPHandler) Statement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid id", nil)
+		return
+	}
+
+	if err := h.authorizeAccount(r.Context(), uint(id), authz.ReadAccount); err != nil {
+		writeAuthzErr(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+	fields := map[string]string{}
+
+	format := strings.ToLower(q.Get("format"))
+	if format == "" {
+		format = StatementCSV
+	}
+	if _, ok := statementContentTypes[format]; !ok {
+		fields["format"] = ErrInvalidStatementFormat.Error()
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	to := now
+
+	if v := q.Get("from"); v != "" {
+		t, err := parseDateParam(v, false)
+		if err != nil {
+			fields["from"] = "must be RFC3339 or YYYY-MM-DD"
+		} else {
+			from = *t
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := parseDateParam(v, true)
+		if err != nil {
+			fields["to"] = "must be RFC3339 or YYYY-MM-DD"
+		} else {
+			to = *t
+		}
+	}
+
+	if len(fields) > 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid parameters", fields)
+		return
+	}
+
+	st, err := h.service.Statement(r.Context(), uint(id), from, to)
+	if err != nil {
+		if errors.Is(err, ErrInvalidStatementPeriod) {
+			httputil.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		writeErr(w, err)
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s-%s.%s", st.Account.ID,
+		st.From.Format("20060102"), st.To.Format("20060102"), statementExtensions[format])
+
+	w.Header().Set("Content-Type", statementContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Un extracto largo puede tardar más que el WriteTimeout del servidor.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(statementWriteTimeout))
+
+	// Ya se mandó el 200: si algo falla a mitad de camino solo queda cortar la respuesta, que
+	// llega sin su cierre.
+	if err := h.service.WriteStatement(r.Context(), st, format, w); err != nil {
+		log.Printf("statement account %d: %v", st.Account.ID, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// GET /v1/accounts/{id}/statements/{month}.pdf
+// month es YYYY-MM. El mes en curso sale hasta ahora; los cerrados, del archivo de extractos.
+func (h *HTTPHandler) MonthlyStatement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid id", nil)
+		return
+	}
+
+	month, err := time.ParseInLocation("2006-01", chi.URLParam(r, "month"), time.Local)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, ErrInvalidStatementMonth.Error(), nil)
+		return
+	}
+
+	if err := h.authorizeAccount(r.Context(), uint(id), authz.ReadAccount); err != nil {
+		writeAuthzErr(w, err)
+		return
+	}
+
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(statementWriteTimeout))
+
+	f, err := h.statements.Monthly(r.Context(), uint(id), month)
+	if err != nil {
+		if errors.Is(err, ErrInvalidStatementMonth) {
+			httputil.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		writeErr(w, err)
+		return
+	}
+	defer f.Close()
+
+	filename := fmt.Sprintf("statement-%d-%s.pdf", id, month.Format("2006-01"))
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	http.ServeContent(w, r, filename, time.Time{}, f)
+}
+
+var historyTypes = map[string]bool{"deposit": true, "withdraw": true, "transfer": true, "reversal": true}
+
+func parseHistoryFilter(r *http.Request) (*HistoryFilter, map[string]string) {
+	q := r.URL.Query()
+	filter := &HistoryFilter{Cursor: q.Get("cursor")}
+	fields := map[string]string{}
+
+	for _, raw := range q["type"] {
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if t == "" {
+				continue
+			}
+			if !historyTypes[t] {
+				fields["type"] = "must be deposit, withdraw, transfer or reversal"
+				continue
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+
+	if v := q.Get("from"); v != "" {
+		from, err := parseDateParam(v, false)
+		if err != nil {
+			fields["from"] = "must be RFC3339 or YYYY-MM-DD"
+		}
+		filter.From = from
+	}
+
+	if v := q.Get("to"); v != "" {
+		to, err := parseDateParam(v, true)
+		if err != nil {
+			fields["to"] = "must be RFC3339 or YYYY-MM-DD"
+		}
+		filter.To = to
+	}
+
+	if v := q.Get("minAmount"); v != "" {
+		d := money.Decimal(v)
+		filter.MinAmount = &d
+	}
+
+	if v := q.Get("maxAmount"); v != "" {
+		d := money.Decimal(v)
+		filter.MaxAmount = &d
+	}
+
+	if v := q.Get("counterpartyAccountId"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || n == 0 {
+			fields["counterpartyAccountId"] = "must be a positive integer"
+		} else {
+			cp := uint(n)
+			filter.CounterpartyID = &cp
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			fields["limit"] = "must be a positive integer"
+		}
+		filter.Limit = n
+	}
+
+	return filter, fields
+}
+
+// Las fechas sin hora en "to" incluyen el día completo.
+func parseDateParam(v string, endOfDay bool) (*time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}
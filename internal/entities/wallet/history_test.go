@@ -0,0 +1,75 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	ledger "github.com/sebaactis/wallet-go-api/internal/entities/legder"
+	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+)
+
+// El filtro por monto usa lo que se movió en la cuenta, en su moneda: la pata acreditada de un FX
+// se filtra por lo acreditado, no por el monto de origen.
+func TestHistoryAmountFilterUsesAccountCurrency(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	svc := NewService(db, nil, 0, 0)
+
+	u := &user.User{Name: "ana", Email: "ana@x.io", Password: "x"}
+	if err := db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+	usd := &account.Account{UserID: u.ID, Currency: "USD"}
+	eur := &account.Account{UserID: u.ID, Currency: "EUR"}
+	for _, a := range []*account.Account{usd, eur} {
+		if err := db.Create(a).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// USD 10,00 -> EUR 9,00.
+	now := time.Now()
+	tx := &transaction.Transaction{Type: "transfer", FromAccountID: &usd.ID, ToAccountID: &eur.ID, Amount: 1000, Currency: "USD",
+		ToAmount: 900, ToCurrency: "EUR", FXRate: "0.9", CreatedAt: now}
+	if err := db.Create(tx).Error; err != nil {
+		t.Fatal(err)
+	}
+	entries := []*ledger.LedgerEntry{
+		{TransactionID: tx.ID, AccountID: usd.ID, Amount: -1000, Currency: "USD", CreatedAt: now},
+		{TransactionID: tx.ID, AccountID: eur.ID, Amount: 900, Currency: "EUR", CreatedAt: now},
+	}
+	if err := db.Create(entries).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	dec := func(s string) *money.Decimal { d := money.Decimal(s); return &d }
+
+	tests := []struct {
+		name     string
+		account  uint
+		min, max *money.Decimal
+		want     int
+	}{
+		{"credited leg below min", eur.ID, dec("9.50"), nil, 0},
+		{"credited leg within range", eur.ID, dec("9"), dec("9"), 1},
+		{"credited leg above max", eur.ID, nil, dec("8.99"), 0},
+		{"debited leg by its absolute amount", usd.ID, dec("10"), dec("10"), 1},
+		{"debited leg above max", usd.ID, nil, dec("9.99"), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := svc.History(ctx, tt.account, &HistoryFilter{MinAmount: tt.min, MaxAmount: tt.max})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Items) != tt.want {
+				t.Errorf("got %d items, want %d", len(resp.Items), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	ledger "github.com/sebaactis/wallet-go-api/internal/entities/legder"
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
+	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+	"gorm.io/gorm"
+)
+
+const (
+	HoldActive   = "active"
+	HoldCaptured = "captured"
+	HoldVoided   = "voided"
+	HoldExpired  = "expired"
+
+	defaultHoldTTL = 7 * 24 * time.Hour
+	maxHoldTTL     = 30 * 24 * time.Hour
+
+	expireBatchSize = 100
+)
+
+var (
+	ErrHoldNotFound       = errors.New("hold not found")
+	ErrHoldNotActive      = errors.New("hold is not active")
+	ErrHoldExpired        = errors.New("hold expired")
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds held amount")
+	ErrInvalidHoldTTL     = errors.New("invalid hold expiration")
+)
+
+// Hold reserva fondos de FromAccountID para un pago a ToAccountID que todavía no se confirmó. El
+// saldo contable no cambia; lo reservado se suma a account.Held y deja de estar disponible.
+type Hold struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	FromAccountID  uint       `json:"from_account_id" gorm:"not null;index"`
+	ToAccountID    uint       `json:"to_account_id" gorm:"not null"`
+	Amount         int64      `json:"amount" gorm:"not null"` // unidades menores
+	CapturedAmount int64      `json:"captured_amount" gorm:"not null;default:0"`
+	Currency       string     `json:"currency" gorm:"size:3;not null"`
+	Status         string     `json:"status" gorm:"size:20;not null;index"`
+	Reference      *string    `json:"reference" gorm:"size:100;uniqueIndex"`
+	TransactionID  *uint      `json:"transaction_id"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null;index"`
+	ClosedAt       *time.Time `json:"closed_at"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (s *Service) CreateHold(ctx context.Context, req *HoldRequest, ref string) (*Hold, error) {
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+
+	amount, err := parseAmount(req.Amount, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if req.FromAccountID == req.ToAccountID {
+		return nil, ErrSameAccount
+	}
+
+	ttl := defaultHoldTTL
+	if req.ExpiresInSeconds != 0 {
+		ttl = time.Duration(req.ExpiresInSeconds) * time.Second
+		if ttl <= 0 || ttl > maxHoldTTL {
+			return nil, ErrInvalidHoldTTL
+		}
+	}
+
+	if ref != "" {
+		if h, err := s.repo.FindHoldByReference(ctx, ref); err == nil {
+			return h, nil
+		}
+	}
+
+	var out *Hold
+
+	err = s.transaction(ctx, func(tx *gorm.DB) error {
+		r := s.repo.withTx(tx)
+
+		from, err := r.GetAccount(ctx, req.FromAccountID)
+		if err != nil {
+			return notFound(err)
+		}
+		to, err := r.GetAccount(ctx, req.ToAccountID)
+		if err != nil {
+			return notFound(err)
+		}
+
+		if from.Currency != req.Currency || to.Currency != req.Currency {
+			return ErrCurrencyMismatch
+		}
+		if err := operable(from, to); err != nil {
+			return err
+		}
+		if from.Available() < amount.Amount {
+			return ErrInsufficientFunds
+		}
+
+		h := &Hold{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        amount.Amount,
+			Currency:      req.Currency,
+			Status:        HoldActive,
+			Reference:     toRefPtr(ref),
+			ExpiresAt:     time.Now().Add(ttl),
+		}
+
+		if err := r.CreateHold(ctx, h); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) && ref != "" {
+				if prev, e := r.FindHoldByReference(ctx, ref); e == nil {
+					out = prev
+					return nil
+				}
+			}
+			return err
+		}
+
+		held, err := money.AddInt64(from.Held, amount.Amount)
+		if err != nil {
+			return err
+		}
+		if err := r.UpdateFunds(ctx, from, from.Balance, held); err != nil {
+			return err
+		}
+
+		if err := publishHold(ctx, r, outbox.HoldCreated, h, from); err != nil {
+			return err
+		}
+
+		out = h
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *Service) GetHold(ctx context.Context, id uint) (*Hold, error) {
+	return s.repo.FindHold(ctx, id)
+}
+
+// CaptureHold convierte el hold en una transferencia. Si amount es nil se captura todo; una
+// captura parcial libera el resto, porque un hold se captura una sola vez.
+func (s *Service) CaptureHold(ctx context.Context, id uint, amount *money.Decimal) (*Hold, *transaction.Transaction, error) {
+	var (
+		out *Hold
+		tx  *transaction.Transaction
+	)
+
+	err := s.transaction(ctx, func(db *gorm.DB) error {
+		r := s.repo.withTx(db)
+
+		h, err := r.FindHold(ctx, id)
+		if err != nil {
+			return err
+		}
+		if h.Status != HoldActive {
+			return ErrHoldNotActive
+		}
+		if !time.Now().Before(h.ExpiresAt) {
+			return ErrHoldExpired
+		}
+
+		capture := h.Amount
+		if amount != nil {
+			m, err := parseAmount(*amount, h.Currency)
+			if err != nil {
+				return err
+			}
+			if m.Amount > h.Amount {
+				return ErrCaptureExceedsHold
+			}
+			capture = m.Amount
+		}
+
+		from, err := r.GetAccount(ctx, h.FromAccountID)
+		if err != nil {
+			return notFound(err)
+		}
+		to, err := r.GetAccount(ctx, h.ToAccountID)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := operable(from, to); err != nil {
+			return err
+		}
+
+		// Capturar es transferir, así que cobra la comisión de transfer. El hold reservó solo el
+		// monto: la comisión tiene que entrar en lo disponible fuera de los holds.
+		lines, fee, err := fees(ctx, r, "transfer", from.Currency, capture)
+		if err != nil {
+			return err
+		}
+		debit, err := money.AddInt64(capture, fee)
+		if err != nil {
+			return err
+		}
+		if from.Available()+h.Amount < debit {
+			return ErrInsufficientFunds
+		}
+
+		t := &transaction.Transaction{
+			Type:          "transfer",
+			FromAccountID: &from.ID,
+			ToAccountID:   &to.ID,
+			Amount:        capture,
+			Currency:      h.Currency,
+			Fee:           fee,
+			Fees:          lines,
+		}
+		if err := r.CreateTx(ctx, t); err != nil {
+			return err
+		}
+
+		// Los límites se controlan al capturar, que es cuando la plata se mueve.
+		if err := enforceLimits(ctx, r, from, t); err != nil {
+			return err
+		}
+
+		entries := []*ledger.LedgerEntry{
+			{TransactionID: t.ID, AccountID: from.ID, Amount: -debit, Currency: from.Currency},
+			{TransactionID: t.ID, AccountID: to.ID, Amount: +capture, Currency: to.Currency},
+		}
+		if fee > 0 {
+			revenue, err := systemEntry(ctx, r, ledger.FeesRevenue, t, fee, from.Currency)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, revenue)
+		}
+		if err := r.CreateEntries(ctx, entries...); err != nil {
+			return err
+		}
+
+		if err := r.CloseHold(ctx, h, HoldCaptured, capture, &t.ID); err != nil {
+			return err
+		}
+
+		if err := r.UpdateFunds(ctx, from, from.Balance-debit, from.Held-h.Amount); err != nil {
+			return err
+		}
+		toBal, err := money.AddInt64(to.Balance, capture)
+		if err != nil {
+			return err
+		}
+		if err := r.UpdateBalance(ctx, to, toBal); err != nil {
+			return err
+		}
+
+		if err := publishHold(ctx, r, outbox.HoldCaptured, h, from); err != nil {
+			return err
+		}
+		if err := publishPosted(ctx, r, t, from, -debit); err != nil {
+			return err
+		}
+		if err := publishPosted(ctx, r, t, to, capture); err != nil {
+			return err
+		}
+
+		out, tx = h, t
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return out, tx, nil
+}
+
+// VoidHold libera los fondos reservados sin mover dinero.
+func (s *Service) VoidHold(ctx context.Context, id uint) (*Hold, error) {
+	return s.release(ctx, id, HoldVoided)
+}
+
+// ExpireHolds libera los holds vencidos. Devuelve cuántos cerró. Un hold que no se puede liberar
+// no frena a los demás: se registra, se sigue y los errores vuelven juntos al final.
+func (s *Service) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	var errs []error
+	var last uint
+
+	for {
+		ids, err := s.repo.ExpiredHoldIDs(ctx, now, last, expireBatchSize)
+		if err != nil {
+			return expired, errors.Join(append(errs, err)...)
+		}
+
+		for _, id := range ids {
+			last = id
+			if _, err := s.release(ctx, id, HoldExpired); err != nil {
+				if errors.Is(err, ErrHoldNotActive) {
+					continue // lo capturó o anuló otro request mientras tanto
+				}
+				log.Printf("hold sweeper: hold %d: %v", id, err)
+				errs = append(errs, fmt.Errorf("hold %d: %w", id, err))
+				continue
+			}
+			expired++
+		}
+
+		if len(ids) < expireBatchSize {
+			return expired, errors.Join(errs...)
+		}
+	}
+}
+
+// RunHoldSweeper vence holds cada interval hasta que se cancele ctx. Con interval 0 no corre.
+func (s *Service) RunHoldSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.ExpireHolds(ctx, time.Now())
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("hold sweeper: %v", err)
+			}
+			if n > 0 {
+				log.Printf("hold sweeper: %d holds expired", n)
+			}
+		}
+	}
+}
+
+func (s *Service) release(ctx context.Context, id uint, status string) (*Hold, error) {
+	var out *Hold
+
+	err := s.transaction(ctx, func(db *gorm.DB) error {
+		r := s.repo.withTx(db)
+
+		h, err := r.FindHold(ctx, id)
+		if err != nil {
+			return err
+		}
+		if h.Status != HoldActive {
+			return ErrHoldNotActive
+		}
+
+		from, err := r.GetAccount(ctx, h.FromAccountID)
+		if err != nil {
+			return notFound(err)
+		}
+
+		if err := r.CloseHold(ctx, h, status, 0, nil); err != nil {
+			return err
+		}
+		if err := r.UpdateFunds(ctx, from, from.Balance, from.Held-h.Amount); err != nil {
+			return err
+		}
+
+		eventType := outbox.HoldVoided
+		if status == HoldExpired {
+			eventType = outbox.HoldExpired
+		}
+		if err := publishHold(ctx, r, eventType, h, from); err != nil {
+			return err
+		}
+
+		out = h
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
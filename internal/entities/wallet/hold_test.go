@@ -0,0 +1,191 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	ledger "github.com/sebaactis/wallet-go-api/internal/entities/legder"
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+	"gorm.io/gorm"
+)
+
+func newHoldFixture(t *testing.T, db *gorm.DB, svc *Service, deposit string) (from, to *account.Account) {
+	t.Helper()
+	ctx := context.Background()
+
+	u := &user.User{Name: "ana", Email: t.Name() + "@x.io", Password: "x"}
+	if err := db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+	from = &account.Account{UserID: u.ID, Currency: "USD"}
+	to = &account.Account{UserID: u.ID, Currency: "USD"}
+	for _, a := range []*account.Account{from, to} {
+		if err := db.Create(a).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := svc.Deposit(ctx, &DepositRequest{AccountID: from.ID, Amount: money.Decimal(deposit), Currency: "USD"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	return from, to
+}
+
+func balanceOf(t *testing.T, db *gorm.DB, id uint) (balance, held int64) {
+	t.Helper()
+
+	var a account.Account
+	if err := db.First(&a, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return a.Balance, a.Held
+}
+
+// Capturar un hold cobra la comisión de transfer como una transferencia directa, con su asiento en
+// fees_revenue.
+func TestCaptureHoldChargesTransferFee(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	svc := NewService(db, nil, 0, 0)
+
+	if err := svc.SetFeeSchedule(ctx, &FeeSchedule{TxType: "transfer", Currency: "USD", Kind: FeePercentage, PercentageBps: 100, MinFee: 50, Active: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	from, to := newHoldFixture(t, db, svc, "100")
+
+	h, err := svc.CreateHold(ctx, &HoldRequest{FromAccountID: from.ID, ToAccountID: to.ID, Amount: "80", Currency: "USD"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partial := money.Decimal("60")
+	_, tx, err := svc.CaptureHold(ctx, h.ID, &partial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1 % de 60,00 = 0,60.
+	if tx.Fee != 60 {
+		t.Errorf("transaction fee = %d, want 60", tx.Fee)
+	}
+	if bal, held := balanceOf(t, db, from.ID); bal != 10000-6000-60 || held != 0 {
+		t.Errorf("from balance %d held %d, want %d and 0", bal, held, 10000-6000-60)
+	}
+	if bal, _ := balanceOf(t, db, to.ID); bal != 6000 {
+		t.Errorf("to balance %d, want 6000", bal)
+	}
+
+	var revenue int64
+	db.Raw(`SELECT COALESCE(SUM(le.amount), 0) FROM ledger_entries le JOIN accounts a ON a.id = le.account_id
+		WHERE le.transaction_id = ? AND a.system_code = ?`, tx.ID, ledger.FeesRevenue).Scan(&revenue)
+	if revenue != 60 {
+		t.Errorf("fees revenue entry = %d, want 60", revenue)
+	}
+
+	var sum int64
+	db.Raw("SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE transaction_id = ?", tx.ID).Scan(&sum)
+	if sum != 0 {
+		t.Errorf("capture entries sum to %d, want 0", sum)
+	}
+}
+
+// El hold reserva solo el monto: si lo que queda fuera de él no cubre la comisión, la captura
+// falla y el hold sigue activo.
+func TestCaptureHoldFeeNeedsAvailableFunds(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	svc := NewService(db, nil, 0, 0)
+
+	if err := svc.SetFeeSchedule(ctx, &FeeSchedule{TxType: "transfer", Currency: "USD", Kind: FeeFlat, FlatAmount: 150, Active: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	from, to := newHoldFixture(t, db, svc, "50")
+
+	h, err := svc.CreateHold(ctx, &HoldRequest{FromAccountID: from.ID, ToAccountID: to.ID, Amount: "49", Currency: "USD"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := svc.CaptureHold(ctx, h.ID, nil); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("capture error = %v, want ErrInsufficientFunds", err)
+	}
+	if bal, held := balanceOf(t, db, from.ID); bal != 5000 || held != 4900 {
+		t.Errorf("from balance %d held %d, want 5000 and 4900", bal, held)
+	}
+
+	got, err := svc.GetHold(ctx, h.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != HoldActive {
+		t.Errorf("hold status %s, want %s", got.Status, HoldActive)
+	}
+}
+
+// Un hold que no se puede liberar no frena el barrido: los demás vencen igual, aunque los que
+// fallan llenen un lote entero, y el error dice cuáles quedaron.
+func TestExpireHoldsSkipsHoldsThatFail(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	svc := NewService(db, nil, 0, 0)
+
+	from, to := newHoldFixture(t, db, svc, "1000")
+
+	var holds []*Hold
+	for range expireBatchSize + 1 {
+		h, err := svc.CreateHold(ctx, &HoldRequest{FromAccountID: from.ID, ToAccountID: to.ID, Amount: "1", Currency: "USD"}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		holds = append(holds, h)
+	}
+	good := holds[len(holds)-1]
+
+	if err := db.Exec(fmt.Sprintf(`CREATE TRIGGER holds_stuck BEFORE UPDATE OF status ON holds WHEN OLD.id < %d
+		BEGIN SELECT RAISE(ABORT, 'hold is stuck'); END`, good.ID)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		n   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := svc.ExpireHolds(ctx, time.Now().Add(maxHoldTTL))
+		done <- result{n, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("ExpireHolds did not return")
+	}
+
+	if res.n != 1 {
+		t.Errorf("expired %d holds, want 1", res.n)
+	}
+	if res.err == nil || !strings.Contains(res.err.Error(), fmt.Sprintf("hold %d:", holds[0].ID)) {
+		t.Errorf("error = %v, want one naming hold %d", res.err, holds[0].ID)
+	}
+
+	got, err := svc.GetHold(ctx, good.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != HoldExpired {
+		t.Errorf("hold after the failing ones is %s, want expired", got.Status)
+	}
+	if _, held := balanceOf(t, db, from.ID); held != int64(expireBatchSize)*100 {
+		t.Errorf("held = %d, want only the stuck holds", held)
+	}
+}
@@ -0,0 +1,268 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+)
+
+const (
+	LimitScopeAccount = "account"
+	LimitScopeUser    = "user"
+	LimitScopeTier    = "tier"
+
+	LimitSingle        = "single"
+	LimitDailyVolume   = "daily_volume"
+	LimitMonthlyVolume = "monthly_volume"
+	LimitDailyCount    = "daily_count"
+)
+
+var (
+	ErrLimitExceeded = errors.New("limit exceeded")
+	ErrInvalidLimit  = errors.New("invalid limit")
+)
+
+// Limit acota lo que puede mover una cuenta en una moneda: depósitos, extracciones y
+// transferencias salientes (incluidas las capturas de holds). Las reversiones no cuentan.
+// ScopeID es el id de la cuenta, el del usuario o el nivel de KYC según Scope. Value va en
+// unidades menores, salvo en daily_count que es una cantidad de operaciones.
+//
+// Un límite de usuario reemplaza al de su nivel de KYC para el mismo tipo; los de cuenta se
+// aplican además de ambos.
+type Limit struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	Scope     string `json:"scope" gorm:"size:10;not null;uniqueIndex:idx_transaction_limits_key"`
+	ScopeID   uint   `json:"scope_id" gorm:"not null;uniqueIndex:idx_transaction_limits_key"`
+	Currency  string `json:"currency" gorm:"size:3;not null;uniqueIndex:idx_transaction_limits_key"`
+	Kind      string `json:"kind" gorm:"size:20;not null;uniqueIndex:idx_transaction_limits_key"`
+	Value     int64  `json:"value" gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Limit) TableName() string { return "transaction_limits" }
+
+func (l *Limit) Validate() error {
+	switch l.Scope {
+	case LimitScopeAccount, LimitScopeUser:
+		if l.ScopeID == 0 {
+			return fmt.Errorf("%w: %s id is required", ErrInvalidLimit, l.Scope)
+		}
+	case LimitScopeTier:
+	default:
+		return fmt.Errorf("%w: scope must be account, user or tier", ErrInvalidLimit)
+	}
+
+	switch l.Kind {
+	case LimitSingle, LimitDailyVolume, LimitMonthlyVolume, LimitDailyCount:
+	default:
+		return fmt.Errorf("%w: kind must be single, daily_volume, monthly_volume or daily_count", ErrInvalidLimit)
+	}
+
+	if l.Value <= 0 {
+		return fmt.Errorf("%w: value must be > 0", ErrInvalidLimit)
+	}
+
+	return nil
+}
+
+// LimitUsage acumula lo consumido por una cuenta o un usuario en un día ("2006-01-02") o un mes
+// ("2006-01"). Se crea la primera vez que hace falta en el período, sembrado desde transactions,
+// y después solo se incrementa con un UPDATE condicional: es lo que hace atómico el control.
+type LimitUsage struct {
+	ID        uint   `gorm:"primaryKey"`
+	Scope     string `gorm:"size:10;not null;uniqueIndex:idx_limit_usage_key"`
+	ScopeID   uint   `gorm:"not null;uniqueIndex:idx_limit_usage_key"`
+	Currency  string `gorm:"size:3;not null;uniqueIndex:idx_limit_usage_key"`
+	Period    string `gorm:"size:10;not null;uniqueIndex:idx_limit_usage_key"`
+	Volume    int64  `gorm:"not null;default:0"`
+	TxCount   int64  `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+func (LimitUsage) TableName() string { return "limit_usage" }
+
+// LimitError indica qué límite se alcanzó. ResetsAt es nil para el de operación individual.
+type LimitError struct {
+	Scope    string
+	Kind     string
+	Currency string
+	Value    int64
+	ResetsAt *time.Time
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s %s limit exceeded", e.Scope, e.Kind)
+}
+
+func (e *LimitError) Unwrap() error { return ErrLimitExceeded }
+
+// Limit devuelve el valor del límite formateado: un monto o, en daily_count, una cantidad.
+func (e *LimitError) Limit() string {
+	if e.Kind == LimitDailyCount {
+		return fmt.Sprintf("%d", e.Value)
+	}
+	return money.Format(e.Value, e.Currency)
+}
+
+// usageWindow agrupa los límites que comparten contador: mismo dueño y mismo período.
+type usageWindow struct {
+	scope    string
+	scopeID  uint
+	period   string
+	from, to time.Time
+	volume   *Limit
+	count    *Limit
+}
+
+// enforceLimits controla los límites de acc para la operación t, recién creada, y consume el cupo.
+// Va dentro de la transacción de la operación: si algo falla después, el consumo se deshace con el
+// rollback. Se llama después de CreateTx para que una referencia repetida, que devuelve la
+// transacción previa, no consuma cupo.
+//
+// Los contadores de la cuenta y del usuario se actualizan aunque no haya límites: un límite que se
+// crea (o se vuelve a crear) a mitad del período encuentra el consumo al día.
+func enforceLimits(ctx context.Context, r *Repository, acc *account.Account, t *transaction.Transaction) error {
+	limits, err := r.LimitsFor(ctx, acc)
+	if err != nil {
+		return err
+	}
+
+	// El de usuario pisa al del nivel de KYC; el de cuenta es independiente.
+	type limitKey struct{ owner, kind string }
+	effective := make(map[limitKey]*Limit)
+	for _, l := range limits {
+		key := limitKey{owner: LimitScopeUser, kind: l.Kind}
+		if l.Scope == LimitScopeAccount {
+			key.owner = LimitScopeAccount
+		}
+		if prev, ok := effective[key]; ok && prev.Scope == LimitScopeUser {
+			continue
+		}
+		effective[key] = l
+	}
+
+	amount := t.Amount
+	now := t.CreatedAt.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	windows := make(map[string]*usageWindow)
+	window := func(owner string, daily bool) *usageWindow {
+		key := owner
+		w := &usageWindow{scope: owner, scopeID: acc.UserID}
+		if owner == LimitScopeAccount {
+			w.scopeID = acc.ID
+		}
+		if daily {
+			key += "/day"
+			w.period, w.from, w.to = day.Format("2006-01-02"), day, day.AddDate(0, 0, 1)
+		} else {
+			key += "/month"
+			w.period, w.from, w.to = month.Format("2006-01"), month, month.AddDate(0, 1, 0)
+		}
+		if prev, ok := windows[key]; ok {
+			return prev
+		}
+		windows[key] = w
+		return w
+	}
+
+	for _, owner := range []string{LimitScopeAccount, LimitScopeUser} {
+		window(owner, true)
+		window(owner, false)
+	}
+
+	for key, l := range effective {
+		switch l.Kind {
+		case LimitSingle:
+			if amount > l.Value {
+				return &LimitError{Scope: l.Scope, Kind: l.Kind, Currency: l.Currency, Value: l.Value}
+			}
+		case LimitDailyVolume:
+			window(key.owner, true).volume = l
+		case LimitDailyCount:
+			window(key.owner, true).count = l
+		case LimitMonthlyVolume:
+			window(key.owner, false).volume = l
+		}
+	}
+
+	// En orden fijo, para que con varios límites alcanzados se informe siempre el mismo.
+	keys := make([]string, 0, len(windows))
+	for k := range windows {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := consume(ctx, r, windows[k], acc.Currency, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func consume(ctx context.Context, r *Repository, w *usageWindow, currency string, t *transaction.Transaction) error {
+	amount := t.Amount
+	usage, err := r.LimitUsage(ctx, w.scope, w.scopeID, currency, w.period, w.from, w.to, t.ID)
+	if err != nil {
+		return err
+	}
+
+	var maxVolume, maxCount int64
+	if w.volume != nil {
+		maxVolume = w.volume.Value
+	}
+	if w.count != nil {
+		maxCount = w.count.Value
+	}
+
+	ok, err := r.ConsumeLimit(ctx, usage.ID, amount, maxVolume, maxCount)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+
+	// El UPDATE no pasó: se relee para decir cuál de los dos se alcanzó. El contador solo crece,
+	// así que lo que se lee es al menos lo que hizo fallar la condición.
+	if usage, err = r.LimitUsage(ctx, w.scope, w.scopeID, currency, w.period, w.from, w.to, t.ID); err != nil {
+		return err
+	}
+
+	hit := w.count
+	if w.volume != nil && usage.Volume+amount > maxVolume {
+		hit = w.volume
+	}
+
+	resets := w.to
+	return &LimitError{Scope: hit.Scope, Kind: hit.Kind, Currency: hit.Currency, Value: hit.Value, ResetsAt: &resets}
+}
+
+// SetLimit da de alta o actualiza un límite.
+func (s *Service) SetLimit(ctx context.Context, l *Limit) error {
+	l.Scope = strings.ToLower(strings.TrimSpace(l.Scope))
+	l.Kind = strings.ToLower(strings.TrimSpace(l.Kind))
+	l.Currency = strings.ToUpper(strings.TrimSpace(l.Currency))
+
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	return s.repo.SaveLimit(ctx, l)
+}
+
+// DeleteLimit quita un límite. El consumo ya acumulado en el período queda registrado.
+func (s *Service) DeleteLimit(ctx context.Context, scope string, scopeID uint, currency, kind string) error {
+	return s.repo.DeleteLimit(ctx, strings.ToLower(scope), scopeID, strings.ToUpper(currency), strings.ToLower(kind))
+}
@@ -0,0 +1,57 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sebaactis/wallet-go-api/internal/money"
+)
+
+// Lo que se mueve mientras no hay límite también cuenta: si el límite se borra y se vuelve a crear
+// en el mismo día, el contador que ya existía no puede quedar atrasado.
+func TestLimitCountsUsageWhileUnlimited(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	svc := NewService(db, nil, 0, 0)
+
+	acc, _ := newHoldFixture(t, db, svc, "10")
+
+	limit := func() *Limit {
+		return &Limit{Scope: LimitScopeAccount, ScopeID: acc.ID, Currency: "USD", Kind: LimitDailyVolume, Value: 10000}
+	}
+	deposit := func(amount string) error {
+		_, err := svc.Deposit(ctx, &DepositRequest{AccountID: acc.ID, Amount: money.Decimal(amount), Currency: "USD"}, "")
+		return err
+	}
+
+	if err := svc.SetLimit(ctx, limit()); err != nil {
+		t.Fatal(err)
+	}
+	if err := deposit("30"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteLimit(ctx, LimitScopeAccount, acc.ID, "USD", LimitDailyVolume); err != nil {
+		t.Fatal(err)
+	}
+	if err := deposit("50"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetLimit(ctx, limit()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 10 + 30 + 50 = 90 en el día: 20 más superan los 100.
+	err := deposit("20")
+	var le *LimitError
+	if !errors.As(err, &le) {
+		t.Fatalf("deposit error = %v, want a LimitError", err)
+	}
+	if le.Scope != LimitScopeAccount || le.Kind != LimitDailyVolume {
+		t.Errorf("limit hit %s %s, want account daily_volume", le.Scope, le.Kind)
+	}
+
+	if err := deposit("10"); err != nil {
+		t.Errorf("deposit up to the limit: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
+	"gorm.io/gorm"
+)
+
+var ErrAccountFrozen = errors.New("account frozen")
+
+// Discrepancy es una cuenta cuyo saldo guardado no coincide con la suma de sus asientos.
+// Transactions son las transacciones de la cuenta que explican la diferencia: desbalanceadas, sin
+// asiento para la cuenta o con asientos en otra moneda. Si está vacía, el ledger es consistente y
+// el saldo se escribió por fuera de él.
+type Discrepancy struct {
+	AccountID     uint
+	UserID        uint
+	Currency      string
+	SystemCode    *string
+	Balance       int64
+	LedgerBalance int64
+	Transactions  []uint
+	Frozen        bool
+}
+
+func (d *Discrepancy) Difference() int64 {
+	return d.Balance - d.LedgerBalance
+}
+
+type ReconciliationReport struct {
+	CheckedAt              time.Time
+	AccountsChecked        int64
+	Discrepancies          []*Discrepancy
+	UnbalancedTransactions []uint
+}
+
+func (r *ReconciliationReport) OK() bool {
+	return len(r.Discrepancies) == 0 && len(r.UnbalancedTransactions) == 0
+}
+
+// Reconcile recalcula el saldo de cada cuenta desde el ledger y lo compara con el guardado. Con
+// freeze, las cuentas de clientes con diferencias quedan congeladas hasta que un admin las libere;
+// las internas solo se reportan, porque congelarlas frenaría todas las operaciones.
+func (s *Service) Reconcile(ctx context.Context, freeze bool) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{CheckedAt: time.Now()}
+
+	var err error
+	if report.AccountsChecked, err = s.repo.CountAccounts(ctx); err != nil {
+		return nil, err
+	}
+
+	if report.UnbalancedTransactions, err = s.repo.UnbalancedTransactions(ctx); err != nil {
+		return nil, err
+	}
+
+	mismatches, err := s.repo.BalanceMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range mismatches {
+		d := &Discrepancy{
+			AccountID:     m.ID,
+			UserID:        m.UserID,
+			Currency:      m.Currency,
+			SystemCode:    m.SystemCode,
+			Balance:       m.Balance,
+			LedgerBalance: m.LedgerBalance,
+			Frozen:        m.FrozenAt != nil,
+		}
+
+		if d.Transactions, err = s.repo.SuspectTransactions(ctx, m.ID, m.Currency, report.UnbalancedTransactions); err != nil {
+			return nil, err
+		}
+
+		if freeze && d.SystemCode == nil && !d.Frozen {
+			reason := fmt.Sprintf("reconciliation %s: balance %d, ledger %d",
+				report.CheckedAt.UTC().Format(time.RFC3339), d.Balance, d.LedgerBalance)
+			if err := s.freeze(ctx, d, reason); err != nil {
+				return nil, err
+			}
+			d.Frozen = true
+		}
+
+		report.Discrepancies = append(report.Discrepancies, d)
+	}
+
+	return report, nil
+}
+
+// Unfreeze libera una cuenta congelada. No corrige el saldo: eso queda a cargo de quien investigó.
+func (s *Service) Unfreeze(ctx context.Context, accountID uint) error {
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		r := s.repo.withTx(tx)
+
+		acc, err := r.FindAccount(ctx, accountID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAccountNotFound
+			}
+			return err
+		}
+
+		if err := r.Unfreeze(ctx, accountID); err != nil {
+			return err
+		}
+		if acc.FrozenAt == nil {
+			return nil
+		}
+
+		return r.Publish(ctx, outbox.AccountUnfrozen, "account", acc.ID, acc.UserID,
+			AccountEvent{AccountID: acc.ID, Currency: acc.Currency})
+	})
+}
+
+func (s *Service) freeze(ctx context.Context, d *Discrepancy, reason string) error {
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		r := s.repo.withTx(tx)
+
+		if err := r.Freeze(ctx, d.AccountID, reason); err != nil {
+			return err
+		}
+
+		return r.Publish(ctx, outbox.AccountFrozen, "account", d.AccountID, d.UserID,
+			AccountEvent{AccountID: d.AccountID, Currency: d.Currency, Reason: reason})
+	})
+}
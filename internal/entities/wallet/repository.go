@@ -2,11 +2,17 @@ package wallet
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"time"
 
+	"github.com/sebaactis/wallet-go-api/internal/authz"
 	"github.com/sebaactis/wallet-go-api/internal/entities/account"
 	ledger "github.com/sebaactis/wallet-go-api/internal/entities/legder"
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
 	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository struct {
@@ -28,27 +34,683 @@ func (r *Repository) CreateTx(ctx context.Context, t *transaction.Transaction) e
 func (r *Repository) FindTxByReference(ctx context.Context, ref string) (*transaction.Transaction, error) {
 	var t transaction.Transaction
 
-	if err := r.db.WithContext(ctx).Where("reference = ?", ref).First(&t).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Fees").Where("reference = ?", ref).First(&t).Error; err != nil {
 		return nil, err
 	}
 
 	return &t, nil
 }
 
+// CreateEntries escribe todos los asientos de una transacción de una vez; si no balancean no se
+// escribe ninguno.
 func (r *Repository) CreateEntries(ctx context.Context, entries ...*ledger.LedgerEntry) error {
+	if err := ledger.Validate(entries); err != nil {
+		return err
+	}
 	return r.db.WithContext(ctx).Create(&entries).Error
 }
 
-func (r *Repository) GetAccount(ctx context.Context, accountId uint, currency string) (*account.Account, error) {
+// SystemAccount devuelve la cuenta interna code de la moneda y la crea si todavía no existe.
+func (r *Repository) SystemAccount(ctx context.Context, code, currency string) (*account.Account, error) {
+	var a account.Account
+
+	err := r.db.WithContext(ctx).Where("system_code = ? AND currency = ?", code, currency).First(&a).Error
+	if err == nil {
+		return &a, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var ownerID uint
+	if err := r.db.WithContext(ctx).Table("users").Where("role = ?", authz.RoleSystem).Limit(1).Pluck("id", &ownerID).Error; err != nil {
+		return nil, err
+	}
+	if ownerID == 0 {
+		return nil, errors.New("system user not found")
+	}
+
+	a = account.Account{UserID: ownerID, Currency: currency, SystemCode: &code}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&a).Error; err != nil {
+		return nil, err
+	}
+
+	// Si otra instancia la creó primero el insert no hizo nada: se relee.
+	if err := r.db.WithContext(ctx).Where("system_code = ? AND currency = ?", code, currency).First(&a).Error; err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// AddSystemBalance mueve el saldo de una cuenta interna con un incremento atómico. A diferencia de
+// las cuentas de clientes no hay compare-and-swap: ninguna regla depende de su saldo y todas las
+// transacciones pasan por ellas, así que un CAS solo generaría reintentos.
+func (r *Repository) AddSystemBalance(ctx context.Context, accountID uint, delta int64) error {
+	return r.db.WithContext(ctx).
+		Model(&account.Account{}).
+		Where("id = ? AND system_code IS NOT NULL", accountID).
+		Update("balance", gorm.Expr("balance + ?", delta)).Error
+}
+
+// GetAccount carga una cuenta de cliente para operar. Las cuentas internas del ledger no se
+// pueden usar como origen ni destino: para los clientes no existen. La moneda la valida cada
+// operación, que así devuelve ErrCurrencyMismatch en lugar de un not found.
+func (r *Repository) GetAccount(ctx context.Context, accountId uint) (*account.Account, error) {
+	var a account.Account
+
+	if err := r.db.WithContext(ctx).Where("id = ? AND system_code IS NULL", accountId).First(&a).Error; err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// UpdateBalance escribe el saldo solo si nadie modificó la cuenta desde que se leyó (compare-and-swap
+// sobre version). Si otra transacción ganó, devuelve ErrConcurrentUpdate y el llamador reintenta.
+func (r *Repository) UpdateBalance(ctx context.Context, acc *account.Account, newBalance int64) error {
+	return r.UpdateFunds(ctx, acc, newBalance, acc.Held)
+}
+
+// UpdateFunds es UpdateBalance pero también escribe el monto reservado por holds, con el mismo
+// compare-and-swap sobre version.
+func (r *Repository) UpdateFunds(ctx context.Context, acc *account.Account, newBalance, newHeld int64) error {
+	result := r.db.WithContext(ctx).
+		Model(&account.Account{}).
+		Where("id = ? AND version = ?", acc.ID, acc.Version).
+		Updates(map[string]interface{}{
+			"balance": newBalance,
+			"held":    newHeld,
+			"version": gorm.Expr("version + 1"),
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConcurrentUpdate
+	}
+
+	acc.Balance = newBalance
+	acc.Held = newHeld
+	acc.Version++
+	return nil
+}
+
+type entryQuery struct {
+	Types          []string
+	From           *time.Time
+	To             *time.Time
+	MinAmount      *int64
+	MaxAmount      *int64
+	CounterpartyID *uint
+	BeforeID       uint
+	Limit          int
+}
+
+func (r *Repository) FindAccount(ctx context.Context, id uint) (*account.Account, error) {
+	var a account.Account
+
+	if err := r.db.WithContext(ctx).First(&a, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// FindAccountWithUser es FindAccount con el titular cargado.
+func (r *Repository) FindAccountWithUser(ctx context.Context, id uint) (*account.Account, error) {
 	var a account.Account
 
-	if err := r.db.WithContext(ctx).First(&a, accountId, currency).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("User").First(&a, id).Error; err != nil {
 		return nil, err
 	}
 
 	return &a, nil
 }
 
-func (r *Repository) UpdateBalance(ctx context.Context, id uint, newBalance float64) error {
-	return r.db.WithContext(ctx).Model(&account.Account{}).Where("id = ?", id).Update("balance", newBalance).Error
+// ListEntries devuelve los movimientos de la cuenta del más nuevo al más viejo, con su transacción.
+func (r *Repository) ListEntries(ctx context.Context, accountID uint, q entryQuery) ([]*ledger.LedgerEntry, error) {
+	var entries []*ledger.LedgerEntry
+
+	db := r.db.WithContext(ctx).
+		Model(&ledger.LedgerEntry{}).
+		Joins("JOIN transactions ON transactions.id = ledger_entries.transaction_id").
+		Where("ledger_entries.account_id = ?", accountID)
+
+	if q.BeforeID > 0 {
+		db = db.Where("ledger_entries.id < ?", q.BeforeID)
+	}
+	if len(q.Types) > 0 {
+		db = db.Where("transactions.type IN ?", q.Types)
+	}
+	if q.From != nil {
+		db = db.Where("ledger_entries.created_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		db = db.Where("ledger_entries.created_at < ?", *q.To)
+	}
+	// Los montos se comparan con el movimiento de la cuenta, en su moneda: el de la transacción va
+	// en la moneda de origen y no sirve para la pata acreditada de un FX ni su reversión.
+	if q.MinAmount != nil {
+		db = db.Where("ABS(ledger_entries.amount) >= ?", *q.MinAmount)
+	}
+	if q.MaxAmount != nil {
+		db = db.Where("ABS(ledger_entries.amount) <= ?", *q.MaxAmount)
+	}
+	if q.CounterpartyID != nil {
+		db = db.Where("(transactions.from_account_id = ? OR transactions.to_account_id = ?)", *q.CounterpartyID, *q.CounterpartyID)
+	}
+
+	err := db.Preload("Transaction").
+		Order("ledger_entries.id DESC").
+		Limit(q.Limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// RunningBalances devuelve, para cada movimiento pedido, el saldo de la cuenta luego de aplicarlo.
+func (r *Repository) RunningBalances(ctx context.Context, accountID uint, entryIDs []uint) (map[uint]int64, error) {
+	var rows []struct {
+		ID      uint
+		Balance int64
+	}
+
+	err := r.db.WithContext(ctx).
+		Table("ledger_entries AS le").
+		Select("le.id, (SELECT COALESCE(SUM(x.amount), 0) FROM ledger_entries x WHERE x.account_id = le.account_id AND x.id <= le.id) AS balance").
+		Where("le.account_id = ? AND le.id IN ?", accountID, entryIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		out[row.ID] = row.Balance
+	}
+
+	return out, nil
+}
+
+func (r *Repository) CreateHold(ctx context.Context, h *Hold) error {
+	return r.db.WithContext(ctx).Create(h).Error
+}
+
+func (r *Repository) FindHold(ctx context.Context, id uint) (*Hold, error) {
+	var h Hold
+
+	if err := r.db.WithContext(ctx).First(&h, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHoldNotFound
+		}
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+func (r *Repository) FindHoldByReference(ctx context.Context, ref string) (*Hold, error) {
+	var h Hold
+
+	if err := r.db.WithContext(ctx).Where("reference = ?", ref).First(&h).Error; err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// CloseHold pasa el hold de active al estado final. El WHERE sobre status hace que, si dos
+// requests compiten, solo uno lo cierre.
+func (r *Repository) CloseHold(ctx context.Context, h *Hold, status string, captured int64, txID *uint) error {
+	now := time.Now()
+
+	result := r.db.WithContext(ctx).
+		Model(&Hold{}).
+		Where("id = ? AND status = ?", h.ID, HoldActive).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"captured_amount": captured,
+			"transaction_id":  txID,
+			"closed_at":       now,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrHoldNotActive
+	}
+
+	h.Status = status
+	h.CapturedAmount = captured
+	h.TransactionID = txID
+	h.ClosedAt = &now
+	return nil
+}
+
+// ExpiredHoldIDs pagina por id: afterID deja atrás los holds que ya se intentaron en la pasada.
+func (r *Repository) ExpiredHoldIDs(ctx context.Context, now time.Time, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.WithContext(ctx).
+		Model(&Hold{}).
+		Where("status = ? AND expires_at <= ? AND id > ?", HoldActive, now, afterID).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+
+	return ids, err
+}
+
+func (r *Repository) FindTx(ctx context.Context, id uint) (*transaction.Transaction, error) {
+	var t transaction.Transaction
+
+	if err := r.db.WithContext(ctx).Preload("Fees").First(&t, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (r *Repository) EntriesByTransaction(ctx context.Context, txID uint) ([]*ledger.LedgerEntry, error) {
+	var entries []*ledger.LedgerEntry
+
+	err := r.db.WithContext(ctx).
+		Where("transaction_id = ?", txID).
+		Order("id").
+		Find(&entries).Error
+
+	return entries, err
+}
+
+// FeeSchedule devuelve el esquema activo del tipo y la moneda, o nil si no hay.
+func (r *Repository) FeeSchedule(ctx context.Context, txType, currency string) (*FeeSchedule, error) {
+	var s FeeSchedule
+
+	// Sin esquema es el caso común: Find en lugar de First para no loguear un "record not found"
+	// en cada operación.
+	err := r.db.WithContext(ctx).
+		Preload("Tiers").
+		Where("tx_type = ? AND currency = ? AND active = ?", txType, currency, true).
+		Limit(1).
+		Find(&s).Error
+	if err != nil {
+		return nil, err
+	}
+	if s.ID == 0 {
+		return nil, nil
+	}
+
+	return &s, nil
+}
+
+func (r *Repository) CreateQuote(ctx context.Context, q *FXQuote) error {
+	return r.db.WithContext(ctx).Create(q).Error
+}
+
+func (r *Repository) FindQuote(ctx context.Context, id uint) (*FXQuote, error) {
+	var q FXQuote
+
+	if err := r.db.WithContext(ctx).First(&q, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, err
+	}
+
+	return &q, nil
+}
+
+// UseQuote marca la cotización como consumida por la transacción. El WHERE sobre used_at hace
+// que dos transferencias no puedan usar la misma.
+func (r *Repository) UseQuote(ctx context.Context, id, txID uint) error {
+	result := r.db.WithContext(ctx).
+		Model(&FXQuote{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"used_at":        time.Now(),
+			"transaction_id": txID,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrQuoteUsed
+	}
+
+	return nil
+}
+
+// AddReversed suma al acumulado revertido solo si no se pasa del monto original.
+func (r *Repository) AddReversed(ctx context.Context, txID uint, value int64) error {
+	result := r.db.WithContext(ctx).
+		Model(&transaction.Transaction{}).
+		Where("id = ? AND reversed_amount + ? <= amount", txID, value).
+		Update("reversed_amount", gorm.Expr("reversed_amount + ?", value))
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReversalExceeds
+	}
+
+	return nil
+}
+
+func (r *Repository) CountAccounts(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&account.Account{}).Count(&n).Error
+	return n, err
+}
+
+// UnbalancedTransactions devuelve las transacciones cuyos asientos no netean a cero en alguna moneda.
+func (r *Repository) UnbalancedTransactions(ctx context.Context) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.WithContext(ctx).
+		Table("(?) AS x", r.db.Model(&ledger.LedgerEntry{}).
+			Select("transaction_id").
+			Group("transaction_id, currency").
+			Having("SUM(amount) <> 0")).
+		Distinct("transaction_id").
+		Order("transaction_id").
+		Pluck("transaction_id", &ids).Error
+
+	return ids, err
+}
+
+type balanceMismatch struct {
+	ID            uint
+	UserID        uint
+	Currency      string
+	SystemCode    *string
+	Balance       int64
+	LedgerBalance int64
+	FrozenAt      *time.Time
+}
+
+// BalanceMismatches compara saldo guardado y suma de asientos en una sola consulta, así ambos
+// salen de la misma foto aunque haya transacciones en curso.
+func (r *Repository) BalanceMismatches(ctx context.Context) ([]balanceMismatch, error) {
+	var rows []balanceMismatch
+
+	err := r.db.WithContext(ctx).
+		Table("accounts AS a").
+		Select("a.id, a.user_id, a.currency, a.system_code, a.balance, a.frozen_at, COALESCE(SUM(le.amount), 0) AS ledger_balance").
+		Joins("LEFT JOIN ledger_entries le ON le.account_id = a.id").
+		Group("a.id, a.user_id, a.currency, a.system_code, a.balance, a.frozen_at").
+		Having("a.balance <> COALESCE(SUM(le.amount), 0)").
+		Order("a.id").
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// SuspectTransactions busca, entre las transacciones de la cuenta, las que pueden explicar una
+// diferencia de saldo: desbalanceadas, que la nombran sin asentarle nada o que le asientan en otra
+// moneda.
+func (r *Repository) SuspectTransactions(ctx context.Context, accountID uint, currency string, unbalanced []uint) ([]uint, error) {
+	var ids []uint
+
+	if len(unbalanced) > 0 {
+		var touched []uint
+		err := r.db.WithContext(ctx).
+			Model(&ledger.LedgerEntry{}).
+			Where("account_id = ? AND transaction_id IN ?", accountID, unbalanced).
+			Distinct("transaction_id").
+			Pluck("transaction_id", &touched).Error
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, touched...)
+	}
+
+	var missing []uint
+	err := r.db.WithContext(ctx).
+		Model(&transaction.Transaction{}).
+		Where("(from_account_id = ? OR to_account_id = ?)", accountID, accountID).
+		Where("NOT EXISTS (SELECT 1 FROM ledger_entries le WHERE le.transaction_id = transactions.id AND le.account_id = ?)", accountID).
+		Pluck("id", &missing).Error
+	if err != nil {
+		return nil, err
+	}
+	ids = append(ids, missing...)
+
+	var foreign []uint
+	err = r.db.WithContext(ctx).
+		Model(&ledger.LedgerEntry{}).
+		Where("account_id = ? AND currency <> ?", accountID, currency).
+		Distinct("transaction_id").
+		Pluck("transaction_id", &foreign).Error
+	if err != nil {
+		return nil, err
+	}
+	ids = append(ids, foreign...)
+
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+// Freeze congela la cuenta e incrementa version, así cualquier operación en curso sobre ella
+// pierde el compare-and-swap, se reintenta y ve la cuenta congelada.
+func (r *Repository) Freeze(ctx context.Context, accountID uint, reason string) error {
+	return r.db.WithContext(ctx).
+		Model(&account.Account{}).
+		Where("id = ?", accountID).
+		Updates(map[string]interface{}{
+			"frozen_at":     time.Now(),
+			"frozen_reason": reason,
+			"version":       gorm.Expr("version + 1"),
+		}).Error
+}
+
+func (r *Repository) Unfreeze(ctx context.Context, accountID uint) error {
+	result := r.db.WithContext(ctx).
+		Model(&account.Account{}).
+		Where("id = ?", accountID).
+		Updates(map[string]interface{}{
+			"frozen_at":     nil,
+			"frozen_reason": "",
+			"version":       gorm.Expr("version + 1"),
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAccountNotFound
+	}
+
+	return nil
+}
+
+// LimitsFor devuelve los límites que alcanzan a la cuenta en su moneda: los propios, los de su
+// usuario y los del nivel de KYC del usuario.
+func (r *Repository) LimitsFor(ctx context.Context, acc *account.Account) ([]*Limit, error) {
+	var limits []*Limit
+
+	err := r.db.WithContext(ctx).
+		Where("currency = ?", acc.Currency).
+		Where(r.db.Where("scope = ? AND scope_id = ?", LimitScopeAccount, acc.ID).
+			Or("scope = ? AND scope_id = ?", LimitScopeUser, acc.UserID).
+			Or("scope = ? AND scope_id = (SELECT kyc_tier FROM users WHERE id = ?)", LimitScopeTier, acc.UserID)).
+		Find(&limits).Error
+
+	return limits, err
+}
+
+// LimitUsage devuelve el contador del período y lo crea si no existe, sembrado con lo que ya se
+// movió en [from, to) sin contar la operación en curso (exclude): la primera operación del
+// período, o la primera después de configurar un límite, parte de lo real y no de cero.
+func (r *Repository) LimitUsage(ctx context.Context, scope string, scopeID uint, currency, period string, from, to time.Time, exclude uint) (*LimitUsage, error) {
+	var u LimitUsage
+
+	find := func() error {
+		return r.db.WithContext(ctx).
+			Where("scope = ? AND scope_id = ? AND currency = ? AND period = ?", scope, scopeID, currency, period).
+			Limit(1).
+			Find(&u).Error
+	}
+
+	if err := find(); err != nil {
+		return nil, err
+	}
+	if u.ID != 0 {
+		return &u, nil
+	}
+
+	// La cuenta que origina cada operación: el destino en los depósitos, el origen en el resto.
+	q := r.db.WithContext(ctx).
+		Table("transactions t").
+		Joins("JOIN accounts a ON a.id = CASE WHEN t.type = 'deposit' THEN t.to_account_id ELSE t.from_account_id END").
+		Where("t.type IN ?", []string{"deposit", "withdraw", "transfer"}).
+		Where("t.currency = ? AND t.created_at >= ? AND t.created_at < ? AND t.id <> ?", currency, from, to, exclude)
+	if scope == LimitScopeAccount {
+		q = q.Where("a.id = ?", scopeID)
+	} else {
+		q = q.Where("a.user_id = ?", scopeID)
+	}
+
+	var seed struct {
+		Volume  int64
+		TxCount int64
+	}
+	if err := q.Select("COALESCE(SUM(t.amount), 0) AS volume, COUNT(*) AS tx_count").Scan(&seed).Error; err != nil {
+		return nil, err
+	}
+
+	u = LimitUsage{Scope: scope, ScopeID: scopeID, Currency: currency, Period: period, Volume: seed.Volume, TxCount: seed.TxCount}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&u).Error; err != nil {
+		return nil, err
+	}
+
+	// Si otra operación lo creó primero el insert no hizo nada: se relee.
+	u = LimitUsage{}
+	if err := find(); err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// ConsumeLimit suma la operación al contador solo si no supera los topes (0 = sin tope). Como la
+// condición se evalúa en el mismo UPDATE, dos operaciones concurrentes no pueden pasar ambas el
+// último cupo. Devuelve false si no había cupo.
+func (r *Repository) ConsumeLimit(ctx context.Context, usageID uint, amount, maxVolume, maxCount int64) (bool, error) {
+	q := r.db.WithContext(ctx).Model(&LimitUsage{}).Where("id = ?", usageID)
+	if maxVolume > 0 {
+		q = q.Where("volume + ? <= ?", amount, maxVolume)
+	}
+	if maxCount > 0 {
+		q = q.Where("tx_count + 1 <= ?", maxCount)
+	}
+
+	result := q.Updates(map[string]any{
+		"volume":     gorm.Expr("volume + ?", amount),
+		"tx_count":   gorm.Expr("tx_count + 1"),
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *Repository) SaveLimit(ctx context.Context, l *Limit) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scope"}, {Name: "scope_id"}, {Name: "currency"}, {Name: "kind"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(l).Error
+}
+
+func (r *Repository) DeleteLimit(ctx context.Context, scope string, scopeID uint, currency, kind string) error {
+	result := r.db.WithContext(ctx).
+		Where("scope = ? AND scope_id = ? AND currency = ? AND kind = ?", scope, scopeID, currency, kind).
+		Delete(&Limit{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Publish escribe un evento de dominio en el outbox con la conexión del repositorio: dentro de
+// una operación, en su misma transacción.
+func (r *Repository) Publish(ctx context.Context, eventType, aggregateType string, aggregateID, userID uint, payload any) error {
+	return outbox.Append(ctx, r.db, eventType, aggregateType, aggregateID, userID, payload)
+}
+
+type statementTotals struct {
+	Opening     int64
+	Credits     int64
+	CreditCount int64
+	Debits      int64
+	DebitCount  int64
+}
+
+// StatementTotals suma los movimientos de la cuenta anteriores a from (el saldo de apertura) y los
+// créditos y débitos de [from, to).
+func (r *Repository) StatementTotals(ctx context.Context, accountID uint, from, to time.Time) (*statementTotals, error) {
+	var t statementTotals
+
+	err := r.db.WithContext(ctx).
+		Model(&ledger.LedgerEntry{}).
+		Select(`COALESCE(SUM(CASE WHEN created_at < ? THEN amount ELSE 0 END), 0) AS opening,
+			COALESCE(SUM(CASE WHEN created_at >= ? AND amount > 0 THEN amount ELSE 0 END), 0) AS credits,
+			COUNT(CASE WHEN created_at >= ? AND amount > 0 THEN 1 END) AS credit_count,
+			COALESCE(SUM(CASE WHEN created_at >= ? AND amount < 0 THEN -amount ELSE 0 END), 0) AS debits,
+			COUNT(CASE WHEN created_at >= ? AND amount < 0 THEN 1 END) AS debit_count`,
+			from, from, from, from, from).
+		Where("account_id = ? AND created_at < ?", accountID, to).
+		Scan(&t).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// StatementEntries recorre los movimientos de la cuenta en [from, to), en orden, de a lotes: fn
+// recibe cada lote con sus transacciones y nunca está toda la extracción en memoria.
+func (r *Repository) StatementEntries(ctx context.Context, accountID uint, from, to time.Time, batch int, fn func([]*ledger.LedgerEntry) error) error {
+	var entries []*ledger.LedgerEntry
+
+	return r.db.WithContext(ctx).
+		Preload("Transaction").
+		Where("account_id = ? AND created_at >= ? AND created_at < ?", accountID, from, to).
+		FindInBatches(&entries, batch, func(_ *gorm.DB, _ int) error {
+			return fn(entries)
+		}).Error
+}
+
+// StatementAccounts devuelve, ordenadas por id y de a limit, las cuentas de clientes creadas antes
+// de before con id mayor a afterID, con su titular.
+func (r *Repository) StatementAccounts(ctx context.Context, before time.Time, afterID uint, limit int) ([]*account.Account, error) {
+	var accounts []*account.Account
+
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("system_code IS NULL AND created_at < ? AND id > ?", before, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
 }
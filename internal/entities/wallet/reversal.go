@@ -0,0 +1,177 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	ledger "github.com/sebaactis/wallet-go-api/internal/entities/legder"
+	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrNotReversible       = errors.New("transaction cannot be reversed")
+	ErrAlreadyReversed     = errors.New("transaction already fully reversed")
+	ErrReversalExceeds     = errors.New("reversal amount exceeds remaining amount")
+)
+
+func (s *Service) GetTransaction(ctx context.Context, id uint) (*transaction.Transaction, error) {
+	return s.repo.FindTx(ctx, id)
+}
+
+// Reverse crea una transacción de tipo reversal que compensa, total o parcialmente, a la original:
+// por cada asiento de la original se postea el espejo por el monto revertido. Lo ya revertido se
+// acumula en la original, así que la suma de reversiones nunca pasa del monto original.
+func (s *Service) Reverse(ctx context.Context, id uint, amount *money.Decimal, ref string) (*transaction.Transaction, error) {
+	if ref != "" {
+		if t, err := s.repo.FindTxByReference(ctx, ref); err == nil {
+			return t, nil
+		}
+	}
+
+	var out *transaction.Transaction
+
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		r := s.repo.withTx(tx)
+
+		orig, err := r.FindTx(ctx, id)
+		if err != nil {
+			return err
+		}
+		if orig.Type == "reversal" {
+			return ErrNotReversible
+		}
+
+		remaining := orig.Reversible()
+		if remaining <= 0 {
+			return ErrAlreadyReversed
+		}
+
+		value := remaining
+		if amount != nil {
+			m, err := parseAmount(*amount, orig.Currency)
+			if err != nil {
+				return err
+			}
+			if m.Amount > remaining {
+				return ErrReversalExceeds
+			}
+			value = m.Amount
+		}
+
+		entries, err := r.EntriesByTransaction(ctx, orig.ID)
+		if err != nil {
+			return err
+		}
+
+		rev := &transaction.Transaction{
+			Type:          "reversal",
+			Reference:     toRefPtr(ref),
+			FromAccountID: orig.ToAccountID,
+			ToAccountID:   orig.FromAccountID,
+			Amount:        value,
+			Currency:      orig.Currency,
+			ReversesID:    &orig.ID,
+		}
+
+		// En una transferencia entre monedas la pata acreditada se devuelve en su moneda, en
+		// proporción a lo revertido y al tipo original (no al vigente). La reversión queda
+		// expresada desde quien devuelve: sale en ToCurrency y entra en Currency.
+		converted := value
+		if orig.IsFX() {
+			converted = scaleAmount(value, orig.ToAmount, orig.Amount)
+			if converted <= 0 {
+				return ErrFXAmountTooSmall
+			}
+			rev.Amount = converted
+			rev.Currency = orig.ToCurrency
+			rev.ToAmount = value
+			rev.ToCurrency = orig.Currency
+			rev.FXRate = orig.FXRate
+			if rate, err := parseRate(orig.FXRate); err == nil {
+				rev.FXRate = truncate(rate.Inv(rate), rateDecimals)
+			}
+		}
+
+		if err := r.CreateTx(ctx, rev); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) && ref != "" {
+				if prev, e := r.FindTxByReference(ctx, ref); e == nil {
+					out = prev
+					return nil
+				}
+			}
+			return err
+		}
+
+		// Guardia contra dos reversiones concurrentes que juntas superen el monto.
+		if err := r.AddReversed(ctx, orig.ID, value); err != nil {
+			return err
+		}
+
+		// Cada asiento se espeja en proporción a lo revertido; como las patas de una misma moneda
+		// son iguales y opuestas, el truncado es el mismo y la reversión también balancea. La
+		// comisión se devuelve en la misma proporción: la pata del monto escala exacta a value, así
+		// que la del origen trunca igual que la de fees_revenue.
+		type posted struct {
+			acc   *account.Account
+			delta int64
+		}
+		mirror := make([]*ledger.LedgerEntry, 0, len(entries))
+		var touched []posted
+		for _, e := range entries {
+			delta := -scaleAmount(e.Amount, value, orig.Amount)
+
+			acc, err := r.FindAccount(ctx, e.AccountID)
+			if err != nil {
+				return notFound(err)
+			}
+
+			if acc.IsSystem() {
+				if err := r.AddSystemBalance(ctx, acc.ID, delta); err != nil {
+					return err
+				}
+				mirror = append(mirror, &ledger.LedgerEntry{TransactionID: rev.ID, AccountID: acc.ID, Amount: delta, Currency: e.Currency})
+				continue
+			}
+
+			if err := operable(acc); err != nil {
+				return err
+			}
+			if delta < 0 && acc.Available() < -delta {
+				return ErrInsufficientFunds
+			}
+
+			newBal, err := money.AddInt64(acc.Balance, delta)
+			if err != nil {
+				return err
+			}
+			if err := r.UpdateBalance(ctx, acc, newBal); err != nil {
+				return err
+			}
+
+			mirror = append(mirror, &ledger.LedgerEntry{TransactionID: rev.ID, AccountID: acc.ID, Amount: delta, Currency: e.Currency})
+			touched = append(touched, posted{acc, delta})
+		}
+
+		if err := r.CreateEntries(ctx, mirror...); err != nil {
+			return err
+		}
+
+		for _, p := range touched {
+			if err := publishPosted(ctx, r, rev, p.acc, p.delta); err != nil {
+				return err
+			}
+		}
+
+		out = rev
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
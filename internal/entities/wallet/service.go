@@ -2,11 +2,20 @@ package wallet
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
 	ledger "github.com/sebaactis/wallet-go-api/internal/entities/legder"
 	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
+	"github.com/sebaactis/wallet-go-api/internal/httputil"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+	"github.com/sebaactis/wallet-go-api/internal/platform/database"
 	"gorm.io/gorm"
 )
 
@@ -16,22 +25,31 @@ var (
 	ErrNegativeAmount    = errors.New("amount must be > 0")
 	ErrInsufficientFunds = errors.New("insufficient funds")
 	ErrSameAccount       = errors.New("from and to accounts are the same")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrConcurrentUpdate  = errors.New("account was modified concurrently")
 )
 
 type Service struct {
 	db   *gorm.DB
 	repo *Repository
+
+	// Conversión entre monedas: tipo medio del proveedor menos spreadBps puntos básicos; las
+	// cotizaciones quedan congeladas quoteTTL.
+	rates     FXRateProvider
+	spreadBps int
+	quoteTTL  time.Duration
 }
 
-func NewService(db *gorm.DB) *Service {
-	return &Service{db: db, repo: NewRepository(db)}
+func NewService(db *gorm.DB, rates FXRateProvider, spreadBps int, quoteTTL time.Duration) *Service {
+	return &Service{db: db, repo: NewRepository(db), rates: rates, spreadBps: spreadBps, quoteTTL: quoteTTL}
 }
 
 func (s *Service) Deposit(ctx context.Context, depositRequest *DepositRequest, ref string) (*transaction.Transaction, error) {
 	depositRequest.Currency = strings.ToUpper(depositRequest.Currency)
 
-	if depositRequest.Amount <= 0 {
-		return nil, ErrNegativeAmount
+	amount, err := parseAmount(depositRequest.Amount, depositRequest.Currency)
+	if err != nil {
+		return nil, err
 	}
 
 	if ref != "" {
@@ -42,23 +60,26 @@ func (s *Service) Deposit(ctx context.Context, depositRequest *DepositRequest, r
 
 	var out *transaction.Transaction
 
-	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err = s.transaction(ctx, func(tx *gorm.DB) error {
 		r := s.repo.withTx(tx)
 
-		acc, err := r.GetAccount(ctx, depositRequest.AccountID, depositRequest.Currency)
+		acc, err := r.GetAccount(ctx, depositRequest.AccountID)
 		if err != nil {
-			return ErrAccountNotFound
+			return notFound(err)
 		}
 
 		if acc.Currency != depositRequest.Currency {
 			return ErrCurrencyMismatch
 		}
+		if err := operable(acc); err != nil {
+			return err
+		}
 
 		t := &transaction.Transaction{
 			Type:        "deposit",
 			Reference:   toRefPtr(ref),
 			ToAccountID: &acc.ID,
-			Amount:      depositRequest.Amount,
+			Amount:      amount.Amount,
 			Currency:    depositRequest.Currency,
 		}
 
@@ -73,18 +94,35 @@ func (s *Service) Deposit(ctx context.Context, depositRequest *DepositRequest, r
 			return err
 		}
 
+		if err := enforceLimits(ctx, r, acc, t); err != nil {
+			return err
+		}
+
 		entry := &ledger.LedgerEntry{
 			TransactionID: t.ID,
 			AccountID:     acc.ID,
-			Amount:        depositRequest.Amount,
+			Amount:        amount.Amount,
+			Currency:      acc.Currency,
+		}
+
+		clearing, err := systemEntry(ctx, r, ledger.CashInClearing, t, -amount.Amount, acc.Currency)
+		if err != nil {
+			return err
 		}
 
-		if err := r.CreateEntries(ctx, entry); err != nil {
+		if err := r.CreateEntries(ctx, entry, clearing); err != nil {
 			return err
 		}
 
-		newBal := acc.Balance + depositRequest.Amount
-		if err := r.UpdateBalance(ctx, acc.ID, newBal); err != nil {
+		newBal, err := money.AddInt64(acc.Balance, amount.Amount)
+		if err != nil {
+			return err
+		}
+		if err := r.UpdateBalance(ctx, acc, newBal); err != nil {
+			return err
+		}
+
+		if err := publishPosted(ctx, r, t, acc, amount.Amount); err != nil {
 			return err
 		}
 
@@ -102,8 +140,9 @@ func (s *Service) Deposit(ctx context.Context, depositRequest *DepositRequest, r
 func (s *Service) Withdraw(ctx context.Context, withdrawRequest *WithdrawRequest, ref string) (*transaction.Transaction, error) {
 	withdrawRequest.Currency = strings.ToUpper(withdrawRequest.Currency)
 
-	if withdrawRequest.Amount <= 0 {
-		return nil, ErrNegativeAmount
+	amount, err := parseAmount(withdrawRequest.Amount, withdrawRequest.Currency)
+	if err != nil {
+		return nil, err
 	}
 
 	if ref != "" {
@@ -114,18 +153,31 @@ func (s *Service) Withdraw(ctx context.Context, withdrawRequest *WithdrawRequest
 
 	var out *transaction.Transaction
 
-	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err = s.transaction(ctx, func(tx *gorm.DB) error {
 		r := s.repo.withTx(tx)
 
-		acc, err := r.GetAccount(ctx, withdrawRequest.AccountID, withdrawRequest.Currency)
+		acc, err := r.GetAccount(ctx, withdrawRequest.AccountID)
 
 		if err != nil {
-			return ErrAccountNotFound
+			return notFound(err)
 		}
 		if acc.Currency != withdrawRequest.Currency {
 			return ErrCurrencyMismatch
 		}
-		if acc.Balance < withdrawRequest.Amount {
+		if err := operable(acc); err != nil {
+			return err
+		}
+
+		// La comisión se cobra aparte del monto retirado: tiene que alcanzar para ambos.
+		lines, fee, err := fees(ctx, r, "withdraw", acc.Currency, amount.Amount)
+		if err != nil {
+			return err
+		}
+		debit, err := money.AddInt64(amount.Amount, fee)
+		if err != nil {
+			return err
+		}
+		if acc.Available() < debit {
 			return ErrInsufficientFunds
 		}
 
@@ -133,8 +185,10 @@ func (s *Service) Withdraw(ctx context.Context, withdrawRequest *WithdrawRequest
 			Type:          "withdraw",
 			Reference:     toRefPtr(ref),
 			FromAccountID: &acc.ID,
-			Amount:        withdrawRequest.Amount,
+			Amount:        amount.Amount,
 			Currency:      withdrawRequest.Currency,
+			Fee:           fee,
+			Fees:          lines,
 		}
 
 		if err := r.CreateTx(ctx, t); err != nil {
@@ -147,17 +201,41 @@ func (s *Service) Withdraw(ctx context.Context, withdrawRequest *WithdrawRequest
 			return err
 		}
 
+		if err := enforceLimits(ctx, r, acc, t); err != nil {
+			return err
+		}
+
 		entry := &ledger.LedgerEntry{
 			TransactionID: t.ID,
 			AccountID:     acc.ID,
-			Amount:        -withdrawRequest.Amount,
+			Amount:        -debit,
+			Currency:      acc.Currency,
 		}
-		if err := r.CreateEntries(ctx, entry); err != nil {
+
+		clearing, err := systemEntry(ctx, r, ledger.CashOutClearing, t, amount.Amount, acc.Currency)
+		if err != nil {
+			return err
+		}
+
+		entries := []*ledger.LedgerEntry{entry, clearing}
+		if fee > 0 {
+			revenue, err := systemEntry(ctx, r, ledger.FeesRevenue, t, fee, acc.Currency)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, revenue)
+		}
+
+		if err := r.CreateEntries(ctx, entries...); err != nil {
+			return err
+		}
+
+		newBal := acc.Balance - debit
+		if err := r.UpdateBalance(ctx, acc, newBal); err != nil {
 			return err
 		}
 
-		newBal := acc.Balance - withdrawRequest.Amount
-		if err := r.UpdateBalance(ctx, acc.ID, newBal); err != nil {
+		if err := publishPosted(ctx, r, t, acc, -debit); err != nil {
 			return err
 		}
 
@@ -177,8 +255,9 @@ func (s *Service) Withdraw(ctx context.Context, withdrawRequest *WithdrawRequest
 func (s *Service) Transfer(ctx context.Context, transferRequest *TransferRequest, ref string) (*transaction.Transaction, error) {
 	transferRequest.Currency = strings.ToUpper(strings.TrimSpace(transferRequest.Currency))
 
-	if transferRequest.Amount <= 0 {
-		return nil, ErrNegativeAmount
+	amount, err := parseAmount(transferRequest.Amount, transferRequest.Currency)
+	if err != nil {
+		return nil, err
 	}
 	if transferRequest.FromAccountID == transferRequest.ToAccountID {
 		return nil, ErrSameAccount
@@ -192,23 +271,36 @@ func (s *Service) Transfer(ctx context.Context, transferRequest *TransferRequest
 
 	var out *transaction.Transaction
 
-	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err = s.transaction(ctx, func(tx *gorm.DB) error {
 		r := s.repo.withTx(tx)
 
-		from, err := r.GetAccount(ctx, transferRequest.FromAccountID, transferRequest.Currency)
+		from, err := r.GetAccount(ctx, transferRequest.FromAccountID)
 		if err != nil {
-			return ErrAccountNotFound
+			return notFound(err)
 		}
 
-		to, err := r.GetAccount(ctx, transferRequest.ToAccountID, transferRequest.Currency)
+		to, err := r.GetAccount(ctx, transferRequest.ToAccountID)
 		if err != nil {
-			return ErrAccountNotFound
+			return notFound(err)
 		}
 
-		if from.Currency != transferRequest.Currency || to.Currency != transferRequest.Currency {
+		if from.Currency != transferRequest.Currency {
 			return ErrCurrencyMismatch
 		}
-		if from.Balance < transferRequest.Amount {
+		if err := operable(from, to); err != nil {
+			return err
+		}
+
+		// La comisión se calcula sobre el monto enviado, en la moneda de origen, y la paga quien envía.
+		lines, fee, err := fees(ctx, r, "transfer", from.Currency, amount.Amount)
+		if err != nil {
+			return err
+		}
+		debit, err := money.AddInt64(amount.Amount, fee)
+		if err != nil {
+			return err
+		}
+		if from.Available() < debit {
 			return ErrInsufficientFunds
 		}
 
@@ -217,8 +309,30 @@ func (s *Service) Transfer(ctx context.Context, transferRequest *TransferRequest
 			Reference:     toRefPtr(ref),
 			FromAccountID: &from.ID,
 			ToAccountID:   &to.ID,
-			Amount:        transferRequest.Amount,
+			Amount:        amount.Amount,
 			Currency:      transferRequest.Currency,
+			Fee:           fee,
+			Fees:          lines,
+		}
+
+		// Entre monedas distintas la cuenta destino recibe el monto convertido; la transacción
+		// guarda ambas patas y el tipo aplicado.
+		credited := amount.Amount
+		var leg *fxLeg
+		if to.Currency != from.Currency {
+			leg, err = s.resolveFX(ctx, r, from.UserID, transferRequest, amount.Amount, to.Currency)
+			if err != nil {
+				return err
+			}
+			credited = leg.Converted
+			t.ToAmount = leg.Converted
+			t.ToCurrency = to.Currency
+			t.FXRate = leg.Rate
+			if leg.Quote != nil {
+				t.FXQuoteID = &leg.Quote.ID
+			}
+		} else if transferRequest.QuoteID != nil {
+			return ErrQuoteMismatch
 		}
 
 		if err := r.CreateTx(ctx, t); err != nil {
@@ -231,17 +345,62 @@ func (s *Service) Transfer(ctx context.Context, transferRequest *TransferRequest
 			return err
 		}
 
-		debit := &ledger.LedgerEntry{TransactionID: t.ID, AccountID: from.ID, Amount: -transferRequest.Amount}
-		credit := &ledger.LedgerEntry{TransactionID: t.ID, AccountID: to.ID, Amount: +transferRequest.Amount}
+		if err := enforceLimits(ctx, r, from, t); err != nil {
+			return err
+		}
+
+		if leg != nil && leg.Quote != nil {
+			if err := r.UseQuote(ctx, leg.Quote.ID, t.ID); err != nil {
+				return err
+			}
+		}
+
+		entries := []*ledger.LedgerEntry{
+			{TransactionID: t.ID, AccountID: from.ID, Amount: -debit, Currency: from.Currency},
+			{TransactionID: t.ID, AccountID: to.ID, Amount: +credited, Currency: to.Currency},
+		}
 
-		if err := r.CreateEntries(ctx, debit, credit); err != nil {
+		if fee > 0 {
+			revenue, err := systemEntry(ctx, r, ledger.FeesRevenue, t, fee, from.Currency)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, revenue)
+		}
+
+		// Entre monedas la cuenta FX de cada moneda cierra su pata: recibe lo debitado y entrega
+		// lo acreditado, así cada moneda netea a cero por separado.
+		if leg != nil {
+			fxIn, err := systemEntry(ctx, r, ledger.FX, t, amount.Amount, from.Currency)
+			if err != nil {
+				return err
+			}
+			fxOut, err := systemEntry(ctx, r, ledger.FX, t, -credited, to.Currency)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, fxIn, fxOut)
+		}
+
+		if err := r.CreateEntries(ctx, entries...); err != nil {
 			return err
 		}
 
-		if err := r.UpdateBalance(ctx, from.ID, from.Balance-transferRequest.Amount); err != nil {
+		if err := r.UpdateBalance(ctx, from, from.Balance-debit); err != nil {
 			return err
 		}
-		if err := r.UpdateBalance(ctx, to.ID, to.Balance+transferRequest.Amount); err != nil {
+		toBal, err := money.AddInt64(to.Balance, credited)
+		if err != nil {
+			return err
+		}
+		if err := r.UpdateBalance(ctx, to, toBal); err != nil {
+			return err
+		}
+
+		if err := publishPosted(ctx, r, t, from, -debit); err != nil {
+			return err
+		}
+		if err := publishPosted(ctx, r, t, to, credited); err != nil {
 			return err
 		}
 
@@ -254,6 +413,81 @@ func (s *Service) Transfer(ctx context.Context, transferRequest *TransferRequest
 	return out, nil
 }
 
+const (
+	maxTxAttempts = 8
+	retryBaseWait = 5 * time.Millisecond
+)
+
+// transaction corre fn en una transacción de base y la repite completa si perdió una carrera
+// contra otra escritura sobre las mismas cuentas.
+func (s *Service) transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	var err error
+
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		err = s.db.WithContext(ctx).Transaction(fn)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+
+		wait := retryBaseWait << attempt
+		wait += time.Duration(rand.Int64N(int64(wait)))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	return err
+}
+
+// operable rechaza las cuentas congeladas por reconciliación.
+func operable(accounts ...*account.Account) error {
+	for _, acc := range accounts {
+		if acc.IsFrozen() {
+			return fmt.Errorf("%w: account %d", ErrAccountFrozen, acc.ID)
+		}
+	}
+	return nil
+}
+
+// systemEntry arma el asiento de la cuenta interna code en la moneda indicada y mueve su saldo.
+func systemEntry(ctx context.Context, r *Repository, code string, t *transaction.Transaction, amount int64, currency string) (*ledger.LedgerEntry, error) {
+	acc, err := r.SystemAccount(ctx, code, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.AddSystemBalance(ctx, acc.ID, amount); err != nil {
+		return nil, err
+	}
+
+	return &ledger.LedgerEntry{TransactionID: t.ID, AccountID: acc.ID, Amount: amount, Currency: currency}, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAccountNotFound
+	}
+	return err
+}
+
+func isRetryable(err error) bool {
+	return errors.Is(err, ErrConcurrentUpdate) || database.IsRetryable(err)
+}
+
+func parseAmount(d money.Decimal, currency string) (money.Money, error) {
+	amount, err := d.Money(currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if !amount.IsPositive() {
+		return money.Money{}, ErrNegativeAmount
+	}
+	return amount, nil
+}
+
 func toRefPtr(ref string) *string {
 	ref = strings.TrimSpace(ref)
 	if ref == "" {
@@ -261,3 +495,131 @@ func toRefPtr(ref string) *string {
 	}
 	return &ref
 }
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+func (s *Service) History(ctx context.Context, accountID uint, filter *HistoryFilter) (*HistoryResponse, error) {
+	acc, err := s.repo.FindAccount(ctx, accountID)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+
+	q := entryQuery{
+		Types:          filter.Types,
+		From:           filter.From,
+		To:             filter.To,
+		CounterpartyID: filter.CounterpartyID,
+		Limit:          filter.Limit,
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = defaultHistoryLimit
+	}
+	if q.Limit > maxHistoryLimit {
+		q.Limit = maxHistoryLimit
+	}
+
+	if filter.Cursor != "" {
+		q.BeforeID, err = decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if filter.MinAmount != nil {
+		m, err := filter.MinAmount.Money(acc.Currency)
+		if err != nil {
+			return nil, err
+		}
+		q.MinAmount = &m.Amount
+	}
+	if filter.MaxAmount != nil {
+		m, err := filter.MaxAmount.Money(acc.Currency)
+		if err != nil {
+			return nil, err
+		}
+		q.MaxAmount = &m.Amount
+	}
+
+	// Se pide uno de más para saber si hay otra página.
+	limit := q.Limit
+	q.Limit++
+
+	entries, err := s.repo.ListEntries(ctx, acc.ID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &HistoryResponse{Items: []HistoryItem{}}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+		next := encodeCursor(entries[len(entries)-1].ID)
+		resp.NextCursor = &next
+	}
+	if len(entries) == 0 {
+		return resp, nil
+	}
+
+	ids := make([]uint, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+
+	balances, err := s.repo.RunningBalances(ctx, acc.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		item := HistoryItem{
+			EntryID:       e.ID,
+			TransactionID: e.TransactionID,
+			Amount:        money.New(e.Amount, acc.Currency).Decimal(),
+			Currency:      acc.Currency,
+			BalanceAfter:  money.New(balances[e.ID], acc.Currency).Decimal(),
+			CreatedAt:     httputil.FormatDate(&e.CreatedAt),
+		}
+
+		if t := e.Transaction; t != nil {
+			item.Type = t.Type
+			item.Reference = t.Reference
+			item.CounterpartyAccountID = counterparty(t, acc.ID)
+		}
+
+		resp.Items = append(resp.Items, item)
+	}
+
+	return resp, nil
+}
+
+func counterparty(t *transaction.Transaction, accountID uint) *uint {
+	if t.FromAccountID != nil && *t.FromAccountID != accountID {
+		return t.FromAccountID
+	}
+	if t.ToAccountID != nil && *t.ToAccountID != accountID {
+		return t.ToAccountID
+	}
+	return nil
+}
+
+func encodeCursor(entryID uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(entryID), 10)))
+}
+
+func decodeCursor(cursor string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return uint(id), nil
+}
@@ -0,0 +1,177 @@
+package wallet
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	ledger "github.com/sebaactis/wallet-go-api/internal/entities/legder"
+)
+
+const (
+	StatementCSV     = "csv"
+	StatementOFX     = "ofx"
+	StatementCamt053 = "camt053"
+
+	statementBatchSize = 500
+)
+
+var (
+	ErrInvalidStatementFormat = errors.New("format must be csv, ofx or camt053")
+	ErrInvalidStatementPeriod = errors.New("from must be before to")
+)
+
+// Statement es el encabezado de un extracto: la cuenta, el período [From, To) y sus saldos. Los
+// movimientos no están acá: se leen de a lotes mientras se escribe.
+type Statement struct {
+	Account     *account.Account
+	From        time.Time
+	To          time.Time
+	Opening     int64
+	Closing     int64
+	Credits     int64
+	CreditCount int64
+	Debits      int64
+	DebitCount  int64
+	GeneratedAt time.Time
+}
+
+// StatementLine es un movimiento del extracto con el saldo que dejó.
+type StatementLine struct {
+	EntryID       uint
+	TransactionID uint
+	Type          string
+	Reference     string
+	Counterparty  *uint
+	Amount        int64
+	Balance       int64
+	BookedAt      time.Time
+}
+
+// statementWriter renderiza un formato. Begin recibe el encabezado ya completo, con el saldo de
+// cierre: camt.053 lo pide antes de los movimientos.
+type statementWriter interface {
+	Begin(s *Statement) error
+	Line(l *StatementLine) error
+	End(s *Statement) error
+}
+
+func newStatementWriter(format string, w io.Writer) (statementWriter, error) {
+	switch format {
+	case StatementCSV:
+		return newCSVStatement(w), nil
+	case StatementOFX:
+		return newOFXStatement(w), nil
+	case StatementCamt053:
+		return newCamtStatement(w), nil
+	default:
+		return nil, ErrInvalidStatementFormat
+	}
+}
+
+// Statement arma el encabezado del extracto de la cuenta para [from, to). Va antes de escribir
+// nada, para que los errores todavía se puedan responder con su código.
+func (s *Service) Statement(ctx context.Context, accountID uint, from, to time.Time) (*Statement, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidStatementPeriod
+	}
+
+	acc, err := s.repo.FindAccountWithUser(ctx, accountID)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+
+	return s.statement(ctx, acc, from, to)
+}
+
+// statement arma el encabezado para una cuenta ya cargada (con su titular, si se va a mostrar).
+func (s *Service) statement(ctx context.Context, acc *account.Account, from, to time.Time) (*Statement, error) {
+	// Un período abierto se corta ahora: el saldo de cierre es el de este momento.
+	now := time.Now()
+	if to.After(now) {
+		to = now
+	}
+
+	totals, err := s.repo.StatementTotals(ctx, acc.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Statement{
+		Account:     acc,
+		From:        from,
+		To:          to,
+		Opening:     totals.Opening,
+		Closing:     totals.Opening + totals.Credits - totals.Debits,
+		Credits:     totals.Credits,
+		CreditCount: totals.CreditCount,
+		Debits:      totals.Debits,
+		DebitCount:  totals.DebitCount,
+		GeneratedAt: now,
+	}, nil
+}
+
+// WriteStatement escribe el extracto en el formato pedido a medida que lee los movimientos.
+func (s *Service) WriteStatement(ctx context.Context, st *Statement, format string, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	sw, err := newStatementWriter(format, bw)
+	if err != nil {
+		return err
+	}
+
+	if err := s.renderStatement(ctx, st, sw); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// renderStatement pasa el encabezado y cada movimiento, con el saldo que dejó, por sw.
+func (s *Service) renderStatement(ctx context.Context, st *Statement, sw statementWriter) error {
+	if err := sw.Begin(st); err != nil {
+		return err
+	}
+
+	balance := st.Opening
+	err := s.repo.StatementEntries(ctx, st.Account.ID, st.From, st.To, statementBatchSize, func(entries []*ledger.LedgerEntry) error {
+		for _, e := range entries {
+			balance += e.Amount
+
+			l := &StatementLine{
+				EntryID:       e.ID,
+				TransactionID: e.TransactionID,
+				Amount:        e.Amount,
+				Balance:       balance,
+				BookedAt:      e.CreatedAt,
+			}
+			if t := e.Transaction; t != nil {
+				l.Type = t.Type
+				l.Counterparty = counterparty(t, st.Account.ID)
+				if t.Reference != nil {
+					l.Reference = *t.Reference
+				}
+			}
+
+			if err := sw.Line(l); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Si entró un movimiento con fecha del período después de calcular los totales, el cierre ya
+	// escrito no coincidiría con las líneas.
+	if balance != st.Closing {
+		return fmt.Errorf("statement for account %d changed while rendering", st.Account.ID)
+	}
+
+	return sw.End(st)
+}
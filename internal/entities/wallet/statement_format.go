@@ -0,0 +1,414 @@
+package wallet
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/money"
+)
+
+// --- CSV ---------------------------------------------------------------------------------------
+
+// csvStatement escribe una fila por movimiento, entre una de saldo de apertura y una de cierre.
+type csvStatement struct {
+	w        *csv.Writer
+	currency string
+}
+
+func newCSVStatement(w io.Writer) *csvStatement {
+	return &csvStatement{w: csv.NewWriter(w)}
+}
+
+var csvStatementHeader = []string{
+	"date", "entry_id", "transaction_id", "type", "reference", "counterparty_account_id", "amount", "balance", "currency",
+}
+
+func (c *csvStatement) Begin(s *Statement) error {
+	c.currency = s.Account.Currency
+
+	if err := c.w.Write(csvStatementHeader); err != nil {
+		return err
+	}
+	return c.balance(s.From, "opening_balance", s.Opening)
+}
+
+func (c *csvStatement) Line(l *StatementLine) error {
+	counterparty := ""
+	if l.Counterparty != nil {
+		counterparty = strconv.FormatUint(uint64(*l.Counterparty), 10)
+	}
+
+	return c.w.Write([]string{
+		l.BookedAt.UTC().Format(time.RFC3339),
+		strconv.FormatUint(uint64(l.EntryID), 10),
+		strconv.FormatUint(uint64(l.TransactionID), 10),
+		l.Type,
+		csvText(l.Reference),
+		counterparty,
+		money.Format(l.Amount, c.currency),
+		money.Format(l.Balance, c.currency),
+		c.currency,
+	})
+}
+
+func (c *csvStatement) End(s *Statement) error {
+	if err := c.balance(s.To, "closing_balance", s.Closing); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvStatement) balance(at time.Time, kind string, amount int64) error {
+	return c.w.Write([]string{
+		at.UTC().Format(time.RFC3339), "", "", kind, "", "", "", money.Format(amount, c.currency), c.currency,
+	})
+}
+
+// csvText neutraliza texto del usuario que una planilla tomaría como fórmula.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// --- XML ---------------------------------------------------------------------------------------
+
+// xmlStream escribe XML de a elementos: los contenedores se abren y cierran con tokens y cada
+// movimiento se codifica completo, así el documento nunca está entero en memoria.
+type xmlStream struct {
+	enc *xml.Encoder
+	err error
+}
+
+func newXMLStream(w io.Writer) *xmlStream {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &xmlStream{enc: enc}
+}
+
+func (x *xmlStream) open(name string, attrs ...xml.Attr) {
+	if x.err == nil {
+		x.err = x.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs})
+	}
+}
+
+func (x *xmlStream) close(name string) {
+	if x.err == nil {
+		x.err = x.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+	}
+}
+
+func (x *xmlStream) element(name string, v any) {
+	if x.err == nil {
+		x.err = x.enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+	}
+}
+
+func (x *xmlStream) token(t xml.Token) {
+	if x.err == nil {
+		x.err = x.enc.EncodeToken(t)
+	}
+}
+
+// prolog escribe las instrucciones de procesamiento del encabezado, una por línea.
+func (x *xmlStream) prolog(pis ...xml.ProcInst) {
+	for _, pi := range pis {
+		x.token(pi)
+		x.token(xml.CharData("\n"))
+	}
+}
+
+// flush termina el documento con un salto de línea y lo manda al writer.
+func (x *xmlStream) flush() error {
+	x.token(xml.CharData("\n"))
+	if x.err == nil {
+		x.err = x.enc.Flush()
+	}
+	return x.err
+}
+
+// --- OFX 2.2 -----------------------------------------------------------------------------------
+
+// ofxStatement escribe un STMTRS de OFX 2.2. OFX no tiene saldo de apertura: el de cierre va en
+// LEDGERBAL y el de apertura en BALLIST.
+type ofxStatement struct {
+	x        *xmlStream
+	currency string
+}
+
+func newOFXStatement(w io.Writer) *ofxStatement {
+	return &ofxStatement{x: newXMLStream(w)}
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	FITID  string `xml:"FITID"`
+	Name   string `xml:"NAME,omitempty"`
+	Memo   string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+type ofxBal struct {
+	Name  string `xml:"NAME"`
+	Desc  string `xml:"DESC"`
+	Type  string `xml:"BALTYPE"`
+	Value string `xml:"VALUE"`
+	AsOf  string `xml:"DTASOF"`
+}
+
+func ofxDate(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+func (o *ofxStatement) Begin(s *Statement) error {
+	o.currency = s.Account.Currency
+	x := o.x
+
+	x.prolog(
+		xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8" standalone="no"`)},
+		xml.ProcInst{Target: "OFX", Inst: []byte(`OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"`)},
+	)
+
+	x.open("OFX")
+	x.open("SIGNONMSGSRSV1")
+	x.open("SONRS")
+	x.element("STATUS", ofxStatus{Code: 0, Severity: "INFO"})
+	x.element("DTSERVER", ofxDate(s.GeneratedAt))
+	x.element("LANGUAGE", "ENG")
+	x.close("SONRS")
+	x.close("SIGNONMSGSRSV1")
+
+	x.open("BANKMSGSRSV1")
+	x.open("STMTTRNRS")
+	x.element("TRNUID", "0")
+	x.element("STATUS", ofxStatus{Code: 0, Severity: "INFO"})
+	x.open("STMTRS")
+	x.element("CURDEF", s.Account.Currency)
+	x.element("BANKACCTFROM", struct {
+		BankID   string `xml:"BANKID"`
+		AcctID   string `xml:"ACCTID"`
+		AcctType string `xml:"ACCTTYPE"`
+	}{"WALLET", strconv.FormatUint(uint64(s.Account.ID), 10), "CHECKING"})
+	x.open("BANKTRANLIST")
+	x.element("DTSTART", ofxDate(s.From))
+	x.element("DTEND", ofxDate(s.To))
+
+	return x.err
+}
+
+func (o *ofxStatement) Line(l *StatementLine) error {
+	t := ofxTransaction{
+		Type:   "CREDIT",
+		Posted: ofxDate(l.BookedAt),
+		Amount: money.Format(l.Amount, o.currency),
+		FITID:  strconv.FormatUint(uint64(l.EntryID), 10),
+		Name:   l.Type,
+		Memo:   l.Reference,
+	}
+	if l.Amount < 0 {
+		t.Type = "DEBIT"
+	}
+
+	o.x.element("STMTTRN", t)
+	return o.x.err
+}
+
+func (o *ofxStatement) End(s *Statement) error {
+	x := o.x
+
+	x.close("BANKTRANLIST")
+	x.element("LEDGERBAL", ofxBalance{Amount: money.Format(s.Closing, o.currency), AsOf: ofxDate(s.To)})
+	x.open("BALLIST")
+	x.element("BAL", ofxBal{
+		Name:  "OPENING",
+		Desc:  "Opening balance",
+		Type:  "DOLLAR",
+		Value: money.Format(s.Opening, o.currency),
+		AsOf:  ofxDate(s.From),
+	})
+	x.close("BALLIST")
+	x.close("STMTRS")
+	x.close("STMTTRNRS")
+	x.close("BANKMSGSRSV1")
+	x.close("OFX")
+
+	return x.flush()
+}
+
+// --- ISO 20022 camt.053 ------------------------------------------------------------------------
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+
+// camtStatement escribe un BkToCstmrStmt de camt.053.001.08 con un solo Stmt. Los montos van sin
+// signo y el sentido en CdtDbtInd.
+type camtStatement struct {
+	x        *xmlStream
+	currency string
+}
+
+func newCamtStatement(w io.Writer) *camtStatement {
+	return &camtStatement{x: newXMLStream(w)}
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtDateTime struct {
+	DateTime string `xml:"DtTm"`
+}
+
+type camtBalance struct {
+	Type      string       `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount   `xml:"Amt"`
+	Indicator string       `xml:"CdtDbtInd"`
+	Date      camtDateTime `xml:"Dt"`
+}
+
+type camtCount struct {
+	Count string `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camtSummary struct {
+	Total struct {
+		Count string `xml:"NbOfNtries"`
+		Sum   string `xml:"Sum"`
+		Net   struct {
+			Amount    string `xml:"Amt"`
+			Indicator string `xml:"CdtDbtInd"`
+		} `xml:"TtlNetNtry"`
+	} `xml:"TtlNtries"`
+	Credits camtCount `xml:"TtlCdtNtries"`
+	Debits  camtCount `xml:"TtlDbtNtries"`
+}
+
+type camtEntry struct {
+	Ref       string       `xml:"NtryRef"`
+	Amount    camtAmount   `xml:"Amt"`
+	Indicator string       `xml:"CdtDbtInd"`
+	Status    string       `xml:"Sts>Cd"`
+	Booking   camtDateTime `xml:"BookgDt"`
+	Value     camtDateTime `xml:"ValDt"`
+	SvcrRef   string       `xml:"AcctSvcrRef"`
+	TxCode    string       `xml:"BkTxCd>Prtry>Cd"`
+	Details   *camtDetails `xml:"NtryDtls,omitempty"`
+}
+
+type camtDetails struct {
+	EndToEnd string `xml:"TxDtls>Refs>EndToEndId"`
+}
+
+func camtDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func absAmount(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func camtIndicator(n int64) string {
+	if n < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func (c *camtStatement) Begin(s *Statement) error {
+	c.currency = s.Account.Currency
+	x := c.x
+
+	id := fmt.Sprintf("STMT-%d-%s-%s", s.Account.ID, s.From.UTC().Format("20060102"), s.To.UTC().Format("20060102"))
+
+	x.prolog(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)})
+	x.open("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace})
+	x.open("BkToCstmrStmt")
+	x.element("GrpHdr", struct {
+		MsgID   string `xml:"MsgId"`
+		Created string `xml:"CreDtTm"`
+	}{id, camtDate(s.GeneratedAt)})
+
+	x.open("Stmt")
+	x.element("Id", id)
+	x.element("CreDtTm", camtDate(s.GeneratedAt))
+	x.element("FrToDt", struct {
+		From string `xml:"FrDtTm"`
+		To   string `xml:"ToDtTm"`
+	}{camtDate(s.From), camtDate(s.To)})
+	x.element("Acct", struct {
+		ID       string `xml:"Id>Othr>Id"`
+		Currency string `xml:"Ccy"`
+	}{strconv.FormatUint(uint64(s.Account.ID), 10), s.Account.Currency})
+
+	x.element("Bal", c.balance("OPBD", s.Opening, s.From))
+	x.element("Bal", c.balance("CLBD", s.Closing, s.To))
+
+	var summary camtSummary
+	net := s.Credits - s.Debits
+	summary.Total.Count = strconv.FormatInt(s.CreditCount+s.DebitCount, 10)
+	summary.Total.Sum = money.Format(s.Credits+s.Debits, c.currency)
+	summary.Total.Net.Amount = money.Format(absAmount(net), c.currency)
+	summary.Total.Net.Indicator = camtIndicator(net)
+	summary.Credits = camtCount{Count: strconv.FormatInt(s.CreditCount, 10), Sum: money.Format(s.Credits, c.currency)}
+	summary.Debits = camtCount{Count: strconv.FormatInt(s.DebitCount, 10), Sum: money.Format(s.Debits, c.currency)}
+	x.element("TxsSummry", summary)
+
+	return x.err
+}
+
+func (c *camtStatement) balance(code string, amount int64, at time.Time) camtBalance {
+	return camtBalance{
+		Type:      code,
+		Amount:    camtAmount{Currency: c.currency, Value: money.Format(absAmount(amount), c.currency)},
+		Indicator: camtIndicator(amount),
+		Date:      camtDateTime{DateTime: camtDate(at)},
+	}
+}
+
+func (c *camtStatement) Line(l *StatementLine) error {
+	e := camtEntry{
+		Ref:       strconv.FormatUint(uint64(l.EntryID), 10),
+		Amount:    camtAmount{Currency: c.currency, Value: money.Format(absAmount(l.Amount), c.currency)},
+		Indicator: camtIndicator(l.Amount),
+		Status:    "BOOK",
+		Booking:   camtDateTime{DateTime: camtDate(l.BookedAt)},
+		Value:     camtDateTime{DateTime: camtDate(l.BookedAt)},
+		SvcrRef:   strconv.FormatUint(uint64(l.TransactionID), 10),
+		TxCode:    l.Type,
+	}
+	if l.Reference != "" {
+		e.Details = &camtDetails{EndToEnd: l.Reference}
+	}
+
+	c.x.element("Ntry", e)
+	return c.x.err
+}
+
+func (c *camtStatement) End(s *Statement) error {
+	c.x.close("Stmt")
+	c.x.close("BkToCstmrStmt")
+	c.x.close("Document")
+	return c.x.flush()
+}
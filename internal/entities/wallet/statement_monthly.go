@@ -0,0 +1,193 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/platform/filestore"
+)
+
+const monthlyStatementBatch = 100
+
+var ErrInvalidStatementMonth = errors.New("month must be YYYY-MM and not in the future")
+
+// StatementArchive guarda los extractos mensuales en PDF. Un mes cerrado no cambia (las
+// correcciones son movimientos nuevos, con su fecha), así que su PDF se genera una vez: lo hace
+// el proceso de fin de mes o, si todavía no pasó, el primer pedido.
+type StatementArchive struct {
+	service *Service
+	store   *filestore.Store
+}
+
+func NewStatementArchive(service *Service, store *filestore.Store) *StatementArchive {
+	return &StatementArchive{service: service, store: store}
+}
+
+// monthBounds devuelve el período [from, to) del mes que contiene t, en la zona horaria local.
+func monthBounds(t time.Time) (time.Time, time.Time) {
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	return from, from.AddDate(0, 1, 0)
+}
+
+// Monthly devuelve el PDF del mes para la cuenta. Solo se guarda el mes que genera el proceso de
+// fin de mes (el último cerrado), si el pedido llega antes que él; el mes en curso, hasta ahora, y
+// los cerrados anteriores que no estén en el store se arman al momento. Si se guardara cualquier
+// mes pedido, un usuario podría llenar el store.
+func (a *StatementArchive) Monthly(ctx context.Context, accountID uint, month time.Time) (io.ReadSeekCloser, error) {
+	from, to := monthBounds(month)
+
+	now := time.Now()
+	if from.After(now) {
+		return nil, ErrInvalidStatementMonth
+	}
+
+	acc, err := a.service.repo.FindAccountWithUser(ctx, accountID)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+	if opened, _ := monthBounds(acc.CreatedAt.In(time.Local)); from.Before(opened) {
+		return nil, fmt.Errorf("%w: the account was opened in %s, there is nothing before", ErrInvalidStatementMonth, opened.Format("2006-01"))
+	}
+
+	if !to.After(now) {
+		key := monthlyStatementKey(acc.ID, from)
+
+		f, err := a.store.Open(key)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, filestore.ErrNotFound) {
+			return nil, err
+		}
+
+		if current, _ := monthBounds(now); from.Equal(current.AddDate(0, -1, 0)) {
+			if err := a.store.Put(key, func(w io.Writer) error {
+				return a.write(ctx, acc, from, to, w)
+			}); err != nil {
+				return nil, err
+			}
+			return a.store.Open(key)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := a.write(ctx, acc, from, to, &buf); err != nil {
+		return nil, err
+	}
+	return nopSeekCloser{bytes.NewReader(buf.Bytes())}, nil
+}
+
+// GenerateMonth guarda el extracto del mes de cada cuenta de cliente que existía al cerrar el
+// mes y todavía no lo tiene. Una cuenta que falla no frena a las demás; cuando terminan todas
+// bien queda una marca y las corridas siguientes no vuelven a recorrer el mes.
+func (a *StatementArchive) GenerateMonth(ctx context.Context, month time.Time) (int, error) {
+	from, to := monthBounds(month)
+	if to.After(time.Now()) {
+		return 0, fmt.Errorf("month %s is not closed yet", from.Format("2006-01"))
+	}
+
+	done := monthlyStatementDir(from) + "/.complete"
+	if ok, err := a.store.Exists(done); err != nil || ok {
+		return 0, err
+	}
+
+	var (
+		generated int
+		errs      []error
+		afterID   uint
+	)
+	for {
+		accounts, err := a.service.repo.StatementAccounts(ctx, to, afterID, monthlyStatementBatch)
+		if err != nil {
+			return generated, err
+		}
+		if len(accounts) == 0 {
+			break
+		}
+
+		for _, acc := range accounts {
+			afterID = acc.ID
+
+			key := monthlyStatementKey(acc.ID, from)
+			ok, err := a.store.Exists(key)
+			if err == nil && !ok {
+				err = a.store.Put(key, func(w io.Writer) error {
+					return a.write(ctx, acc, from, to, w)
+				})
+				if err == nil {
+					generated++
+				}
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return generated, ctx.Err()
+				}
+				errs = append(errs, fmt.Errorf("account %d: %w", acc.ID, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return generated, errors.Join(errs...)
+	}
+
+	return generated, a.store.Put(done, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "%d statements\n", generated)
+		return err
+	})
+}
+
+// Run genera cada interval los extractos del mes anterior: la primera corrida después del cierre
+// del mes los deja listos. Con interval 0 no corre.
+func (a *StatementArchive) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current, _ := monthBounds(time.Now())
+			month := current.AddDate(0, -1, 0)
+
+			n, err := a.GenerateMonth(ctx, month)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("monthly statements %s: %v", month.Format("2006-01"), err)
+			}
+			if n > 0 {
+				log.Printf("monthly statements %s: %d generated", month.Format("2006-01"), n)
+			}
+		}
+	}
+}
+
+func (a *StatementArchive) write(ctx context.Context, acc *account.Account, from, to time.Time, w io.Writer) error {
+	st, err := a.service.statement(ctx, acc, from, to)
+	if err != nil {
+		return err
+	}
+	return a.service.renderStatement(ctx, st, newPDFStatement(w))
+}
+
+func monthlyStatementDir(from time.Time) string {
+	return "statements/" + from.Format("2006-01")
+}
+
+func monthlyStatementKey(accountID uint, from time.Time) string {
+	return fmt.Sprintf("%s/%d.pdf", monthlyStatementDir(from), accountID)
+}
+
+type nopSeekCloser struct{ io.ReadSeeker }
+
+func (nopSeekCloser) Close() error { return nil }
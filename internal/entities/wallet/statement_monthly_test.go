@@ -0,0 +1,80 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/platform/filestore"
+)
+
+// Solo hay extractos desde el mes en que se abrió la cuenta, y a pedido solo se guarda el mes que
+// también guardaría el proceso de fin de mes.
+func TestMonthlyStatementMonths(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	svc := NewService(db, nil, 0, 0)
+
+	store, err := filestore.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := NewStatementArchive(svc, store)
+
+	current, _ := monthBounds(time.Now())
+	month := func(offset int) time.Time { return current.AddDate(0, offset, 0) }
+
+	u := &user.User{Name: "ana", Email: "ana@x.io", Password: "x"}
+	if err := db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+	acc := &account.Account{UserID: u.ID, Currency: "USD", CreatedAt: month(-3).Add(36 * time.Hour)}
+	if err := db.Create(acc).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		month  time.Time
+		err    error
+		stored bool
+	}{
+		{"before the account", month(-4), ErrInvalidStatementMonth, false},
+		{"year one", time.Date(1, 1, 1, 0, 0, 0, 0, time.Local), ErrInvalidStatementMonth, false},
+		{"future", month(1), ErrInvalidStatementMonth, false},
+		{"month opened", month(-3), nil, false},
+		{"older closed month", month(-2), nil, false},
+		{"last closed month", month(-1), nil, true},
+		{"current month", month(0), nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := archive.Monthly(ctx, acc.ID, tt.month)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Monthly error = %v, want %v", err, tt.err)
+			}
+			if err == nil {
+				body, _ := io.ReadAll(f)
+				f.Close()
+				if !bytes.HasPrefix(body, []byte("%PDF")) {
+					t.Errorf("body is not a PDF: %.20q", body)
+				}
+			}
+
+			from, _ := monthBounds(tt.month)
+			stored, err := store.Exists(monthlyStatementKey(acc.ID, from))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored != tt.stored {
+				t.Errorf("stored = %v, want %v", stored, tt.stored)
+			}
+		})
+	}
+}
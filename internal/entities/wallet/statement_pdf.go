@@ -0,0 +1,261 @@
+package wallet
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/money"
+	"github.com/sebaactis/wallet-go-api/internal/platform/pdf"
+)
+
+// Diseño del extracto en PDF: A4, márgenes de 40 puntos y una tabla de cinco columnas.
+const (
+	pdfMargin    = 40.0
+	pdfRight     = pdf.A4Width - pdfMargin
+	pdfRowHeight = 16.0
+	pdfBodySize  = 9.0
+	pdfBrand     = "Wallet Go"
+
+	// Debajo de esto no entran más filas: queda el pie de página.
+	pdfBottom = pdf.A4Height - 60
+)
+
+var (
+	pdfBrandColor = pdf.RGB(28, 52, 96)
+	pdfMuted      = pdf.RGB(110, 117, 128)
+	pdfZebra      = pdf.RGB(243, 245, 248)
+	pdfRule       = pdf.RGB(210, 214, 220)
+	pdfCredit     = pdf.RGB(22, 120, 60)
+)
+
+// Columnas de la tabla de movimientos: los textos arrancan en x y los importes terminan en x.
+type pdfColumn struct {
+	title string
+	x     float64
+	width float64
+	right bool
+}
+
+var pdfColumns = []pdfColumn{
+	{title: "Date", x: pdfMargin + 4, width: 58},
+	{title: "Description", x: pdfMargin + 66, width: 170},
+	{title: "Reference", x: pdfMargin + 242, width: 110},
+	{title: "Amount", x: pdfRight - 90, width: 80, right: true},
+	{title: "Balance", x: pdfRight - 4, width: 80, right: true},
+}
+
+// pdfStatement arma el extracto mensual con marca: encabezado con el titular y la cuenta, saldo
+// de apertura, la tabla de movimientos, totales y saldo de cierre. Las páginas se arman en
+// memoria y el documento se escribe entero en End, cuando ya se sabe cuántas son.
+type pdfStatement struct {
+	w        io.Writer
+	doc      *pdf.Document
+	page     *pdf.Page
+	y        float64
+	rows     int
+	currency string
+}
+
+func newPDFStatement(w io.Writer) *pdfStatement {
+	return &pdfStatement{w: w}
+}
+
+func (p *pdfStatement) Begin(s *Statement) error {
+	p.currency = s.Account.Currency
+
+	p.doc = pdf.New(pdf.A4Width, pdf.A4Height)
+	p.doc.Title = fmt.Sprintf("Statement %s - account %d", statementPeriod(s), s.Account.ID)
+	p.doc.Author = pdfBrand
+	p.doc.Created = s.GeneratedAt
+
+	p.page = p.doc.AddPage()
+	page := p.page
+
+	// Banda con la marca.
+	page.Rect(0, 0, pdf.A4Width, 80, pdfBrandColor)
+	page.Text(pdfMargin, 48, pdf.HelveticaBold, 22, pdf.White, pdfBrand)
+	page.TextRight(pdfRight, 40, pdf.HelveticaBold, 13, pdf.White, "Monthly statement")
+	page.TextRight(pdfRight, 58, pdf.Helvetica, 10, pdf.White, statementPeriod(s))
+
+	// Titular y cuenta.
+	y := 115.0
+	holder, email := "", ""
+	if u := s.Account.User; u != nil {
+		holder, email = u.Name, u.Email
+	}
+	p.field(pdfMargin, y, "Account holder", holder)
+	p.field(pdfMargin+260, y, "Account", fmt.Sprintf("#%d · %s", s.Account.ID, s.Account.Currency))
+	y += 34
+	p.field(pdfMargin, y, "Email", email)
+	p.field(pdfMargin+260, y, "Period", fmt.Sprintf("%s – %s", s.From.Format("02 Jan 2006"), lastDay(s).Format("02 Jan 2006")))
+
+	// Resumen del período.
+	y += 28
+	page.Rect(pdfMargin, y, pdfRight-pdfMargin, 54, pdfZebra)
+	boxes := []struct {
+		label string
+		value string
+	}{
+		{"Opening balance", money.Format(s.Opening, p.currency)},
+		{fmt.Sprintf("Credits (%d)", s.CreditCount), money.Format(s.Credits, p.currency)},
+		{fmt.Sprintf("Debits (%d)", s.DebitCount), money.Format(-s.Debits, p.currency)},
+		{"Closing balance", money.Format(s.Closing, p.currency)},
+	}
+	bw := (pdfRight - pdfMargin) / float64(len(boxes))
+	for i, b := range boxes {
+		x := pdfMargin + 12 + bw*float64(i)
+		page.Text(x, y+20, pdf.Helvetica, 8, pdfMuted, b.label)
+		page.Text(x, y+40, pdf.HelveticaBold, 13, pdf.Black, b.value)
+	}
+
+	p.y = y + 54 + 26
+	p.tableHeader()
+	p.row("", "Opening balance", "", "", money.Format(s.Opening, p.currency), pdf.HelveticaBold, pdf.Black)
+
+	return nil
+}
+
+func (p *pdfStatement) Line(l *StatementLine) error {
+	amountColor := pdf.Black
+	if l.Amount > 0 {
+		amountColor = pdfCredit
+	}
+
+	p.row(
+		l.BookedAt.Format("02 Jan 2006"),
+		describeLine(l),
+		l.Reference,
+		money.Format(l.Amount, p.currency),
+		money.Format(l.Balance, p.currency),
+		pdf.Helvetica,
+		amountColor,
+	)
+	return nil
+}
+
+func (p *pdfStatement) End(s *Statement) error {
+	// Totales y cierre: tres filas que van juntas en la misma página.
+	if p.y+3*pdfRowHeight+8 > pdfBottom {
+		p.newPage()
+	}
+
+	p.page.Line(pdfMargin, p.y, pdfRight, p.y, 0.8, pdfRule)
+	p.y += 4
+	p.row("", fmt.Sprintf("Total credits (%d)", s.CreditCount), "", money.Format(s.Credits, p.currency), "", pdf.Helvetica, pdfCredit)
+	p.row("", fmt.Sprintf("Total debits (%d)", s.DebitCount), "", money.Format(-s.Debits, p.currency), "", pdf.Helvetica, pdf.Black)
+	p.row("", "Closing balance", "", "", money.Format(s.Closing, p.currency), pdf.HelveticaBold, pdf.Black)
+
+	// Pie en todas las páginas, ahora que se sabe cuántas son.
+	pages := p.doc.Pages()
+	for i, page := range pages {
+		page.Line(pdfMargin, pdf.A4Height-45, pdfRight, pdf.A4Height-45, 0.5, pdfRule)
+		page.Text(pdfMargin, pdf.A4Height-32, pdf.Helvetica, 7.5, pdfMuted,
+			fmt.Sprintf("%s · Account #%d · Generated %s", pdfBrand, s.Account.ID, s.GeneratedAt.Format("02 Jan 2006 15:04 MST")))
+		page.TextRight(pdfRight, pdf.A4Height-32, pdf.Helvetica, 7.5, pdfMuted,
+			fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+	}
+
+	_, err := p.doc.WriteTo(p.w)
+	return err
+}
+
+// field escribe una etiqueta chica con su valor abajo.
+func (p *pdfStatement) field(x, y float64, label, value string) {
+	p.page.Text(x, y, pdf.Helvetica, 8, pdfMuted, label)
+	p.page.Text(x, y+14, pdf.HelveticaBold, 11, pdf.Black, pdf.Truncate(pdf.HelveticaBold, 11, value, 240))
+}
+
+func (p *pdfStatement) tableHeader() {
+	p.page.Rect(pdfMargin, p.y, pdfRight-pdfMargin, pdfRowHeight+2, pdfBrandColor)
+	for _, c := range pdfColumns {
+		if c.right {
+			p.page.TextRight(c.x, p.y+12, pdf.HelveticaBold, 8.5, pdf.White, c.title)
+		} else {
+			p.page.Text(c.x, p.y+12, pdf.HelveticaBold, 8.5, pdf.White, c.title)
+		}
+	}
+	p.y += pdfRowHeight + 2
+	p.rows = 0
+}
+
+// row escribe una fila de la tabla; si no entra, sigue en una página nueva. color es el del importe.
+func (p *pdfStatement) row(date, description, reference, amount, balance string, f pdf.Font, color pdf.Color) {
+	if p.y+pdfRowHeight > pdfBottom {
+		p.newPage()
+	}
+
+	if p.rows%2 == 1 {
+		p.page.Rect(pdfMargin, p.y, pdfRight-pdfMargin, pdfRowHeight, pdfZebra)
+	}
+	p.rows++
+
+	base := p.y + 11
+	values := []string{date, description, reference, amount, balance}
+	for i, c := range pdfColumns {
+		v := pdf.Truncate(f, pdfBodySize, values[i], c.width)
+		switch {
+		case v == "":
+		case c.right:
+			col := pdf.Black
+			if i == 3 {
+				col = color
+			}
+			p.page.TextRight(c.x, base, f, pdfBodySize, col, v)
+		default:
+			p.page.Text(c.x, base, f, pdfBodySize, pdf.Black, v)
+		}
+	}
+	p.y += pdfRowHeight
+}
+
+// newPage sigue la tabla en otra página, con un encabezado reducido y los títulos de columnas.
+func (p *pdfStatement) newPage() {
+	p.page = p.doc.AddPage()
+	p.page.Rect(0, 0, pdf.A4Width, 36, pdfBrandColor)
+	p.page.Text(pdfMargin, 23, pdf.HelveticaBold, 12, pdf.White, pdfBrand)
+	p.page.TextRight(pdfRight, 23, pdf.Helvetica, 9, pdf.White, p.doc.Title)
+
+	p.y = 60
+	p.tableHeader()
+}
+
+// describeLine es la descripción legible de un movimiento.
+func describeLine(l *StatementLine) string {
+	var d string
+	switch l.Type {
+	case "deposit":
+		d = "Deposit"
+	case "withdraw":
+		d = "Withdrawal"
+	case "transfer":
+		d = "Transfer"
+	case "reversal":
+		d = "Reversal"
+	default:
+		d = l.Type
+	}
+
+	if l.Counterparty != nil {
+		dir := "from"
+		if l.Amount < 0 {
+			dir = "to"
+		}
+		d += " " + dir + " account #" + strconv.FormatUint(uint64(*l.Counterparty), 10)
+	}
+	return d
+}
+
+// statementPeriod es el mes del extracto, o el rango si no es un mes justo.
+func statementPeriod(s *Statement) string {
+	if s.From.Day() == 1 && lastDay(s).Month() == s.From.Month() {
+		return s.From.Format("January 2006")
+	}
+	return s.From.Format("02 Jan 2006") + " – " + lastDay(s).Format("02 Jan 2006")
+}
+
+// lastDay es el último instante incluido en el período: To es exclusivo.
+func lastDay(s *Statement) time.Time {
+	return s.To.Add(-time.Nanosecond)
+}
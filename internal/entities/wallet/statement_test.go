@@ -0,0 +1,111 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	ledger "github.com/sebaactis/wallet-go-api/internal/entities/legder"
+	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"gorm.io/gorm"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const (
+	statementAccountID = 510
+	counterpartyID     = 511
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// seedStatement carga un libro fijo, con ids y fechas explícitos: un depósito antes del período
+// (saldo de apertura), tres movimientos dentro y uno después, más el lado de la contraparte de la
+// transferencia, que no es de la cuenta. Las referencias prueban el escape de CSV y de XML.
+func seedStatement(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	ref := func(s string) *string { return &s }
+	id := func(n uint) *uint { return &n }
+
+	u := &user.User{ID: 500, Name: "Ana Pérez", Email: "ana@x.io", Password: "x"}
+	accounts := []*account.Account{
+		{ID: statementAccountID, UserID: 500, Currency: "USD"},
+		{ID: counterpartyID, UserID: 500, Currency: "USD"},
+	}
+	txs := []*transaction.Transaction{
+		{ID: 100, Type: "deposit", ToAccountID: id(statementAccountID), Amount: 15000, Currency: "USD", CreatedAt: at("2026-02-27T10:00:00Z")},
+		{ID: 101, Type: "deposit", Reference: ref("INV-2026-031"), ToAccountID: id(statementAccountID), Amount: 25000, Currency: "USD", CreatedAt: at("2026-03-02T09:15:00Z")},
+		{ID: 102, Type: "transfer", Reference: ref(`rent & "utilities" <march>`), FromAccountID: id(statementAccountID), ToAccountID: id(counterpartyID), Amount: 8025, Currency: "USD", CreatedAt: at("2026-03-05T14:30:00Z")},
+		{ID: 103, Type: "withdraw", Reference: ref("-ATM 42"), FromAccountID: id(statementAccountID), Amount: 4000, Currency: "USD", CreatedAt: at("2026-03-20T18:45:30.5Z")},
+		{ID: 104, Type: "deposit", ToAccountID: id(statementAccountID), Amount: 1000, Currency: "USD", CreatedAt: at("2026-04-02T08:00:00Z")},
+	}
+	entries := []*ledger.LedgerEntry{
+		{ID: 1000, TransactionID: 100, AccountID: statementAccountID, Amount: 15000, Currency: "USD", CreatedAt: at("2026-02-27T10:00:00Z")},
+		{ID: 1001, TransactionID: 101, AccountID: statementAccountID, Amount: 25000, Currency: "USD", CreatedAt: at("2026-03-02T09:15:00Z")},
+		{ID: 1002, TransactionID: 102, AccountID: statementAccountID, Amount: -8025, Currency: "USD", CreatedAt: at("2026-03-05T14:30:00Z")},
+		{ID: 1003, TransactionID: 102, AccountID: counterpartyID, Amount: 8025, Currency: "USD", CreatedAt: at("2026-03-05T14:30:00Z")},
+		{ID: 1004, TransactionID: 103, AccountID: statementAccountID, Amount: -4000, Currency: "USD", CreatedAt: at("2026-03-20T18:45:30.5Z")},
+		{ID: 1005, TransactionID: 104, AccountID: statementAccountID, Amount: 1000, Currency: "USD", CreatedAt: at("2026-04-02T08:00:00Z")},
+	}
+
+	for _, v := range []any{u, accounts, txs, entries} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Cada formato se compara byte a byte con su archivo en testdata. Con -update se regeneran.
+func TestStatementGolden(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	seedStatement(t, db)
+	svc := NewService(db, nil, 0, 0)
+
+	for format, golden := range map[string]string{
+		StatementCSV:     "statement.csv.golden",
+		StatementOFX:     "statement.ofx.golden",
+		StatementCamt053: "statement.camt053.xml.golden",
+	} {
+		t.Run(format, func(t *testing.T) {
+			st, err := svc.Statement(ctx, statementAccountID, at("2026-03-01T00:00:00Z"), at("2026-04-01T00:00:00Z"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			st.GeneratedAt = at("2026-04-01T06:00:00Z")
+
+			var buf bytes.Buffer
+			if err := svc.WriteStatement(ctx, st, format, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", golden)
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("%s output differs from %s (run with -update to accept):\n%s", format, path, buf.String())
+			}
+		})
+	}
+}
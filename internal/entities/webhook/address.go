@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress es la falla de un envío cuyo host resolvió a una dirección interna.
+var ErrBlockedAddress = errors.New("webhook address not allowed")
+
+// sharedAddressSpace es el rango de CGNAT (RFC 6598), que algunas nubes usan para servicios
+// internos.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr indica si se puede mandar un webhook a ip: nada de loopback, redes privadas,
+// link-local (incluye la metadata de las nubes), multicast ni la dirección sin especificar.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() &&
+		!ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// checkURL resuelve el host de una URL ya normalizada y la rechaza si alguna de sus direcciones
+// no es pública. Es el control al registrar; el que vale es el de checkDial, porque el DNS puede
+// cambiar después.
+func (s *Service) checkURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidEndpoint)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: host %q does not resolve", ErrInvalidEndpoint, u.Hostname())
+	}
+	for _, ip := range addrs {
+		if !s.allowAddr(ip) {
+			return fmt.Errorf("%w: host %q resolves to a non-public address", ErrInvalidEndpoint, u.Hostname())
+		}
+	}
+
+	return nil
+}
+
+// checkDial corre con la dirección ya resuelta, justo antes de conectar, así un host que
+// resolvía a una IP pública al registrarse no puede apuntar después a la red interna.
+func (s *Service) checkDial(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	if !s.allowAddr(ap.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ap.Addr())
+	}
+	return nil
+}
+
+// newClient arma el cliente de los envíos. No usa el proxy del entorno, que conectaría a otra
+// dirección que la controlada, y no sigue redirecciones: la respuesta 3xx cuenta como falla.
+func (s *Service) newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: s.checkDial}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
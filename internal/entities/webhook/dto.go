@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/httputil"
+)
+
+// CreateEndpointRequest: sin Events recibe todos los tipos. Sin Secret se genera uno, que solo se
+// devuelve en esta respuesta.
+type CreateEndpointRequest struct {
+	URL    string   `json:"url"    validate:"required,url,max=500"`
+	Events []string `json:"events" validate:"omitempty,max=20"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=100"`
+}
+
+// UpdateEndpointRequest solo toca los campos presentes. Enabled en true rehabilita un endpoint
+// deshabilitado y pone en cero sus fallas.
+type UpdateEndpointRequest struct {
+	URL     *string   `json:"url"     validate:"omitempty,url,max=500"`
+	Events  *[]string `json:"events"  validate:"omitempty,max=20"`
+	Secret  *string   `json:"secret"  validate:"omitempty,min=16,max=100"`
+	Enabled *bool     `json:"enabled"`
+}
+
+type EndpointResponse struct {
+	ID             uint     `json:"id"`
+	URL            string   `json:"url"`
+	Events         []string `json:"events"`
+	Secret         string   `json:"secret,omitempty"`
+	Enabled        bool     `json:"enabled"`
+	Failures       int      `json:"consecutiveFailures"`
+	DisabledAt     *string  `json:"disabledAt"`
+	DisabledReason string   `json:"disabledReason,omitempty"`
+	CreatedAt      string   `json:"createdAt"`
+}
+
+type DeliveryResponse struct {
+	ID            uint               `json:"id"`
+	EventID       uint               `json:"eventId"`
+	EventType     string             `json:"eventType"`
+	Status        string             `json:"status"`
+	Attempts      int                `json:"attempts"`
+	ResponseCode  int                `json:"responseCode,omitempty"`
+	LastError     string             `json:"lastError,omitempty"`
+	NextAttemptAt *string            `json:"nextAttemptAt"`
+	DeliveredAt   *string            `json:"deliveredAt"`
+	CreatedAt     string             `json:"createdAt"`
+	Log           []*AttemptResponse `json:"log"`
+}
+
+type AttemptResponse struct {
+	ResponseCode int    `json:"responseCode"`
+	ResponseBody string `json:"responseBody,omitempty"`
+	Error        string `json:"error,omitempty"`
+	DurationMs   int64  `json:"durationMs"`
+	At           string `json:"at"`
+}
+
+func ToResponse(e *Endpoint) *EndpointResponse {
+	return &EndpointResponse{
+		ID:             e.ID,
+		URL:            e.URL,
+		Events:         e.EventTypes(),
+		Enabled:        e.Enabled(),
+		Failures:       e.Failures,
+		DisabledAt:     formatOptional(e.DisabledAt),
+		DisabledReason: e.DisabledReason,
+		CreatedAt:      httputil.FormatDate(&e.CreatedAt),
+	}
+}
+
+func ToResponseMany(endpoints []*Endpoint) []*EndpointResponse {
+	response := make([]*EndpointResponse, len(endpoints))
+
+	for i, e := range endpoints {
+		response[i] = ToResponse(e)
+	}
+
+	return response
+}
+
+func ToDeliveryResponse(d *Delivery) *DeliveryResponse {
+	resp := &DeliveryResponse{
+		ID:           d.ID,
+		EventID:      d.EventID,
+		EventType:    d.EventType,
+		Status:       d.Status,
+		Attempts:     d.Attempts,
+		ResponseCode: d.ResponseCode,
+		LastError:    d.LastError,
+		DeliveredAt:  formatOptional(d.DeliveredAt),
+		CreatedAt:    httputil.FormatDate(&d.CreatedAt),
+		Log:          make([]*AttemptResponse, len(d.Log)),
+	}
+
+	if d.Status == DeliveryPending {
+		resp.NextAttemptAt = formatOptional(&d.NextAttemptAt)
+	}
+
+	for i, a := range d.Log {
+		resp.Log[i] = &AttemptResponse{
+			ResponseCode: a.ResponseCode,
+			ResponseBody: a.ResponseBody,
+			Error:        a.Error,
+			DurationMs:   a.DurationMs,
+			At:           httputil.FormatDate(&a.CreatedAt),
+		}
+	}
+
+	return resp
+}
+
+func ToDeliveryResponseMany(deliveries []*Delivery) []*DeliveryResponse {
+	response := make([]*DeliveryResponse, len(deliveries))
+
+	for i, d := range deliveries {
+		response[i] = ToDeliveryResponse(d)
+	}
+
+	return response
+}
+
+func formatOptional(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := httputil.FormatDate(t)
+	return &s
+}
@@ -0,0 +1,218 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sebaactis/wallet-go-api/internal/authz"
+	"github.com/sebaactis/wallet-go-api/internal/httputil"
+	"github.com/sebaactis/wallet-go-api/internal/validation"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+type HTTPHandler struct {
+	service   *Service
+	validator validation.StructValidator
+}
+
+func NewHTTPHandler(service *Service, validator validation.StructValidator) *HTTPHandler {
+	return &HTTPHandler{service: service, validator: validator}
+}
+
+// POST /v1/webhooks
+func (h *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
+	subject, ok := authz.SubjectFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req CreateEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid json", nil)
+		return
+	}
+
+	if fields, ok := h.validator.ValidateStruct(&req); !ok {
+		httputil.WriteError(w, http.StatusBadRequest, "validation error", fields)
+		return
+	}
+
+	e, err := h.service.Create(r.Context(), subject.UserID, &req)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	resp := ToResponse(e)
+	resp.Secret = e.Secret
+	httputil.WriteJSON(w, http.StatusCreated, resp)
+}
+
+// GET /v1/webhooks
+func (h *HTTPHandler) List(w http.ResponseWriter, r *http.Request) {
+	subject, ok := authz.SubjectFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	endpoints, err := h.service.List(r.Context(), subject.UserID)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, ToResponseMany(endpoints))
+}
+
+// GET /v1/webhooks/{id}
+func (h *HTTPHandler) Get(w http.ResponseWriter, r *http.Request) {
+	e, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, ToResponse(e))
+}
+
+// PATCH /v1/webhooks/{id}
+func (h *HTTPHandler) Update(w http.ResponseWriter, r *http.Request) {
+	e, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid json", nil)
+		return
+	}
+
+	if fields, ok := h.validator.ValidateStruct(&req); !ok {
+		httputil.WriteError(w, http.StatusBadRequest, "validation error", fields)
+		return
+	}
+
+	e, err := h.service.Update(r.Context(), e, &req)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, ToResponse(e))
+}
+
+// DELETE /v1/webhooks/{id}
+func (h *HTTPHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	e, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), e); err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /v1/webhooks/{id}/deliveries?status=&limit=
+func (h *HTTPHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	e, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+
+	status := q.Get("status")
+	switch status {
+	case "", DeliveryPending, DeliverySucceeded, DeliveryFailed:
+	default:
+		httputil.WriteError(w, http.StatusBadRequest, "invalid status", nil)
+		return
+	}
+
+	limit := defaultDeliveriesLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxDeliveriesLimit {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid limit", nil)
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.service.Deliveries(r.Context(), e, status, limit)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, ToDeliveryResponseMany(deliveries))
+}
+
+// POST /v1/webhooks/{id}/deliveries/{deliveryId}/redeliver
+func (h *HTTPHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	e, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.Atoi(chi.URLParam(r, "deliveryId"))
+	if err != nil || deliveryID <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid delivery id", nil)
+		return
+	}
+
+	d, err := h.service.Redeliver(r.Context(), e, uint(deliveryID))
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusAccepted, ToDeliveryResponse(d))
+}
+
+func (h *HTTPHandler) load(w http.ResponseWriter, r *http.Request) (*Endpoint, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid id", nil)
+		return nil, false
+	}
+
+	e, err := h.service.Get(r.Context(), uint(id))
+	if err != nil {
+		writeErr(w, err)
+		return nil, false
+	}
+
+	if err := authz.Authorize(r.Context(), authz.ManageWebhook, authz.Resource{OwnerID: e.UserID}); err != nil {
+		httputil.WriteError(w, authz.Status(err), err.Error(), nil)
+		return nil, false
+	}
+
+	return e, true
+}
+
+func writeErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrEndpointNotFound), errors.Is(err, ErrDeliveryNotFound):
+		httputil.WriteError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ErrEndpointDisabled), errors.Is(err, ErrTooManyEndpoints):
+		httputil.WriteError(w, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, ErrInvalidEndpoint):
+		httputil.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		httputil.WriteError(w, http.StatusInternalServerError, "internal error", nil)
+	}
+}
@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Endpoint es una URL de un usuario que recibe sus eventos. Events son los tipos que le interesan,
+// separados por coma; vacío recibe todos. Failures cuenta los intentos fallidos seguidos, de
+// cualquier envío: al llegar al tope el endpoint queda deshabilitado (DisabledAt) hasta que el
+// usuario lo vuelva a habilitar.
+type Endpoint struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"user_id" gorm:"not null;index"`
+	URL            string     `json:"url" gorm:"size:500;not null"`
+	Events         string     `json:"events" gorm:"size:500;not null;default:''"`
+	Secret         string     `json:"-" gorm:"size:100;not null"`
+	Failures       int        `json:"failures" gorm:"not null;default:0"`
+	DisabledAt     *time.Time `json:"disabled_at"`
+	DisabledReason string     `json:"disabled_reason" gorm:"size:255"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (Endpoint) TableName() string { return "webhook_endpoints" }
+
+func (e *Endpoint) Enabled() bool { return e.DisabledAt == nil }
+
+func (e *Endpoint) EventTypes() []string {
+	if e.Events == "" {
+		return []string{}
+	}
+	return strings.Split(e.Events, ",")
+}
+
+// Wants indica si el endpoint está suscripto al tipo de evento.
+func (e *Endpoint) Wants(eventType string) bool {
+	if e.Events == "" {
+		return true
+	}
+	for _, t := range e.EventTypes() {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery es el envío de un evento del outbox a un endpoint. Payload es el body tal cual se manda
+// en cada intento; la firma cambia porque incluye el timestamp del intento.
+type Delivery struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	EndpointID    uint       `json:"endpoint_id" gorm:"not null;uniqueIndex:idx_webhook_deliveries_event,priority:1"`
+	EventID       uint       `json:"event_id" gorm:"not null;uniqueIndex:idx_webhook_deliveries_event,priority:2"`
+	EventType     string     `json:"event_type" gorm:"size:50;not null"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"`
+	Status        string     `json:"status" gorm:"size:20;not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	ResponseCode  int        `json:"response_code" gorm:"not null;default:0"`
+	LastError     string     `json:"last_error" gorm:"size:500"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	Log           []Attempt  `json:"log" gorm:"foreignKey:DeliveryID"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Delivery) TableName() string { return "webhook_deliveries" }
+
+// Attempt es un intento de envío: lo que respondió el endpoint o por qué no se pudo. ResponseCode
+// es 0 si no hubo respuesta (timeout, conexión rechazada).
+type Attempt struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	DeliveryID   uint      `json:"delivery_id" gorm:"not null;index"`
+	ResponseCode int       `json:"response_code" gorm:"not null;default:0"`
+	ResponseBody string    `json:"response_body" gorm:"size:500"`
+	Error        string    `json:"error" gorm:"size:500"`
+	DurationMs   int64     `json:"duration_ms" gorm:"not null;default:0"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (Attempt) TableName() string { return "webhook_attempts" }
@@ -0,0 +1,209 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
+
+func (r *Repository) Create(ctx context.Context, e *Endpoint) error {
+	return r.db.WithContext(ctx).Create(e).Error
+}
+
+func (r *Repository) Save(ctx context.Context, e *Endpoint) error {
+	return r.db.WithContext(ctx).Save(e).Error
+}
+
+func (r *Repository) FindByID(ctx context.Context, id uint) (*Endpoint, error) {
+	var e Endpoint
+
+	if err := r.db.WithContext(ctx).First(&e, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func (r *Repository) FindByUser(ctx context.Context, userID uint) ([]*Endpoint, error) {
+	endpoints := []*Endpoint{}
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id").
+		Find(&endpoints).Error
+
+	return endpoints, err
+}
+
+func (r *Repository) CountByUser(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&Endpoint{}).Where("user_id = ?", userID).Count(&n).Error
+	return n, err
+}
+
+// Delete borra el endpoint con sus envíos y su log.
+func (r *Repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deliveries := tx.Model(&Delivery{}).Select("id").Where("endpoint_id = ?", id)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&Attempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("endpoint_id = ?", id).Delete(&Delivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Endpoint{}, id).Error
+	})
+}
+
+// Enabled devuelve los endpoints habilitados del usuario.
+func (r *Repository) Enabled(ctx context.Context, userID uint) ([]*Endpoint, error) {
+	var endpoints []*Endpoint
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND disabled_at IS NULL", userID).
+		Order("id").
+		Find(&endpoints).Error
+
+	return endpoints, err
+}
+
+// Enqueue crea el envío salvo que ya exista para el mismo endpoint y evento: el outbox entrega al
+// menos una vez, así que el mismo evento puede llegar repetido.
+func (r *Repository) Enqueue(ctx context.Context, d *Delivery) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(d).Error
+}
+
+// Due devuelve los envíos pendientes que ya toca intentar, de endpoints habilitados.
+func (r *Repository) Due(ctx context.Context, now time.Time, limit int) ([]*Delivery, error) {
+	var deliveries []*Delivery
+
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", DeliveryPending, now).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Where("endpoint_id IN (?)", r.db.Model(&Endpoint{}).Select("id").Where("disabled_at IS NULL")).
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&deliveries).Error
+
+	return deliveries, err
+}
+
+// Claim toma el envío hasta until con un compare-and-swap, para que con varias instancias lo
+// mande una sola.
+func (r *Repository) Claim(ctx context.Context, d *Delivery, now, until time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&Delivery{}).
+		Where("id = ? AND status = ? AND attempts = ? AND (locked_until IS NULL OR locked_until < ?)", d.ID, DeliveryPending, d.Attempts, now).
+		Update("locked_until", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Record guarda el intento y el estado en que queda el envío.
+func (r *Repository) Record(ctx context.Context, d *Delivery, a *Attempt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(a).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&Delivery{}).
+			Where("id = ?", d.ID).
+			Updates(map[string]interface{}{
+				"status":          d.Status,
+				"attempts":        d.Attempts,
+				"next_attempt_at": d.NextAttemptAt,
+				"locked_until":    nil,
+				"response_code":   d.ResponseCode,
+				"last_error":      d.LastError,
+				"delivered_at":    d.DeliveredAt,
+			}).Error
+	})
+}
+
+func (r *Repository) ResetFailures(ctx context.Context, endpointID uint) error {
+	return r.db.WithContext(ctx).
+		Model(&Endpoint{}).
+		Where("id = ? AND failures <> 0", endpointID).
+		Update("failures", 0).Error
+}
+
+// AddFailure suma un intento fallido al endpoint y lo deshabilita si llegó a disableAfter.
+// Devuelve true si lo deshabilitó esta llamada.
+func (r *Repository) AddFailure(ctx context.Context, endpointID uint, disableAfter int, now time.Time, reason string) (bool, error) {
+	db := r.db.WithContext(ctx)
+
+	if err := db.Model(&Endpoint{}).
+		Where("id = ?", endpointID).
+		Update("failures", gorm.Expr("failures + 1")).Error; err != nil {
+		return false, err
+	}
+
+	result := db.Model(&Endpoint{}).
+		Where("id = ? AND disabled_at IS NULL AND failures >= ?", endpointID, disableAfter).
+		Updates(map[string]interface{}{"disabled_at": now, "disabled_reason": reason})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *Repository) FindDelivery(ctx context.Context, id uint) (*Delivery, error) {
+	var d Delivery
+
+	err := r.db.WithContext(ctx).
+		Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&d, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// Deliveries devuelve los envíos del endpoint del más nuevo al más viejo, con sus intentos.
+func (r *Repository) Deliveries(ctx context.Context, endpointID uint, status string, limit int) ([]*Delivery, error) {
+	deliveries := []*Delivery{}
+
+	db := r.db.WithContext(ctx).
+		Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	err := db.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Redeliver vuelve a encolar el envío para ya, con los intentos en cero. El log se conserva.
+func (r *Repository) Redeliver(ctx context.Context, d *Delivery, now time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&Delivery{}).
+		Where("id = ?", d.ID).
+		Updates(map[string]interface{}{
+			"status":          DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"locked_until":    nil,
+		}).Error
+}
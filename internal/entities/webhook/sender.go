@@ -0,0 +1,234 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	SignatureHeader = "X-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	// claimFor es cuánto queda tomado un envío; tiene que superar al timeout del cliente.
+	claimFor      = 2 * time.Minute
+	maxRetryDelay = 6 * time.Hour
+	sendWorkers   = 8
+	maxLoggedBody = 500
+)
+
+// Sign devuelve la firma de un envío: HMAC-SHA256 con el secreto del endpoint sobre
+// "<timestamp>.<body>", en hex y con el prefijo "sha256=". El receptor la recalcula con el
+// X-Webhook-Timestamp recibido, la compara en tiempo constante y descarta los envíos viejos.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify comprueba una firma hecha con Sign.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// SendDue manda los envíos pendientes que ya toca intentar. Devuelve cuántos salieron bien.
+func (s *Service) SendDue(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := s.repo.Due(ctx, now, dueBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		sent int
+		errs []error
+	)
+	sem := make(chan struct{}, sendWorkers)
+
+	for _, d := range deliveries {
+		ok, err := s.repo.Claim(ctx, d, now, now.Add(claimFor))
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			break
+		}
+		if !ok {
+			continue // lo tomó otra instancia
+		}
+
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			delivered, err := s.attempt(ctx, d)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("delivery %d: %w", d.ID, err))
+			}
+			if delivered {
+				sent++
+			}
+		})
+	}
+
+	wg.Wait()
+	return sent, errors.Join(errs...)
+}
+
+// attempt hace un intento y guarda el resultado. El error es de la base, no del endpoint: una
+// falla del endpoint queda registrada en el envío y se reintenta.
+func (s *Service) attempt(ctx context.Context, d *Delivery) (bool, error) {
+	e, err := s.repo.FindByID(ctx, d.EndpointID)
+	if err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	code, respBody, sendErr := s.send(ctx, e, d)
+
+	a := &Attempt{
+		DeliveryID:   d.ID,
+		ResponseCode: code,
+		ResponseBody: truncate(respBody, maxLoggedBody),
+		DurationMs:   time.Since(start).Milliseconds(),
+	}
+
+	now := time.Now()
+	d.Attempts++
+	d.ResponseCode = code
+
+	if sendErr == nil {
+		d.Status, d.LastError, d.DeliveredAt = DeliverySucceeded, "", &now
+		if err := s.record(ctx, d, a); err != nil {
+			return false, err
+		}
+		return true, s.repo.ResetFailures(ctx, e.ID)
+	}
+
+	a.Error = truncate(sendErr.Error(), maxLoggedBody)
+	d.LastError = a.Error
+
+	if d.Attempts >= s.maxAttempts {
+		d.Status = DeliveryFailed
+	} else {
+		wait := s.retryDelay << (d.Attempts - 1)
+		if wait <= 0 || wait > maxRetryDelay {
+			wait = maxRetryDelay
+		}
+		d.NextAttemptAt = now.Add(wait)
+	}
+
+	if err := s.record(ctx, d, a); err != nil {
+		return false, err
+	}
+
+	reason := fmt.Sprintf("%d consecutive failed deliveries", s.disableAfter)
+	disabled, err := s.repo.AddFailure(ctx, e.ID, s.disableAfter, now, reason)
+	if err != nil {
+		return false, err
+	}
+	if disabled {
+		log.Printf("webhooks: endpoint %d disabled after %d consecutive failures", e.ID, s.disableAfter)
+	}
+
+	return false, nil
+}
+
+// record guarda el intento. Si la base lo rechaza, lo vuelve a guardar sin la respuesta del
+// endpoint: el intento tiene que contar igual, o el envío seguiría pendiente con los mismos intentos
+// y se volvería a mandar cada claimFor para siempre.
+func (s *Service) record(ctx context.Context, d *Delivery, a *Attempt) error {
+	err := s.repo.Record(ctx, d, a)
+	if err == nil {
+		return nil
+	}
+
+	log.Printf("webhooks: delivery %d: recording attempt without the response body: %v", d.ID, err)
+	a.ID, a.ResponseBody = 0, ""
+	return s.repo.Record(ctx, d, a)
+}
+
+// send hace el POST firmado. Cualquier respuesta fuera de 2xx cuenta como falla.
+func (s *Service) send(ctx context.Context, e *Endpoint, d *Delivery) (int, string, error) {
+	body := []byte(d.Payload)
+	ts := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wallet-go-api-webhooks")
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, Sign(e.Secret, ts, body))
+	req.Header.Set(EventHeader, d.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(d.ID), 10))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, string(respBody), nil
+}
+
+// RunSender manda envíos pendientes cada interval hasta que se cancele ctx. Con interval 0 no corre.
+func (s *Service) RunSender(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.SendDue(ctx, time.Now()); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("webhooks: %v", err)
+			}
+		}
+	}
+}
+
+// truncate deja s como texto que acepta cualquier base: UTF-8 válido, sin NUL y de a lo sumo n
+// bytes, cortado entre runas. La respuesta del endpoint puede traer cualquier cosa.
+func truncate(s string, n int) string {
+	s = strings.ToValidUTF8(strings.ReplaceAll(s, "\x00", ""), "\uFFFD")
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,331 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/platform/config"
+	"github.com/sebaactis/wallet-go-api/internal/platform/database"
+	"gorm.io/gorm"
+)
+
+const testSecret = "whsec_test"
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "webhooks.db") + "?_pragma=foreign_keys(ON)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	db, err := database.Open(config.Config{Driver: "sqlite", DSN: dsn, MaxOpenConns: 8, MaxIdleConns: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// receiver es un endpoint de prueba: verifica la firma de cada envío y responde con el código
+// que le toque a ese intento y con body.
+type receiver struct {
+	t     *testing.T
+	codes []int
+	body  string
+
+	mu       sync.Mutex
+	requests int
+	bodies   []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	ts, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	if err != nil || !Verify(testSecret, ts, body, r.Header.Get(SignatureHeader)) {
+		rc.t.Errorf("delivery %s arrived with an invalid signature", r.Header.Get(DeliveryHeader))
+	}
+	if got := r.Header.Get(EventHeader); got != outbox.TransactionPosted {
+		rc.t.Errorf("event header %q, want %q", got, outbox.TransactionPosted)
+	}
+
+	rc.mu.Lock()
+	code := rc.codes[min(rc.requests, len(rc.codes)-1)]
+	rc.requests++
+	rc.bodies = append(rc.bodies, string(body))
+	rc.mu.Unlock()
+
+	w.WriteHeader(code)
+	io.WriteString(w, rc.body)
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.requests
+}
+
+// newSenderFixture registra un endpoint que apunta a url y encola un evento para él. El servicio
+// acepta direcciones de loopback para poder usar httptest.
+func newSenderFixture(t *testing.T, url string, maxAttempts, disableAfter int) (*Service, *Endpoint, *Delivery) {
+	t.Helper()
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	u := &user.User{Name: "ana", Email: "ana@x.io", Password: "x"}
+	if err := db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService(NewRepository(db), time.Second, maxAttempts, time.Minute, disableAfter)
+	svc.allowAddr = func(netip.Addr) bool { return true }
+
+	e, err := svc.Create(ctx, u.ID, &CreateEndpointRequest{URL: url, Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := &outbox.Event{ID: 1, Type: outbox.TransactionPosted, UserID: u.ID, Payload: `{"id":1}`, CreatedAt: time.Now()}
+	if err := svc.Enqueue(ctx, ev); err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := svc.Deliveries(ctx, e, "", 10)
+	if err != nil || len(ds) != 1 {
+		t.Fatalf("deliveries = %v, %v; want one", ds, err)
+	}
+	return svc, e, ds[0]
+}
+
+func reload(t *testing.T, svc *Service, e *Endpoint, d *Delivery) (*Endpoint, *Delivery) {
+	t.Helper()
+	ctx := context.Background()
+
+	e, err := svc.Get(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = svc.repo.FindDelivery(ctx, d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, d
+}
+
+// El primer intento falla y se reprograma con espera; el reintento sale bien y limpia las fallas
+// del endpoint.
+func TestSendDueRetriesFailedDelivery(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{t: t, codes: []int{http.StatusInternalServerError, http.StatusNoContent}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	svc, e, d := newSenderFixture(t, srv.URL, 3, 5)
+
+	if sent, err := svc.SendDue(ctx, time.Now()); err != nil || sent != 0 {
+		t.Fatalf("first SendDue = %d, %v; want 0, nil", sent, err)
+	}
+	e, d = reload(t, svc, e, d)
+	if d.Status != DeliveryPending || d.Attempts != 1 || d.ResponseCode != http.StatusInternalServerError {
+		t.Errorf("after failure: status %s attempts %d code %d", d.Status, d.Attempts, d.ResponseCode)
+	}
+	if wait := time.Until(d.NextAttemptAt); wait < 50*time.Second || wait > time.Minute {
+		t.Errorf("next attempt in %v, want about a minute", wait)
+	}
+	if e.Failures != 1 {
+		t.Errorf("endpoint failures = %d, want 1", e.Failures)
+	}
+
+	// Antes de la espera no hay nada que mandar.
+	if _, err := svc.SendDue(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if n := rc.count(); n != 1 {
+		t.Fatalf("receiver got %d requests before the retry delay, want 1", n)
+	}
+
+	if sent, err := svc.SendDue(ctx, time.Now().Add(2*time.Minute)); err != nil || sent != 1 {
+		t.Fatalf("retry SendDue = %d, %v; want 1, nil", sent, err)
+	}
+	e, d = reload(t, svc, e, d)
+	if d.Status != DeliverySucceeded || d.Attempts != 2 || d.DeliveredAt == nil {
+		t.Errorf("after retry: status %s attempts %d delivered %v", d.Status, d.Attempts, d.DeliveredAt)
+	}
+	if e.Failures != 0 {
+		t.Errorf("endpoint failures = %d after a success, want 0", e.Failures)
+	}
+	if rc.bodies[0] != rc.bodies[1] {
+		t.Errorf("retry body %q differs from the first %q", rc.bodies[1], rc.bodies[0])
+	}
+}
+
+// Un endpoint que falla disableAfter veces seguidas queda deshabilitado y no recibe más envíos.
+func TestSendDueDisablesFailingEndpoint(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{t: t, codes: []int{http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	svc, e, d := newSenderFixture(t, srv.URL, 5, 2)
+
+	now := time.Now()
+	for i := range 2 {
+		if _, err := svc.SendDue(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+		if n := rc.count(); n != i+1 {
+			t.Fatalf("receiver got %d requests, want %d", n, i+1)
+		}
+		now = now.Add(time.Hour)
+	}
+
+	e, d = reload(t, svc, e, d)
+	if e.Enabled() || e.Failures != 2 {
+		t.Fatalf("endpoint enabled %v failures %d, want disabled after 2", e.Enabled(), e.Failures)
+	}
+	if d.Status != DeliveryPending || d.Attempts != 2 {
+		t.Errorf("delivery status %s attempts %d, want pending with 2", d.Status, d.Attempts)
+	}
+
+	if _, err := svc.SendDue(ctx, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if n := rc.count(); n != 2 {
+		t.Errorf("disabled endpoint got %d requests, want 2", n)
+	}
+}
+
+func TestCreateRejectsInternalHosts(t *testing.T) {
+	svc := NewService(nil, time.Second, 1, time.Minute, 1)
+
+	for _, u := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://[::1]/hook",
+		"http://0.0.0.0/hook",
+		"http://10.1.2.3/hook",
+		"http://172.16.0.1/hook",
+		"http://192.168.1.10/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hook",
+		"http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://100.100.100.200/hook",
+	} {
+		_, err := svc.Create(context.Background(), 1, &CreateEndpointRequest{URL: u})
+		if !errors.Is(err, ErrInvalidEndpoint) {
+			t.Errorf("Create(%s) error = %v, want ErrInvalidEndpoint", u, err)
+		}
+	}
+}
+
+// El control al conectar frena un endpoint que pasó el alta pero resuelve a la red interna.
+func TestSendRefusesInternalAddress(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{t: t, codes: []int{http.StatusNoContent}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	svc, e, d := newSenderFixture(t, srv.URL, 3, 5)
+	svc.allowAddr = publicAddr
+
+	if sent, err := svc.SendDue(ctx, time.Now()); err != nil || sent != 0 {
+		t.Fatalf("SendDue = %d, %v; want 0, nil", sent, err)
+	}
+	if n := rc.count(); n != 0 {
+		t.Errorf("receiver got %d requests, want none", n)
+	}
+	if _, d = reload(t, svc, e, d); d.Attempts != 1 || d.ResponseCode != 0 {
+		t.Errorf("delivery attempts %d code %d, want a failed attempt without response", d.Attempts, d.ResponseCode)
+	}
+}
+
+// Una redirección no se sigue: el envío falla con el 3xx y el destino no recibe nada.
+func TestSendDoesNotFollowRedirects(t *testing.T) {
+	ctx := context.Background()
+	target := &receiver{t: t, codes: []int{http.StatusNoContent}}
+	targetSrv := httptest.NewServer(target)
+	defer targetSrv.Close()
+
+	redirect := httptest.NewServer(http.RedirectHandler(targetSrv.URL, http.StatusFound))
+	defer redirect.Close()
+
+	svc, e, d := newSenderFixture(t, redirect.URL, 3, 5)
+
+	if sent, err := svc.SendDue(ctx, time.Now()); err != nil || sent != 0 {
+		t.Fatalf("SendDue = %d, %v; want 0, nil", sent, err)
+	}
+	if n := target.count(); n != 0 {
+		t.Errorf("redirect target got %d requests, want none", n)
+	}
+	if _, d = reload(t, svc, e, d); d.ResponseCode != http.StatusFound || d.Status != DeliveryPending {
+		t.Errorf("delivery code %d status %s, want 302 and pending", d.ResponseCode, d.Status)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"ok", 10, "ok"},
+		{"a\x00b", 10, "ab"},
+		{"a\xffb", 10, "a\uFFFDb"},
+		{"abcé", 4, "abc"}, // la é ocupa los bytes 3 y 4: no se corta a la mitad
+		{"abcé", 5, "abcé"},
+		{"日本語", 7, "日本"},
+	}
+
+	for _, tt := range tests {
+		got := truncate(tt.in, tt.n)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+		if !utf8.ValidString(got) || len(got) > tt.n {
+			t.Errorf("truncate(%q, %d) = %q, not valid UTF-8 within %d bytes", tt.in, tt.n, got, tt.n)
+		}
+	}
+}
+
+// Si la base rechaza la respuesta del endpoint (Postgres no acepta NUL ni UTF-8 inválido), el
+// intento se guarda sin ella: el envío no queda pendiente para volver a mandarse.
+func TestSendDueRecordsAttemptWhenResponseIsRejected(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{t: t, codes: []int{http.StatusOK}, body: "accepted"}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	svc, e, d := newSenderFixture(t, srv.URL, 3, 5)
+	if err := svc.repo.db.Exec(`CREATE TRIGGER reject_response_body BEFORE INSERT ON webhook_attempts
+		WHEN NEW.response_body <> '' BEGIN SELECT RAISE(ABORT, 'invalid byte sequence'); END`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if sent, err := svc.SendDue(ctx, time.Now()); err != nil || sent != 1 {
+		t.Fatalf("SendDue = %d, %v; want 1, nil", sent, err)
+	}
+	if _, d = reload(t, svc, e, d); d.Status != DeliverySucceeded || d.Attempts != 1 {
+		t.Errorf("delivery status %s attempts %d, want succeeded with 1", d.Status, d.Attempts)
+	}
+
+	if _, err := svc.SendDue(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if n := rc.count(); n != 1 {
+		t.Errorf("receiver got %d requests, want 1", n)
+	}
+}
@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/outbox"
+)
+
+const (
+	maxEndpointsPerUser = 10
+	dueBatchSize        = 50
+)
+
+var (
+	ErrInvalidEndpoint  = errors.New("invalid webhook endpoint")
+	ErrTooManyEndpoints = errors.New("too many webhook endpoints")
+	ErrEndpointDisabled = errors.New("webhook endpoint disabled")
+)
+
+type Service struct {
+	repo   *Repository
+	client *http.Client
+
+	maxAttempts  int
+	retryDelay   time.Duration
+	disableAfter int
+
+	// allowAddr decide a qué direcciones se puede mandar; los tests lo abren para usar httptest.
+	allowAddr func(netip.Addr) bool
+}
+
+// timeout acota cada envío. Un envío se reintenta hasta maxAttempts veces, esperando retryDelay y
+// duplicando la espera en cada intento; el endpoint se deshabilita después de disableAfter
+// intentos fallidos seguidos, sumando todos sus envíos.
+func NewService(repo *Repository, timeout time.Duration, maxAttempts int, retryDelay time.Duration, disableAfter int) *Service {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	if disableAfter <= 0 {
+		disableAfter = 1
+	}
+	s := &Service{
+		repo:         repo,
+		maxAttempts:  maxAttempts,
+		retryDelay:   retryDelay,
+		disableAfter: disableAfter,
+		allowAddr:    publicAddr,
+	}
+	s.client = s.newClient(timeout)
+	return s
+}
+
+// Create da de alta el endpoint. El secreto queda en el endpoint devuelto: es la única vez que se
+// puede mostrar.
+func (s *Service) Create(ctx context.Context, userID uint, req *CreateEndpointRequest) (*Endpoint, error) {
+	u, err := normalizeURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkURL(ctx, u); err != nil {
+		return nil, err
+	}
+	events, err := normalizeEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := s.repo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxEndpointsPerUser {
+		return nil, ErrTooManyEndpoints
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	e := &Endpoint{UserID: userID, URL: u, Events: events, Secret: secret}
+	if err := s.repo.Create(ctx, e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uint) (*Endpoint, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *Service) List(ctx context.Context, userID uint) ([]*Endpoint, error) {
+	return s.repo.FindByUser(ctx, userID)
+}
+
+func (s *Service) Update(ctx context.Context, e *Endpoint, req *UpdateEndpointRequest) (*Endpoint, error) {
+	if req.URL != nil {
+		u, err := normalizeURL(*req.URL)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkURL(ctx, u); err != nil {
+			return nil, err
+		}
+		e.URL = u
+	}
+	if req.Events != nil {
+		events, err := normalizeEvents(*req.Events)
+		if err != nil {
+			return nil, err
+		}
+		e.Events = events
+	}
+	if req.Secret != nil {
+		e.Secret = *req.Secret
+	}
+
+	if req.Enabled != nil {
+		switch {
+		case *req.Enabled && !e.Enabled():
+			e.DisabledAt, e.DisabledReason, e.Failures = nil, "", 0
+		case !*req.Enabled && e.Enabled():
+			now := time.Now()
+			e.DisabledAt, e.DisabledReason = &now, "disabled by user"
+		}
+	}
+
+	if err := s.repo.Save(ctx, e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (s *Service) Delete(ctx context.Context, e *Endpoint) error {
+	return s.repo.Delete(ctx, e.ID)
+}
+
+func (s *Service) Deliveries(ctx context.Context, e *Endpoint, status string, limit int) ([]*Delivery, error) {
+	return s.repo.Deliveries(ctx, e.ID, status, limit)
+}
+
+// Redeliver vuelve a mandar un envío, haya salido bien o no, con los intentos en cero.
+func (s *Service) Redeliver(ctx context.Context, e *Endpoint, deliveryID uint) (*Delivery, error) {
+	if !e.Enabled() {
+		return nil, ErrEndpointDisabled
+	}
+
+	d, err := s.repo.FindDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if d.EndpointID != e.ID {
+		return nil, ErrDeliveryNotFound
+	}
+
+	now := time.Now()
+	if err := s.repo.Redeliver(ctx, d, now); err != nil {
+		return nil, err
+	}
+
+	d.Status, d.Attempts, d.NextAttemptAt = DeliveryPending, 0, now
+	return d, nil
+}
+
+// Enqueue es el suscriptor del outbox: crea un envío por cada endpoint habilitado del dueño del
+// evento que esté suscripto a su tipo. No manda nada, así una URL lenta no frena al dispatcher.
+func (s *Service) Enqueue(ctx context.Context, ev *outbox.Event) error {
+	endpoints, err := s.repo.Enabled(ctx, ev.UserID)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	for _, e := range endpoints {
+		if !e.Wants(ev.Type) {
+			continue
+		}
+
+		if body == nil {
+			if body, err = envelope(ev); err != nil {
+				return err
+			}
+		}
+
+		d := &Delivery{
+			EndpointID:    e.ID,
+			EventID:       ev.ID,
+			EventType:     ev.Type,
+			Payload:       string(body),
+			Status:        DeliveryPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := s.repo.Enqueue(ctx, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// envelope arma el body que recibe el endpoint: el evento con su payload en data.
+func envelope(ev *outbox.Event) ([]byte, error) {
+	return json.Marshal(struct {
+		ID        uint            `json:"id"`
+		Type      string          `json:"type"`
+		CreatedAt time.Time       `json:"createdAt"`
+		Data      json.RawMessage `json:"data"`
+	}{ev.ID, ev.Type, ev.CreatedAt.UTC(), json.RawMessage(ev.Payload)})
+}
+
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidEndpoint)
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("%w: url must not carry credentials", ErrInvalidEndpoint)
+	}
+
+	return u.String(), nil
+}
+
+// normalizeEvents valida los tipos y los deja ordenados y sin repetir, separados por coma.
+func normalizeEvents(events []string) (string, error) {
+	set := make(map[string]bool, len(events))
+	for _, t := range events {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !outbox.KnownType(t) {
+			return "", fmt.Errorf("%w: unknown event type %q", ErrInvalidEndpoint, t)
+		}
+		set[t] = true
+	}
+
+	out := make([]string, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+
+	return strings.Join(out, ","), nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
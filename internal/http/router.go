@@ -6,61 +6,117 @@ import (
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/sebaactis/wallet-go-api/internal/auth"
+	"github.com/sebaactis/wallet-go-api/internal/authz"
 	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/entities/schedule"
 	"github.com/sebaactis/wallet-go-api/internal/entities/token"
 	"github.com/sebaactis/wallet-go-api/internal/entities/user"
 	"github.com/sebaactis/wallet-go-api/internal/entities/wallet"
+	"github.com/sebaactis/wallet-go-api/internal/entities/webhook"
 	"github.com/sebaactis/wallet-go-api/internal/health"
 	"github.com/sebaactis/wallet-go-api/internal/httpmw"
 	"github.com/sebaactis/wallet-go-api/internal/validation"
 )
 
 type Deps struct {
-	UserHandler    *user.HTTPHandler
-	AccountHandler *account.HTTPHandler
-	WalletHandler  *wallet.HTTPHandler
-	Validator      *validation.Validator
-	RateLimiter    *httpmw.RateLimiter
-	AuthHandler    *auth.HTTPHandler
-	AuthMiddleWare *httpmw.AuthMiddleware
-	TokensHandler  *token.HTTPHandler
+	UserHandler     *user.HTTPHandler
+	AccountHandler  *account.HTTPHandler
+	WalletHandler   *wallet.HTTPHandler
+	Validator       *validation.Validator
+	RateLimiter     *httpmw.RateLimiter
+	AuthHandler     *auth.HTTPHandler
+	AuthMiddleWare  *httpmw.AuthMiddleware
+	TokensHandler   *token.HTTPHandler
+	ScheduleHandler *schedule.HTTPHandler
+	WebhookHandler  *webhook.HTTPHandler
+	Idempotency     *httpmw.Idempotency
 }
 
 func NewRouter(d Deps) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Use(chimw.RequestID, chimw.RealIP, chimw.Recoverer)
-	r.Use(httpmw.Logger(), httpmw.JSONContentType(), httpmw.Timeout(8*time.Second))
+	r.Use(httpmw.Logger(), httpmw.JSONContentType())
 
 	if d.RateLimiter != nil {
 		r.Use(d.RateLimiter.Middleware())
 	}
 
+	// Timeout usa http.TimeoutHandler, que guarda la respuesta entera antes de mandarla: las
+	// exportaciones, que van por streaming, se registran aparte y sin él.
+	timed := r.With(httpmw.Timeout(8 * time.Second))
+
 	hh := health.New()
-	r.Get("/health", hh.Liveness)
+	timed.Get("/health", hh.Liveness)
 
-	r.Route("/v1", func(r chi.Router) {
-		r.Get("/users", d.UserHandler.FindAll)
+	timed.Route("/v1", func(r chi.Router) {
 		r.Post("/register", d.UserHandler.Create)
 		r.Post("/login", d.AuthHandler.Login)
-		r.Post("/unlock", d.AuthHandler.UnlockUser)
+		r.Post("/login/2fa", d.AuthHandler.LoginMFA)
+		r.Post("/auth/refresh", d.AuthHandler.Refresh)
+		r.Post("/auth/logout", d.AuthHandler.Logout)
 		r.Get("/recoveryPassword", d.AuthHandler.RecoveryPasswordRequest)
 		r.Post("/updatePasswordRecovery", d.AuthHandler.UpdatePasswordByRecovery)
-		r.Get("/tokens", d.TokensHandler.GetAll)
 
 		// Rutas protegidas:
 		r.Group(func(pr chi.Router) {
 			pr.Use(d.AuthMiddleWare.RequireAuth())
+			if d.Idempotency != nil {
+				pr.Use(d.Idempotency.Middleware())
+			}
+
+			pr.Post("/auth/logout-all", d.AuthHandler.LogoutAll)
+			pr.Get("/me/sessions", d.AuthHandler.ListSessions)
+			pr.Delete("/me/sessions/{id}", d.AuthHandler.RevokeSession)
+			pr.Post("/me/2fa/setup", d.AuthHandler.SetupMFA)
+			pr.Post("/me/2fa/verify", d.AuthHandler.VerifyMFA)
 
 			pr.Get("/users/{id}", d.UserHandler.GetByID)
 			pr.Post("/accounts", d.AccountHandler.Create)
 			pr.Get("/accounts/{id}/balance", d.AccountHandler.GetBalance)
+			pr.Get("/accounts/{id}/transactions", d.WalletHandler.History)
 
 			pr.Post("/wallet/deposit", d.WalletHandler.Deposit)
 			pr.Post("/wallet/withdraw", d.WalletHandler.Withdraw)
 			pr.Post("/wallet/transfer", d.WalletHandler.Transfer)
+			pr.Post("/wallet/transactions/{id}/reverse", d.WalletHandler.Reverse)
+			pr.Post("/wallet/holds", d.WalletHandler.CreateHold)
+			pr.Get("/wallet/holds/{id}", d.WalletHandler.GetHold)
+			pr.Post("/wallet/holds/{id}/capture", d.WalletHandler.CaptureHold)
+			pr.Post("/wallet/holds/{id}/void", d.WalletHandler.VoidHold)
+
+			pr.Post("/fx/quotes", d.WalletHandler.CreateQuote)
+			pr.Get("/fx/quotes/{id}", d.WalletHandler.GetQuote)
+
+			pr.Post("/wallet/schedules", d.ScheduleHandler.Create)
+			pr.Get("/wallet/schedules", d.ScheduleHandler.List)
+			pr.Get("/wallet/schedules/{id}", d.ScheduleHandler.Get)
+			pr.Patch("/wallet/schedules/{id}", d.ScheduleHandler.Update)
+			pr.Delete("/wallet/schedules/{id}", d.ScheduleHandler.Cancel)
+
+			pr.Post("/webhooks", d.WebhookHandler.Create)
+			pr.Get("/webhooks", d.WebhookHandler.List)
+			pr.Get("/webhooks/{id}", d.WebhookHandler.Get)
+			pr.Patch("/webhooks/{id}", d.WebhookHandler.Update)
+			pr.Delete("/webhooks/{id}", d.WebhookHandler.Delete)
+			pr.Get("/webhooks/{id}/deliveries", d.WebhookHandler.Deliveries)
+			pr.Post("/webhooks/{id}/deliveries/{deliveryId}/redeliver", d.WebhookHandler.Redeliver)
+
+			// Administración: solo staff.
+			pr.With(httpmw.RequireRole(authz.RoleSupport, authz.RoleAdmin)).Get("/users", d.UserHandler.FindAll)
+			pr.With(httpmw.RequireRole(authz.RoleSupport, authz.RoleAdmin)).Post("/unlock", d.AuthHandler.UnlockUser)
+			pr.With(httpmw.RequireRole(authz.RoleAdmin)).Get("/tokens", d.TokensHandler.GetAll)
+			pr.With(httpmw.RequireRole(authz.RoleAdmin)).Post("/reconciliation", d.WalletHandler.Reconcile)
+			pr.With(httpmw.RequireRole(authz.RoleAdmin)).Post("/accounts/{id}/unfreeze", d.WalletHandler.Unfreeze)
 		})
 	})
 
+	r.Group(func(sr chi.Router) {
+		sr.Use(d.AuthMiddleWare.RequireAuth())
+
+		sr.Get("/v1/accounts/{id}/statements", d.WalletHandler.Statement)
+		sr.Get("/v1/accounts/{id}/statements/{month}.pdf", d.WalletHandler.MonthlyStatement)
+	})
+
 	return r
 }
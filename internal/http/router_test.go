@@ -0,0 +1,404 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sebaactis/wallet-go-api/internal/auth"
+	"github.com/sebaactis/wallet-go-api/internal/authz"
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	"github.com/sebaactis/wallet-go-api/internal/entities/idempotency"
+	"github.com/sebaactis/wallet-go-api/internal/entities/mfa"
+	"github.com/sebaactis/wallet-go-api/internal/entities/schedule"
+	"github.com/sebaactis/wallet-go-api/internal/entities/session"
+	"github.com/sebaactis/wallet-go-api/internal/entities/token"
+	"github.com/sebaactis/wallet-go-api/internal/entities/user"
+	"github.com/sebaactis/wallet-go-api/internal/entities/wallet"
+	"github.com/sebaactis/wallet-go-api/internal/entities/webhook"
+	"github.com/sebaactis/wallet-go-api/internal/httpmw"
+	"github.com/sebaactis/wallet-go-api/internal/platform/config"
+	"github.com/sebaactis/wallet-go-api/internal/platform/database"
+	"github.com/sebaactis/wallet-go-api/internal/platform/filestore"
+	"github.com/sebaactis/wallet-go-api/internal/validation"
+	"gorm.io/gorm"
+)
+
+// testServer es el router real, armado como en cmd/api, sobre una base SQLite temporal.
+type testServer struct {
+	t       *testing.T
+	db      *gorm.DB
+	handler http.Handler
+	jwt     *auth.JWT
+	tokens  *token.Service
+	users   map[string]*user.User
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "api.db") + "?_pragma=foreign_keys(ON)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	db, err := database.Open(config.Config{Driver: "sqlite", DSN: dsn, MaxOpenConns: 4, MaxIdleConns: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	fileStore, err := filestore.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validator := validation.NewValidator()
+	jwt := auth.NewJWT()
+
+	userRepo := user.NewRepository(db)
+	accountRepo := account.NewRepository(db)
+
+	walletService := wallet.NewService(db, nil, 0, time.Minute)
+	tokenService := token.NewService(token.NewRepository(db), validator)
+	sessionService := session.NewService(session.NewRepository(db))
+	userService := user.NewService(userRepo, tokenService, validator)
+	scheduleService := schedule.NewService(schedule.NewRepository(db), walletService, accountRepo, 3, time.Minute)
+	webhookService := webhook.NewService(webhook.NewRepository(db), time.Second, 3, time.Minute, 10)
+
+	handler := NewRouter(Deps{
+		UserHandler:     user.NewHTTPHandler(userService),
+		AccountHandler:  account.NewHTTPHandler(account.NewService(accountRepo)),
+		WalletHandler:   wallet.NewHTTPHandler(walletService, wallet.NewStatementArchive(walletService, fileStore), accountRepo, validator),
+		Validator:       validator,
+		AuthHandler:     auth.NewHTTPHandler(userService, tokenService, sessionService, mfa.NewService(mfa.NewRepository(db)), jwt, validator),
+		AuthMiddleWare:  httpmw.NewAuthMiddleware(jwt, userService, tokenService, sessionService),
+		TokensHandler:   token.NewHTTPHandler(tokenService),
+		ScheduleHandler: schedule.NewHTTPHandler(scheduleService, accountRepo, validator),
+		WebhookHandler:  webhook.NewHTTPHandler(webhookService, validator),
+		Idempotency:     httpmw.NewIdempotency(idempotency.NewService(idempotency.NewRepository(db), time.Hour)),
+	})
+
+	s := &testServer{t: t, db: db, handler: handler, jwt: jwt, tokens: tokenService, users: map[string]*user.User{}}
+	for _, role := range []authz.Role{authz.RoleUser, authz.RoleSupport, authz.RoleAdmin} {
+		s.addUser(string(role), role)
+	}
+	return s
+}
+
+func (s *testServer) addUser(name string, role authz.Role) *user.User {
+	s.t.Helper()
+
+	u := &user.User{Name: name, Email: name + "@wallet.test", Password: "!", Role: role}
+	if err := s.db.Create(u).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	s.users[name] = u
+	return u
+}
+
+// accessCookie emite un access token para el usuario y lo registra, como hace el login.
+func (s *testServer) accessCookie(name string) *http.Cookie {
+	s.t.Helper()
+
+	u := s.users[name]
+	signed, err := s.jwt.Issue(u.ID, u.Email, u.Role, auth.TokenTypeAccess, "")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if _, err := s.tokens.Create(context.Background(), &token.TokenRequest{
+		UserID:    u.ID,
+		TokenType: string(auth.TokenTypeAccess),
+		JTI:       signed.ID,
+		ExpiresAt: signed.ExpiresAt,
+	}); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return &http.Cookie{Name: "accessToken", Value: signed.Token}
+}
+
+// do manda el request como el usuario indicado; con as == "" va sin sesión.
+func (s *testServer) do(as, method, path, body string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if as != "" {
+		req.AddCookie(s.accessCookie(as))
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// Las rutas de administración rechazan a un usuario común con 403 y dejan pasar solo a los roles
+// de staff que corresponden.
+func TestAdminRoutesRequireStaff(t *testing.T) {
+	s := newTestServer(t)
+
+	acc := &account.Account{UserID: s.users["user"].ID, Currency: "USD"}
+	if err := s.db.Create(acc).Error; err != nil {
+		t.Fatal(err)
+	}
+	unfreeze := "/v1/accounts/" + itoa(acc.ID) + "/unfreeze"
+
+	tests := []struct {
+		method, path, body string
+		support            bool
+	}{
+		{http.MethodGet, "/v1/users", "", true},
+		{http.MethodPost, "/v1/unlock", `{"userId":1}`, true},
+		{http.MethodGet, "/v1/tokens", "", false},
+		{http.MethodPost, "/v1/reconciliation", "", false},
+		{http.MethodPost, unfreeze, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if rec := s.do("", tt.method, tt.path, tt.body); rec.Code != http.StatusUnauthorized {
+				t.Errorf("anonymous: status %d, want 401", rec.Code)
+			}
+			if rec := s.do("user", tt.method, tt.path, tt.body); rec.Code != http.StatusForbidden {
+				t.Errorf("user: status %d, want 403", rec.Code)
+			}
+
+			rec := s.do("support", tt.method, tt.path, tt.body)
+			if tt.support && denied(rec.Code) {
+				t.Errorf("support: status %d, want access", rec.Code)
+			}
+			if !tt.support && rec.Code != http.StatusForbidden {
+				t.Errorf("support: status %d, want 403", rec.Code)
+			}
+
+			if rec := s.do("admin", tt.method, tt.path, tt.body); denied(rec.Code) {
+				t.Errorf("admin: status %d, want access (%s)", rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func denied(code int) bool {
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+func itoa(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+const (
+	allow  = 0 // cualquier respuesta que no sea 401/403: la autorización dejó pasar
+	unauth = http.StatusUnauthorized
+	forbid = http.StatusForbidden
+	hidden = http.StatusNotFound // el recurso de otro se responde como inexistente
+)
+
+// fixtures son recursos del usuario "user" (y una cuenta de "other") para pegarle a cada ruta.
+type fixtures struct {
+	account, otherAccount, transfer, capture, void, quote, schedule, webhook, delivery uint
+}
+
+func (s *testServer) fixtures() fixtures {
+	s.t.Helper()
+
+	var f fixtures
+	acc := &account.Account{UserID: s.users["user"].ID, Currency: "USD"}
+	other := &account.Account{UserID: s.users["other"].ID, Currency: "USD"}
+	for _, a := range []*account.Account{acc, other} {
+		if err := s.db.Create(a).Error; err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	f.account, f.otherAccount = acc.ID, other.ID
+
+	post := func(path, body string) map[string]any {
+		s.t.Helper()
+
+		rec := s.do("user", http.MethodPost, path, body)
+		if rec.Code >= 300 {
+			s.t.Fatalf("POST %s: %d %s", path, rec.Code, rec.Body)
+		}
+		var out map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			s.t.Fatalf("POST %s: %v", path, err)
+		}
+		return out
+	}
+	id := func(v any) uint { return uint(v.(float64)) }
+
+	post("/v1/wallet/deposit", fmt.Sprintf(`{"accountId":%d,"amount":"100","currency":"USD"}`, f.account))
+	f.transfer = id(post("/v1/wallet/transfer", fmt.Sprintf(`{"fromAccountId":%d,"toAccountId":%d,"amount":"10","currency":"USD"}`, f.account, f.otherAccount))["transactionId"])
+
+	hold := fmt.Sprintf(`{"fromAccountId":%d,"toAccountId":%d,"amount":"5","currency":"USD"}`, f.account, f.otherAccount)
+	f.capture = id(post("/v1/wallet/holds", hold)["holdId"])
+	f.void = id(post("/v1/wallet/holds", hold)["holdId"])
+
+	runAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	f.schedule = id(post("/v1/wallet/schedules", fmt.Sprintf(`{"fromAccountId":%d,"toAccountId":%d,"amount":"1","currency":"USD","runAt":%q}`, f.account, f.otherAccount, runAt))["id"])
+
+	quote := &wallet.FXQuote{UserID: s.users["user"].ID, FromCurrency: "USD", ToCurrency: "EUR", Amount: 100, ConvertedAmount: 90,
+		MidRate: "0.9", Rate: "0.9", ExpiresAt: time.Now().Add(time.Hour)}
+	endpoint := &webhook.Endpoint{UserID: s.users["user"].ID, URL: "https://hooks.wallet.test/in", Secret: "0123456789abcdef"}
+	for _, v := range []any{quote, endpoint} {
+		if err := s.db.Create(v).Error; err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	delivery := &webhook.Delivery{EndpointID: endpoint.ID, EventID: 1, EventType: "transaction.created", Payload: "{}",
+		Status: webhook.DeliveryFailed, NextAttemptAt: time.Now()}
+	if err := s.db.Create(delivery).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	f.quote, f.webhook, f.delivery = quote.ID, endpoint.ID, delivery.ID
+
+	return f
+}
+
+// Cada ruta del router con el resultado esperado para quien la llama: sin sesión, el dueño de los
+// recursos, otro usuario, soporte y admin. Las rutas públicas tienen que responder sin sesión (sin
+// 403 ni 404). Una ruta nueva sin fila acá hace fallar el test.
+func TestRouteAccess(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("other", authz.RoleUser)
+	f := s.fixtures()
+
+	id := func(format string, ids ...uint) string {
+		args := make([]any, len(ids))
+		for i, v := range ids {
+			args[i] = v
+		}
+		return fmt.Sprintf(format, args...)
+	}
+	mine := fmt.Sprintf(`{"accountId":%d,"amount":"1","currency":"USD"}`, f.account)
+	fromMine := fmt.Sprintf(`{"fromAccountId":%d,"toAccountId":%d,"amount":"1","currency":"USD"}`, f.account, f.otherAccount)
+	schedule := fmt.Sprintf(`{"fromAccountId":%d,"toAccountId":%d,"amount":"1","currency":"USD","runAt":%q}`,
+		f.account, f.otherAccount, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	month := time.Now().AddDate(0, -1, 0).Format("2006-01")
+
+	tests := []struct {
+		method, pattern, path, body       string
+		public                            bool
+		anon, user, other, support, admin int
+	}{
+		{method: "GET", pattern: "/health", public: true},
+		{method: "POST", pattern: "/v1/register", public: true},
+		{method: "POST", pattern: "/v1/login", public: true},
+		{method: "POST", pattern: "/v1/login/2fa", public: true},
+		{method: "POST", pattern: "/v1/auth/refresh", public: true},
+		{method: "POST", pattern: "/v1/auth/logout", public: true},
+		{method: "GET", pattern: "/v1/recoveryPassword", public: true},
+		{method: "POST", pattern: "/v1/updatePasswordRecovery", public: true},
+
+		// Sobre la propia sesión: cualquiera autenticado.
+		{"POST", "/v1/auth/logout-all", "", "", false, unauth, allow, allow, allow, allow},
+		{"GET", "/v1/me/sessions", "", "", false, unauth, allow, allow, allow, allow},
+		{"DELETE", "/v1/me/sessions/{id}", "/v1/me/sessions/999", "", false, unauth, allow, allow, allow, allow},
+		{"POST", "/v1/me/2fa/setup", "", "", false, unauth, allow, allow, allow, allow},
+		{"POST", "/v1/me/2fa/verify", "", `{"code":"000000"}`, false, unauth, allow, allow, allow, allow},
+
+		{"GET", "/v1/users/{id}", id("/v1/users/%d", s.users["user"].ID), "", false, unauth, allow, forbid, allow, allow},
+		{"POST", "/v1/accounts", "", id(`{"userId":%d,"currency":"EUR"}`, s.users["user"].ID), false, unauth, allow, forbid, forbid, forbid},
+		{"GET", "/v1/accounts/{id}/balance", id("/v1/accounts/%d/balance", f.account), "", false, unauth, allow, forbid, allow, allow},
+		{"GET", "/v1/accounts/{id}/transactions", id("/v1/accounts/%d/transactions", f.account), "", false, unauth, allow, forbid, allow, allow},
+		{"GET", "/v1/accounts/{id}/statements", id("/v1/accounts/%d/statements?format=csv", f.account), "", false, unauth, allow, forbid, allow, allow},
+		{"GET", "/v1/accounts/{id}/statements/{month}.pdf", id("/v1/accounts/%d/statements/", f.account) + month + ".pdf", "", false, unauth, allow, forbid, allow, allow},
+
+		// Mover fondos es solo del dueño: ni soporte ni admin.
+		{"POST", "/v1/wallet/deposit", "", mine, false, unauth, allow, forbid, forbid, forbid},
+		{"POST", "/v1/wallet/withdraw", "", mine, false, unauth, allow, forbid, forbid, forbid},
+		{"POST", "/v1/wallet/transfer", "", fromMine, false, unauth, allow, forbid, forbid, forbid},
+		{"POST", "/v1/wallet/holds", "", fromMine, false, unauth, allow, forbid, forbid, forbid},
+		{"GET", "/v1/wallet/holds/{id}", id("/v1/wallet/holds/%d", f.capture), "", false, unauth, allow, forbid, allow, allow},
+		{"POST", "/v1/wallet/holds/{id}/capture", id("/v1/wallet/holds/%d/capture", f.capture), "", false, unauth, allow, forbid, forbid, forbid},
+		{"POST", "/v1/wallet/holds/{id}/void", id("/v1/wallet/holds/%d/void", f.void), "", false, unauth, allow, forbid, forbid, forbid},
+		// La reversa la hace quien recibió la plata (other) o un admin.
+		{"POST", "/v1/wallet/transactions/{id}/reverse", id("/v1/wallet/transactions/%d/reverse", f.transfer), `{"amount":"1"}`, false, unauth, forbid, allow, forbid, allow},
+
+		{"POST", "/v1/fx/quotes", "", `{"fromCurrency":"USD","toCurrency":"EUR","amount":"1"}`, false, unauth, allow, allow, allow, allow},
+		{"GET", "/v1/fx/quotes/{id}", id("/v1/fx/quotes/%d", f.quote), "", false, unauth, allow, hidden, allow, allow},
+
+		{"POST", "/v1/wallet/schedules", "", schedule, false, unauth, allow, forbid, forbid, forbid},
+		{"GET", "/v1/wallet/schedules", "", "", false, unauth, allow, allow, allow, allow},
+		{"GET", "/v1/wallet/schedules/{id}", id("/v1/wallet/schedules/%d", f.schedule), "", false, unauth, allow, forbid, allow, allow},
+		{"PATCH", "/v1/wallet/schedules/{id}", id("/v1/wallet/schedules/%d", f.schedule), `{"amount":"2"}`, false, unauth, allow, forbid, forbid, forbid},
+		{"DELETE", "/v1/wallet/schedules/{id}", id("/v1/wallet/schedules/%d", f.schedule), "", false, unauth, allow, forbid, forbid, forbid},
+
+		// Los webhooks llevan el secreto del usuario: solo el dueño.
+		{"POST", "/v1/webhooks", "", `{"url":"https://203.0.113.10/new"}`, false, unauth, allow, allow, allow, allow},
+		{"GET", "/v1/webhooks", "", "", false, unauth, allow, allow, allow, allow},
+		{"GET", "/v1/webhooks/{id}", id("/v1/webhooks/%d", f.webhook), "", false, unauth, allow, forbid, forbid, forbid},
+		{"PATCH", "/v1/webhooks/{id}", id("/v1/webhooks/%d", f.webhook), `{"enabled":true}`, false, unauth, allow, forbid, forbid, forbid},
+		{"GET", "/v1/webhooks/{id}/deliveries", id("/v1/webhooks/%d/deliveries", f.webhook), "", false, unauth, allow, forbid, forbid, forbid},
+		{"POST", "/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver", id("/v1/webhooks/%d/deliveries/%d/redeliver", f.webhook, f.delivery), "", false, unauth, allow, forbid, forbid, forbid},
+		{"DELETE", "/v1/webhooks/{id}", id("/v1/webhooks/%d", f.webhook), "", false, unauth, allow, forbid, forbid, forbid},
+
+		// Administración.
+		{"GET", "/v1/users", "", "", false, unauth, forbid, forbid, allow, allow},
+		{"POST", "/v1/unlock", "", `{"userId":1}`, false, unauth, forbid, forbid, allow, allow},
+		{"GET", "/v1/tokens", "", "", false, unauth, forbid, forbid, forbid, allow},
+		{"POST", "/v1/reconciliation", "", "", false, unauth, forbid, forbid, forbid, allow},
+		{"POST", "/v1/accounts/{id}/unfreeze", id("/v1/accounts/%d/unfreeze", f.account), "", false, unauth, forbid, forbid, forbid, allow},
+	}
+
+	covered := map[string]bool{}
+	for _, tt := range tests {
+		route := tt.method + " " + tt.pattern
+		covered[route] = true
+
+		path := tt.path
+		if path == "" {
+			path = tt.pattern
+		}
+
+		t.Run(route, func(t *testing.T) {
+			if tt.public {
+				rec := s.do("", tt.method, path, tt.body)
+				if rec.Code == http.StatusForbidden || rec.Code == http.StatusNotFound || rec.Code == http.StatusMethodNotAllowed {
+					t.Errorf("anonymous: status %d on a public route", rec.Code)
+				}
+				return
+			}
+
+			// El dueño va último: las operaciones que cambian estado no afectan a los demás casos.
+			for _, c := range []struct {
+				as   string
+				want int
+			}{
+				{"", tt.anon}, {"other", tt.other}, {"support", tt.support}, {"admin", tt.admin}, {"user", tt.user},
+			} {
+				rec := s.do(c.as, tt.method, path, tt.body)
+
+				who := c.as
+				if who == "" {
+					who = "anonymous"
+				}
+				switch {
+				case c.want == allow && denied(rec.Code):
+					t.Errorf("%s: status %d, want access (%s)", who, rec.Code, strings.TrimSpace(rec.Body.String()))
+				case c.want != allow && rec.Code != c.want:
+					t.Errorf("%s: status %d, want %d", who, rec.Code, c.want)
+				}
+			}
+		})
+	}
+
+	err := chi.Walk(s.handler.(*chi.Mux), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !covered[method+" "+route] {
+			t.Errorf("route %s %s has no access row", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,148 @@
+package httpmw
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/sebaactis/wallet-go-api/internal/auth"
+	"github.com/sebaactis/wallet-go-api/internal/entities/idempotency"
+	"github.com/sebaactis/wallet-go-api/internal/httputil"
+)
+
+const (
+	maxIdempotentBody   = 1 << 20
+	maxIdempotencyKey   = 255
+	idempotencyHeader   = "Idempotency-Key"
+	idempotentReplayHdr = "Idempotent-Replayed"
+)
+
+type Idempotency struct {
+	service *idempotency.Service
+}
+
+func NewIdempotency(service *idempotency.Service) *Idempotency {
+	return &Idempotency{service: service}
+}
+
+// Middleware hace idempotentes las requests que mutan y traen Idempotency-Key. La clave vale por
+// usuario, método y ruta; la primera respuesta se guarda y se repite tal cual en los reintentos.
+// Un reintento con otro body da 422 y uno que llega mientras la original sigue en curso, 409. Las
+// respuestas 5xx no se guardan: la clave se libera para poder reintentar. Va después de
+// RequireAuth, que es quien deja el usuario en el contexto.
+func (m *Idempotency) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyHeader)
+			if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userID, ok := auth.UserIDFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKey {
+				httputil.WriteError(w, http.StatusBadRequest, "idempotency key too long", nil)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil {
+				httputil.WriteError(w, http.StatusBadRequest, "cannot read body", nil)
+				return
+			}
+			if len(body) > maxIdempotentBody {
+				httputil.WriteError(w, http.StatusRequestEntityTooLarge, "request body too large", nil)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.Sum256(body)
+
+			rec, owner, err := m.service.Begin(r.Context(), userID, r.Method, r.URL.Path, key, hex.EncodeToString(sum[:]))
+			switch {
+			case errors.Is(err, idempotency.ErrFingerprintMismatch):
+				httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error(), nil)
+				return
+			case errors.Is(err, idempotency.ErrInFlight):
+				w.Header().Set("Retry-After", "1")
+				httputil.WriteError(w, http.StatusConflict, err.Error(), nil)
+				return
+			case err != nil:
+				httputil.WriteError(w, http.StatusInternalServerError, "internal", nil)
+				return
+			}
+
+			if !owner {
+				w.Header().Set(idempotentReplayHdr, "true")
+				w.WriteHeader(rec.StatusCode)
+				_, _ = w.Write(rec.Response)
+				return
+			}
+
+			rw := &recordingWriter{ResponseWriter: w}
+
+			// Si el handler entra en pánico la clave se libera antes de que Recoverer responda.
+			defer func() {
+				if p := recover(); p != nil {
+					m.release(r, rec)
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+
+			status := rw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			if status >= http.StatusInternalServerError {
+				m.release(r, rec)
+				return
+			}
+
+			// La operación ya se hizo: si el request se canceló igual hay que guardar la respuesta.
+			if err := m.service.Complete(context.WithoutCancel(r.Context()), rec, status, rw.body.Bytes()); err != nil {
+				log.Printf("idempotency: key %s of user %d: %v", key, userID, err)
+			}
+		})
+	}
+}
+
+func (m *Idempotency) release(r *http.Request, rec *idempotency.Record) {
+	if err := m.service.Release(context.WithoutCancel(r.Context()), rec); err != nil {
+		log.Printf("idempotency: release key %s: %v", rec.Key, err)
+	}
+}
+
+// recordingWriter pasa la respuesta al cliente y se queda con una copia.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,34 @@
+package httpmw
+
+import (
+	"net/http"
+
+	"github.com/sebaactis/wallet-go-api/internal/authz"
+	"github.com/sebaactis/wallet-go-api/internal/httputil"
+)
+
+// RequireRole deja pasar solo a los roles indicados. Va siempre después de RequireAuth, que es
+// quien carga el rol del token en el contexto.
+func RequireRole(roles ...authz.Role) func(http.Handler) http.Handler {
+	allowed := make(map[authz.Role]bool, len(roles))
+	for _, r := range roles {
+		allowed[r] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject, ok := authz.SubjectFromContext(r.Context())
+			if !ok {
+				httputil.WriteError(w, http.StatusUnauthorized, "unauthorized", nil)
+				return
+			}
+
+			if !allowed[subject.Role] {
+				httputil.WriteError(w, http.StatusForbidden, "forbidden", nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// Decimal es un monto tal como viaja en JSON: string decimal ("10.50").
+// Se aceptan también números JSON sin decodificarlos como float64.
+type Decimal string
+
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*d = Decimal(s)
+		return nil
+	}
+
+	if bytes.Equal(b, []byte("null")) {
+		*d = ""
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return errors.New("amount must be a decimal string")
+	}
+	*d = Decimal(n.String())
+	return nil
+}
+
+func (d Decimal) Money(currency string) (Money, error) {
+	return Parse(string(d), currency)
+}
+
+type moneyJSON struct {
+	Amount   Decimal `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(b []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	parsed, err := raw.Amount.Money(raw.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
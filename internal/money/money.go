@@ -0,0 +1,181 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrTooManyDecimals  = errors.New("amount has more decimals than the currency allows")
+	ErrOverflow         = errors.New("amount overflow")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money es un monto en unidades menores (centavos, yenes, fils...) de una moneda ISO 4217.
+type Money struct {
+	Amount   int64  `json:"-"`
+	Currency string `json:"-"`
+}
+
+// Exponentes ISO 4217 distintos de 2. Cualquier otra moneda usa 2 decimales.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+func Exponent(currency string) int {
+	if e, ok := exponents[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return 2
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Parse convierte un decimal ("10.50", "-3", "0.125") a unidades menores de la moneda
+// sin pasar por float64.
+func Parse(s, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	s = strings.TrimSpace(s)
+	exp := Exponent(currency)
+
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return Money{}, ErrInvalidAmount
+	}
+	if hasDot && fracPart == "" {
+		return Money{}, ErrInvalidAmount
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	if len(fracPart) > exp {
+		if strings.Trim(fracPart[exp:], "0") != "" {
+			return Money{}, ErrTooManyDecimals
+		}
+		fracPart = fracPart[:exp]
+	}
+	fracPart += strings.Repeat("0", exp-len(fracPart))
+
+	digits := strings.TrimLeft(intPart+fracPart, "0")
+	if digits == "" {
+		return Money{Amount: 0, Currency: currency}, nil
+	}
+
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrOverflow
+	}
+	if neg {
+		n = -n
+	}
+
+	return Money{Amount: n, Currency: currency}, nil
+}
+
+// FromFloat solo existe para convertir datos legados guardados como float64.
+func FromFloat(f float64, currency string) (Money, error) {
+	scaled := math.Round(f * math.Pow10(Exponent(currency)))
+	if math.IsNaN(scaled) || scaled > math.MaxInt64 || scaled < math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return New(int64(scaled), currency), nil
+}
+
+func (m Money) String() string {
+	return Format(m.Amount, m.Currency)
+}
+
+// Format renderiza unidades menores como decimal con los dígitos que corresponden a la moneda.
+func Format(amount int64, currency string) string {
+	exp := Exponent(currency)
+
+	sign := ""
+	u := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		u = uint64(-(amount + 1)) + 1
+	}
+
+	s := strconv.FormatUint(u, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+func (m Money) Decimal() Decimal { return Decimal(m.String()) }
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+func (m Money) Neg() Money { return Money{Amount: -m.Amount, Currency: m.Currency} }
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum, err := AddInt64(m.Amount, o.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+// Cmp devuelve -1, 0 o 1. Ambas monedas deben coincidir.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// AddInt64 suma unidades menores detectando overflow.
+func AddInt64(a, b int64) (int64, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, ErrOverflow
+	}
+	return sum, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
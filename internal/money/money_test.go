@@ -0,0 +1,163 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     int64
+		err      error
+	}{
+		// 2 decimales
+		{"10.50", "USD", 1050, nil},
+		{"10.5", "USD", 1050, nil},
+		{"10", "usd", 1000, nil},
+		{"0.01", "USD", 1, nil},
+		{".5", "USD", 50, nil},
+		{"-3.25", "USD", -325, nil},
+		{"+3.25", "USD", 325, nil},
+		{" 7.00 ", "USD", 700, nil},
+		{"1.2300", "USD", 123, nil},
+		{"000.10", "USD", 10, nil},
+		{"0", "USD", 0, nil},
+		{"1.005", "USD", 0, ErrTooManyDecimals},
+		{"92233720368547758.07", "USD", math.MaxInt64, nil},
+		{"92233720368547758.08", "USD", 0, ErrOverflow},
+
+		// 0 decimales
+		{"1500", "JPY", 1500, nil},
+		{"1500.0", "JPY", 1500, nil},
+		{"1500.5", "JPY", 0, ErrTooManyDecimals},
+		{"-7", "CLP", -7, nil},
+
+		// 3 decimales
+		{"1.234", "KWD", 1234, nil},
+		{"1.2", "KWD", 1200, nil},
+		{"0.001", "BHD", 1, nil},
+		{"1.2345", "KWD", 0, ErrTooManyDecimals},
+		{"1.2340", "KWD", 1234, nil},
+
+		// formato
+		{"", "USD", 0, ErrInvalidAmount},
+		{".", "USD", 0, ErrInvalidAmount},
+		{"1.", "USD", 0, ErrInvalidAmount},
+		{"-", "USD", 0, ErrInvalidAmount},
+		{"1,50", "USD", 0, ErrInvalidAmount},
+		{"1e3", "USD", 0, ErrInvalidAmount},
+		{"abc", "USD", 0, ErrInvalidAmount},
+		{"1.2.3", "USD", 0, ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.currency+" "+tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in, tt.currency)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse(%q, %s) error = %v, want %v", tt.in, tt.currency, err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if got.Amount != tt.want {
+				t.Errorf("Parse(%q, %s) = %d, want %d", tt.in, tt.currency, got.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{1050, "USD", "10.50"},
+		{1, "USD", "0.01"},
+		{0, "USD", "0.00"},
+		{-325, "USD", "-3.25"},
+		{-5, "EUR", "-0.05"},
+		{math.MaxInt64, "USD", "92233720368547758.07"},
+		{math.MinInt64, "USD", "-92233720368547758.08"},
+
+		{1500, "JPY", "1500"},
+		{0, "JPY", "0"},
+		{-7, "CLP", "-7"},
+
+		{1234, "KWD", "1.234"},
+		{1, "BHD", "0.001"},
+		{-1200, "KWD", "-1.200"},
+	}
+
+	for _, tt := range tests {
+		if got := Format(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("Format(%d, %s) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+// Format y Parse tienen que ser inversas para cualquier monto y exponente.
+func TestFormatParseRoundTrip(t *testing.T) {
+	amounts := []int64{0, 1, -1, 99, 100, 12345, -987654321, math.MaxInt64, math.MinInt64 + 1}
+
+	for _, currency := range []string{"JPY", "USD", "KWD", "CLF"} {
+		for _, a := range amounts {
+			s := Format(a, currency)
+			got, err := Parse(s, currency)
+			if err != nil {
+				t.Fatalf("Parse(Format(%d, %s) = %q): %v", a, currency, s, err)
+			}
+			if got.Amount != a {
+				t.Errorf("round trip %s %d -> %q -> %d", currency, a, s, got.Amount)
+			}
+		}
+	}
+}
+
+// FromFloat es la conversión de la migración de montos legados: redondea al entero más cercano.
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		in       float64
+		currency string
+		want     int64
+	}{
+		{10.5, "USD", 1050},
+		{0.1 + 0.2, "USD", 30},
+		{19.999, "USD", 2000},
+		{-2.675, "USD", -268},
+		{1500.4, "JPY", 1500},
+		{1.2345, "KWD", 1235},
+	}
+
+	for _, tt := range tests {
+		got, err := FromFloat(tt.in, tt.currency)
+		if err != nil {
+			t.Fatalf("FromFloat(%v, %s): %v", tt.in, tt.currency, err)
+		}
+		if got.Amount != tt.want {
+			t.Errorf("FromFloat(%v, %s) = %d, want %d", tt.in, tt.currency, got.Amount, tt.want)
+		}
+	}
+
+	if _, err := FromFloat(math.NaN(), "USD"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("FromFloat(NaN) error = %v, want ErrOverflow", err)
+	}
+	if _, err := FromFloat(1e18, "USD"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("FromFloat(1e18) error = %v, want ErrOverflow", err)
+	}
+}
+
+func TestAddInt64(t *testing.T) {
+	if got, err := AddInt64(math.MaxInt64-1, 1); err != nil || got != math.MaxInt64 {
+		t.Errorf("AddInt64 near max = %d, %v", got, err)
+	}
+	if _, err := AddInt64(math.MaxInt64, 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("AddInt64 overflow error = %v", err)
+	}
+	if _, err := AddInt64(math.MinInt64, -1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("AddInt64 underflow error = %v", err)
+	}
+}
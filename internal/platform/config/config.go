@@ -1,15 +1,80 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"time"
+)
 
 type Config struct {
 	HTTPAddr string
 	Driver   string
 	DSN      string
+
+	// Pool de conexiones (0 = valor por defecto de database/sql)
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Cada cuánto se liberan los holds vencidos
+	HoldSweepInterval time.Duration
+
+	// Transferencias programadas: cada cuánto se buscan ocurrencias vencidas y cómo se reintenta
+	// cuando no hay fondos (espera inicial, se duplica en cada intento)
+	SchedulerInterval   time.Duration
+	ScheduleMaxAttempts int
+	ScheduleRetryDelay  time.Duration
+
+	// Conversión entre monedas: si FXRatesFile está vacío los tipos salen de la tabla fx_rates.
+	// El spread va en puntos básicos sobre el tipo medio.
+	FXRatesFile string
+	FXSpreadBps int
+	FXQuoteTTL  time.Duration
+
+	// Idempotency-Key: cuánto se guarda cada clave con su respuesta y cada cuánto se purgan las vencidas
+	IdempotencyTTL           time.Duration
+	IdempotencyPurgeInterval time.Duration
+
+	// Outbox de eventos: cada cuánto se despacha, cuántos intentos tiene cada evento antes de
+	// pasar a dead y la espera inicial entre intentos (se duplica en cada uno)
+	OutboxInterval    time.Duration
+	OutboxMaxAttempts int
+	OutboxRetryDelay  time.Duration
+
+	// Webhooks: cada cuánto se mandan los pendientes, el timeout de cada envío, los intentos por
+	// envío con su espera inicial (se duplica en cada uno) y cuántas fallas seguidas deshabilitan
+	// un endpoint
+	WebhookInterval     time.Duration
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookRetryDelay   time.Duration
+	WebhookDisableAfter int
+
+	// Archivos generados (extractos mensuales en PDF) y cada cuánto se revisa si quedan extractos
+	// del mes anterior por generar
+	FileStoreDir              string
+	MonthlyStatementsInterval time.Duration
 }
 
 func getEnv(key, def string) string {
-	if v := os.Getenv(key); v != "" { return v }
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func getEnvInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n >= 0 {
+		return n
+	}
+	return def
+}
+
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d >= 0 {
+		return d
+	}
 	return def
 }
 
@@ -18,5 +83,36 @@ func Load() Config {
 		HTTPAddr: getEnv("HTTP_ADDR", ":8080"),
 		Driver:   getEnv("DB_DRIVER", "sqlite"),
 		DSN:      getEnv("DB_DSN", "file:wallet.db?cache=shared&mode=rwc"),
+
+		MaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		ConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+		HoldSweepInterval: getEnvDuration("HOLD_SWEEP_INTERVAL", time.Minute),
+
+		SchedulerInterval:   getEnvDuration("SCHEDULER_INTERVAL", 30*time.Second),
+		ScheduleMaxAttempts: getEnvInt("SCHEDULE_MAX_ATTEMPTS", 5),
+		ScheduleRetryDelay:  getEnvDuration("SCHEDULE_RETRY_DELAY", 15*time.Minute),
+
+		FXRatesFile: getEnv("FX_RATES_FILE", ""),
+		FXSpreadBps: getEnvInt("FX_SPREAD_BPS", 50),
+		FXQuoteTTL:  getEnvDuration("FX_QUOTE_TTL", 30*time.Second),
+
+		IdempotencyTTL:           getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		IdempotencyPurgeInterval: getEnvDuration("IDEMPOTENCY_PURGE_INTERVAL", time.Hour),
+
+		OutboxInterval:    getEnvDuration("OUTBOX_INTERVAL", time.Second),
+		OutboxMaxAttempts: getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+		OutboxRetryDelay:  getEnvDuration("OUTBOX_RETRY_DELAY", 5*time.Second),
+
+		WebhookInterval:     getEnvDuration("WEBHOOK_INTERVAL", time.Second),
+		WebhookTimeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryDelay:   getEnvDuration("WEBHOOK_RETRY_DELAY", 30*time.Second),
+		WebhookDisableAfter: getEnvInt("WEBHOOK_DISABLE_AFTER", 20),
+
+		FileStoreDir:              getEnv("FILE_STORE_DIR", "data"),
+		MonthlyStatementsInterval: getEnvDuration("MONTHLY_STATEMENTS_INTERVAL", time.Hour),
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlite "github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Modelos tal como eran cuando el esquema lo creaba AutoMigrate: montos en float y sin versión.
+type legacyUser struct {
+	ID           uint      `gorm:"primaryKey"`
+	Name         string    `gorm:"size:30;not null"`
+	Email        string    `gorm:"size:30;not null;uniqueIndex"`
+	Password     string    `gorm:"size:30;not null"`
+	LoginAttempt int       `gorm:"default:0"`
+	Locked_until time.Time `gorm:"default:null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (legacyUser) TableName() string { return "users" }
+
+type legacyAccount struct {
+	ID        uint        `gorm:"primaryKey"`
+	UserID    uint        `gorm:"not null;index"`
+	User      *legacyUser `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Currency  string      `gorm:"size:3;not null"`
+	Balance   float64     `gorm:"not null;default:0"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (legacyAccount) TableName() string { return "accounts" }
+
+type legacyTransaction struct {
+	ID            uint    `gorm:"primaryKey"`
+	Type          string  `gorm:"size:20;not null"`
+	Reference     *string `gorm:"size:100;index:idx_tx_ref,unique,where:reference IS NOT NULL"`
+	FromAccountID *uint
+	ToAccountID   *uint
+	FromAccount   *legacyAccount `gorm:"foreignKey:FromAccountID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ToAccount     *legacyAccount `gorm:"foreignKey:ToAccountID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Amount        float64        `gorm:"type:decimal(10,2);not null"`
+	Currency      string         `gorm:"size:3;not null"`
+	CreatedAt     time.Time
+}
+
+func (legacyTransaction) TableName() string { return "transactions" }
+
+type legacyLedgerEntry struct {
+	ID            uint               `gorm:"primaryKey"`
+	TransactionID uint               `gorm:"not null;index"`
+	Transaction   *legacyTransaction `gorm:"foreignKey:TransactionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	AccountID     uint               `gorm:"not null;index"`
+	Account       *legacyAccount     `gorm:"foreignKey:AccountID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Amount        float64            `gorm:"not null"`
+	CreatedAt     time.Time
+}
+
+func (legacyLedgerEntry) TableName() string { return "ledger_entries" }
+
+type legacyToken struct {
+	ID           uint      `gorm:"primaryKey"`
+	TokenType    string    `gorm:"size:30;not null"`
+	Token        string    `gorm:"size:1000;not null"`
+	Revoked_Date time.Time `gorm:"default:null"`
+	Is_Revoked   bool      `gorm:"default:false"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (legacyToken) TableName() string { return "tokens" }
+
+func openLegacy(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "legacy.db") + "?_pragma=foreign_keys(ON)&_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&legacyUser{}, &legacyAccount{}, &legacyTransaction{}, &legacyLedgerEntry{}, &legacyToken{}); err != nil {
+		t.Fatalf("legacy schema: %v", err)
+	}
+
+	ana := legacyUser{Name: "ana", Email: "ana@x.io", Password: "x"}
+	db.Create(&ana)
+	accounts := []legacyAccount{
+		{UserID: ana.ID, Currency: "USD", Balance: 10.5},
+		{UserID: ana.ID, Currency: "JPY", Balance: 1500},
+		{UserID: ana.ID, Currency: "KWD", Balance: 0.125},
+	}
+	db.Create(&accounts)
+	txs := []legacyTransaction{
+		{Type: "deposit", ToAccountID: &accounts[0].ID, Amount: 10.5, Currency: "USD"},
+		{Type: "deposit", ToAccountID: &accounts[2].ID, Amount: 0.125, Currency: "KWD"},
+	}
+	db.Create(&txs)
+	entries := []legacyLedgerEntry{
+		{TransactionID: txs[0].ID, AccountID: accounts[0].ID, Amount: 10.5},
+		{TransactionID: txs[1].ID, AccountID: accounts[2].ID, Amount: 0.125},
+	}
+	if err := db.Create(&entries).Error; err != nil {
+		t.Fatalf("legacy rows: %v", err)
+	}
+
+	return db
+}
+
+// Una base creada por AutoMigrate queda en unidades menores y una segunda pasada no la toca.
+func TestMigrateAdoptsLegacyFloatAmounts(t *testing.T) {
+	db := openLegacy(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	checkAmounts(t, db)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+	checkAmounts(t, db)
+}
+
+func checkAmounts(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	tests := []struct {
+		query string
+		want  int64
+	}{
+		{"SELECT balance FROM accounts WHERE id = 1", 1050},
+		{"SELECT balance FROM accounts WHERE id = 2", 1500},
+		{"SELECT balance FROM accounts WHERE id = 3", 125},
+		{"SELECT amount FROM transactions WHERE id = 1", 1050},
+		{"SELECT amount FROM transactions WHERE id = 2", 125},
+		{"SELECT amount FROM ledger_entries WHERE id = 1", 1050},
+		{"SELECT amount FROM ledger_entries WHERE id = 2", 125},
+		{"SELECT version FROM accounts WHERE id = 1", 0},
+	}
+
+	for _, tt := range tests {
+		var got int64
+		if err := db.Raw(tt.query).Scan(&got).Error; err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"errors"
+	"strings"
+
+	mysqldrv "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// IsRetryable indica si el error se debe a una carrera con otra transacción y conviene
+// reintentar la operación completa.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// serialization_failure, deadlock_detected, lock_not_available
+		return pgErr.Code == "40001" || pgErr.Code == "40P01" || pgErr.Code == "55P03"
+	}
+
+	var myErr *mysqldrv.MySQLError
+	if errors.As(err, &myErr) {
+		// ER_LOCK_DEADLOCK, ER_LOCK_WAIT_TIMEOUT
+		return myErr.Number == 1213 || myErr.Number == 1205
+	}
+
+	// SQLite serializa escritores: la transacción que pierde recibe "database is locked".
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked") ||
+		strings.Contains(msg, "sqlite_busy")
+}
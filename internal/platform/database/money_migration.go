@@ -0,0 +1,142 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sebaactis/wallet-go-api/internal/entities/account"
+	ledger "github.com/sebaactis/wallet-go-api/internal/entities/legder"
+	"github.com/sebaactis/wallet-go-api/internal/entities/transaction"
+	"github.com/sebaactis/wallet-go-api/internal/money"
+	"gorm.io/gorm"
+)
+
+// Columnas que antes se guardaban como float64/decimal y ahora son unidades menores (int64).
+type legacyAmountColumn struct {
+	model  any
+	table  string
+	column string
+	field  string
+	query  string
+}
+
+var legacyAmountColumns = []legacyAmountColumn{
+	{
+		model: &account.Account{}, table: "accounts", column: "balance", field: "Balance",
+		query: "SELECT id, balance, currency FROM accounts",
+	},
+	{
+		model: &transaction.Transaction{}, table: "transactions", column: "amount", field: "Amount",
+		query: "SELECT id, amount, currency FROM transactions",
+	},
+	{
+		model: &ledger.LedgerEntry{}, table: "ledger_entries", column: "amount", field: "Amount",
+		query: "SELECT le.id, le.amount, a.currency FROM ledger_entries le JOIN accounts a ON a.id = le.account_id",
+	},
+}
+
+// migrateMoneyToMinorUnits convierte los montos legados a unidades menores según el exponente
+// de la moneda de cada fila y cambia el tipo de la columna en la misma transacción, así una
+// segunda ejecución no vuelve a escalar los valores.
+func migrateMoneyToMinorUnits(db *gorm.DB) error {
+	if db.Dialector.Name() != "sqlite" {
+		return convertLegacyAmounts(db)
+	}
+
+	// En SQLite AlterColumn rehace la tabla (crear, copiar, DROP, renombrar) y el DROP de accounts
+	// dispara los ON DELETE CASCADE contra transactions y ledger_entries. El PRAGMA no tiene efecto
+	// dentro de una transacción, así que se apaga antes, en una conexión fija.
+	return db.Connection(func(conn *gorm.DB) error {
+		var fk int
+		if err := conn.Raw("PRAGMA foreign_keys").Scan(&fk).Error; err != nil {
+			return err
+		}
+		if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+			return err
+		}
+		defer conn.Exec(fmt.Sprintf("PRAGMA foreign_keys = %d", fk))
+
+		return convertLegacyAmounts(conn)
+	})
+}
+
+func convertLegacyAmounts(db *gorm.DB) error {
+	for _, c := range legacyAmountColumns {
+		legacy, err := isLegacyAmountColumn(db, c)
+		if err != nil {
+			return err
+		}
+		if !legacy {
+			continue
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return convertAmountColumn(tx, c)
+		})
+		if err != nil {
+			return fmt.Errorf("migrate %s.%s to minor units: %w", c.table, c.column, err)
+		}
+	}
+
+	return nil
+}
+
+func isLegacyAmountColumn(db *gorm.DB, c legacyAmountColumn) (bool, error) {
+	if !db.Migrator().HasTable(c.table) {
+		return false, nil
+	}
+
+	types, err := db.Migrator().ColumnTypes(c.model)
+	if err != nil {
+		return false, err
+	}
+
+	for _, ct := range types {
+		if ct.Name() != c.column {
+			continue
+		}
+		return !strings.Contains(strings.ToLower(ct.DatabaseTypeName()), "int"), nil
+	}
+
+	return false, nil
+}
+
+func convertAmountColumn(tx *gorm.DB, c legacyAmountColumn) error {
+	type row struct {
+		ID       uint
+		Amount   float64
+		Currency string
+	}
+
+	rows, err := tx.Raw(c.query).Rows()
+	if err != nil {
+		return err
+	}
+
+	var pending []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.ID, &r.Amount, &r.Currency); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range pending {
+		m, err := money.FromFloat(r.Amount, r.Currency)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", r.ID, err)
+		}
+
+		if err := tx.Table(c.table).Where("id = ?", r.ID).Update(c.column, m.Amount).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Migrator().AlterColumn(c.model, c.field)
+}
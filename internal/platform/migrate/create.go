@@ -0,0 +1,55 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Dialects son los subdirectorios de migrations/, uno por driver soportado.
+var Dialects = []string{"sqlite", "postgres", "mysql"}
+
+var nameRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create genera el par up/down vacío con el siguiente número de versión en cada dialecto de dir.
+func Create(dir, name string) ([]string, error) {
+	name = strings.Trim(nameRe.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("migration name is required")
+	}
+
+	var next int64 = 1
+	for _, d := range Dialects {
+		migrations, err := Load(os.DirFS(filepath.Join(dir, d)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for _, m := range migrations {
+			if m.Version >= next {
+				next = m.Version + 1
+			}
+		}
+	}
+
+	var created []string
+	for _, d := range Dialects {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			return created, err
+		}
+
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(dir, d, fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+			body := fmt.Sprintf("-- %04d_%s (%s, %s)\n", next, name, d, direction)
+
+			if err := os.WriteFile(file, []byte(body), 0o644); err != nil {
+				return created, err
+			}
+			created = append(created, file)
+		}
+	}
+
+	return created, nil
+}
@@ -0,0 +1,104 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	lockName    = "wallet_schema_migrations"
+	pgLockKey   = 727274001 // arbitrario, fijo para todas las instancias
+	staleLockAt = 15 * time.Minute
+	lockPoll    = 500 * time.Millisecond
+)
+
+var ErrLockTimeout = errors.New("timed out waiting for migration lock")
+
+// locked ejecuta fn con un lock global de migraciones tomado sobre una conexión fija, así solo
+// una instancia migra aunque arranquen varias a la vez.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		unlock, err := acquireLock(ctx, conn, m.lockTimeout)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := ensureTable(conn); err != nil {
+			return err
+		}
+
+		// El conn de Connection acumula las condiciones de una llamada a la siguiente; la sesión
+		// conserva la conexión y empieza cada operación de cero.
+		return fn(conn.Session(&gorm.Session{}))
+	})
+}
+
+func acquireLock(ctx context.Context, conn *gorm.DB, timeout time.Duration) (func(), error) {
+	switch conn.Dialector.Name() {
+	case "postgres":
+		return pollLock(ctx, timeout, func() (bool, error) {
+			var ok bool
+			err := conn.Raw("SELECT pg_try_advisory_lock(?)", pgLockKey).Scan(&ok).Error
+			return ok, err
+		}, func() {
+			conn.Exec("SELECT pg_advisory_unlock(?)", pgLockKey)
+		})
+
+	case "mysql":
+		return pollLock(ctx, timeout, func() (bool, error) {
+			var ok *int
+			err := conn.Raw("SELECT GET_LOCK(?, 0)", lockName).Scan(&ok).Error
+			return ok != nil && *ok == 1, err
+		}, func() {
+			conn.Exec("SELECT RELEASE_LOCK(?)", lockName)
+		})
+
+	default:
+		// SQLite no tiene advisory locks: se usa una fila en una tabla de lock. Si quedó colgada
+		// de un proceso que murió, se considera vencida pasado staleLockAt.
+		if err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+			id INTEGER PRIMARY KEY,
+			locked_at TIMESTAMP NOT NULL
+		)`).Error; err != nil {
+			return nil, err
+		}
+
+		return pollLock(ctx, timeout, func() (bool, error) {
+			now := time.Now().UTC()
+			if err := conn.Exec("DELETE FROM schema_migrations_lock WHERE id = 1 AND locked_at < ?", now.Add(-staleLockAt)).Error; err != nil {
+				return false, err
+			}
+			res := conn.Exec("INSERT INTO schema_migrations_lock (id, locked_at) VALUES (1, ?) ON CONFLICT DO NOTHING", now)
+			return res.RowsAffected == 1, res.Error
+		}, func() {
+			conn.Exec("DELETE FROM schema_migrations_lock WHERE id = 1")
+		})
+	}
+}
+
+func pollLock(ctx context.Context, timeout time.Duration, try func() (bool, error), release func()) (func(), error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		ok, err := try()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return release, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPoll):
+		}
+	}
+}
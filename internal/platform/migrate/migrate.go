@@ -0,0 +1,391 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var embedded embed.FS
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration was modified")
+	ErrMissingDown      = errors.New("migration has no down file")
+	ErrUnknownApplied   = errors.New("applied migration not found in migration files")
+	ErrDirty            = errors.New("migration failed partway; fix the schema by hand and force its state")
+	ErrNotDirty         = errors.New("migration is not dirty")
+)
+
+var fileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+func (m *Migration) String() string { return fmt.Sprintf("%04d_%s", m.Version, m.Name) }
+
+// applied es una fila de schema_migrations. Dirty marca una migración que empezó y no terminó:
+// solo puede pasar donde el DDL no es transaccional (MySQL confirma cada sentencia).
+type applied struct {
+	Version   int64 `gorm:"primaryKey"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+	Dirty     bool
+}
+
+func (applied) TableName() string { return "schema_migrations" }
+
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Modified  bool
+	Missing   bool
+	Dirty     bool
+}
+
+type Migrator struct {
+	db          *gorm.DB
+	fsys        fs.FS
+	lockTimeout time.Duration
+
+	// transactionalDDL indica si un script entero puede ir en una transacción. En MySQL cada DDL
+	// confirma lo anterior: un script que falla a la mitad deja cambios aplicados.
+	transactionalDDL bool
+}
+
+// New usa los archivos embebidos del dialecto de db (migrations/sqlite, migrations/postgres, ...).
+func New(db *gorm.DB) (*Migrator, error) {
+	sub, err := fs.Sub(embedded, path.Join("migrations", db.Dialector.Name()))
+	if err != nil {
+		return nil, err
+	}
+	return NewWithFS(db, sub), nil
+}
+
+func NewWithFS(db *gorm.DB, fsys fs.FS) *Migrator {
+	return &Migrator{
+		db:               db,
+		fsys:             fsys,
+		lockTimeout:      2 * time.Minute,
+		transactionalDDL: db.Dialector.Name() != "mysql",
+	}
+}
+
+func Load(fsys fs.FS) ([]*Migration, error) {
+	files, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, f := range files {
+		match := fileRe.FindStringSubmatch(f.Name())
+		if f.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		body, err := fs.ReadFile(fsys, f.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	out := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %s has no up file", m)
+		}
+		out = append(out, m)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Up aplica en orden todas las migraciones pendientes.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var done []*Migration
+
+	err := m.locked(ctx, func(conn *gorm.DB) error {
+		migrations, state, err := m.load(conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(migrations, state); err != nil {
+			return err
+		}
+
+		for _, mig := range migrations {
+			if _, ok := state[mig.Version]; ok {
+				continue
+			}
+
+			row := &applied{
+				Version:   mig.Version,
+				Name:      mig.Name,
+				Checksum:  mig.Checksum,
+				AppliedAt: time.Now().UTC(),
+			}
+
+			// Sin DDL transaccional la fila se escribe antes, sucia: si el script falla a la mitad,
+			// queda constancia y no se vuelve a correr sobre un esquema a medio cambiar.
+			if !m.transactionalDDL {
+				row.Dirty = true
+				if err := conn.Create(row).Error; err != nil {
+					return fmt.Errorf("migration %s: %w", mig, err)
+				}
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := execScript(tx, mig.Up); err != nil {
+					return err
+				}
+				if row.Dirty {
+					return tx.Model(row).Update("dirty", false).Error
+				}
+				return tx.Create(row).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", mig, err)
+			}
+
+			log.Printf("migrate: applied %s", mig)
+			done = append(done, mig)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down revierte las últimas n migraciones aplicadas.
+func (m *Migrator) Down(ctx context.Context, n int) ([]*Migration, error) {
+	var done []*Migration
+
+	err := m.locked(ctx, func(conn *gorm.DB) error {
+		migrations, state, err := m.load(conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(migrations, state); err != nil {
+			return err
+		}
+
+		byVersion := map[int64]*Migration{}
+		for _, mig := range migrations {
+			byVersion[mig.Version] = mig
+		}
+
+		versions := make([]int64, 0, len(state))
+		for v := range state {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		if n > len(versions) {
+			n = len(versions)
+		}
+
+		for _, v := range versions[:n] {
+			mig := byVersion[v]
+			if mig.Down == "" {
+				return fmt.Errorf("%s: %w", mig, ErrMissingDown)
+			}
+
+			if !m.transactionalDDL {
+				if err := conn.Model(&applied{}).Where("version = ?", v).Update("dirty", true).Error; err != nil {
+					return fmt.Errorf("migration %s: %w", mig, err)
+				}
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := execScript(tx, mig.Down); err != nil {
+					return err
+				}
+				return tx.Delete(&applied{}, v).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", mig, err)
+			}
+
+			log.Printf("migrate: reverted %s", mig)
+			done = append(done, mig)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Force resuelve una migración sucia después de arreglar el esquema a mano: con done en true queda
+// aplicada, con false queda pendiente y el próximo Up la vuelve a correr.
+func (m *Migrator) Force(ctx context.Context, version int64, done bool) error {
+	return m.locked(ctx, func(conn *gorm.DB) error {
+		q := conn.Model(&applied{}).Where("version = ? AND dirty = ?", version, true)
+
+		var result *gorm.DB
+		if done {
+			result = q.Update("dirty", false)
+		} else {
+			result = q.Delete(&applied{})
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%04d: %w", version, ErrNotDirty)
+		}
+
+		log.Printf("migrate: forced %04d as applied=%v", version, done)
+		return nil
+	})
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn := m.db.WithContext(ctx)
+
+	if err := ensureTable(conn); err != nil {
+		return nil, err
+	}
+
+	migrations, state, err := m.load(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Status
+	for _, mig := range migrations {
+		st := Status{Version: mig.Version, Name: mig.Name}
+		if a, ok := state[mig.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = &a.AppliedAt
+			st.Modified = a.Checksum != mig.Checksum
+			st.Dirty = a.Dirty
+			delete(state, mig.Version)
+		}
+		out = append(out, st)
+	}
+
+	for _, a := range state {
+		at := a.AppliedAt
+		out = append(out, Status{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: &at, Missing: true, Dirty: a.Dirty})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+func (m *Migrator) load(conn *gorm.DB) ([]*Migration, map[int64]applied, error) {
+	migrations, err := Load(m.fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []applied
+	if err := conn.Order("version").Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	state := make(map[int64]applied, len(rows))
+	for _, r := range rows {
+		state[r.Version] = r
+	}
+
+	return migrations, state, nil
+}
+
+// verify corta si una migración ya aplicada se editó o desapareció: el esquema real dejaría de
+// coincidir con lo que dicen los archivos.
+func verify(migrations []*Migration, state map[int64]applied) error {
+	known := map[int64]bool{}
+
+	for v, a := range state {
+		if a.Dirty {
+			return fmt.Errorf("%04d_%s: %w", v, a.Name, ErrDirty)
+		}
+	}
+
+	for _, mig := range migrations {
+		known[mig.Version] = true
+		if a, ok := state[mig.Version]; ok && a.Checksum != mig.Checksum {
+			return fmt.Errorf("%s: %w", mig, ErrChecksumMismatch)
+		}
+	}
+
+	for v, a := range state {
+		if !known[v] {
+			return fmt.Errorf("%04d_%s: %w", v, a.Name, ErrUnknownApplied)
+		}
+	}
+
+	return nil
+}
+
+func ensureTable(conn *gorm.DB) error {
+	err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL,
+		dirty BOOLEAN NOT NULL DEFAULT FALSE
+	)`).Error
+	if err != nil {
+		return err
+	}
+
+	// Las bases migradas antes de que existiera dirty no la tienen.
+	if conn.Migrator().HasColumn(&applied{}, "dirty") {
+		return nil
+	}
+	return conn.Exec("ALTER TABLE schema_migrations ADD COLUMN dirty BOOLEAN NOT NULL DEFAULT FALSE").Error
+}
+
+func execScript(tx *gorm.DB, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	sqlite "github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "migrate.db") + "?_pragma=foreign_keys(ON)&_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	return db
+}
+
+// schema devuelve las sentencias de creación de tablas e índices, para comparar esquemas.
+func schema(t *testing.T, db *gorm.DB) map[string]string {
+	t.Helper()
+
+	var rows []struct {
+		Name string
+		SQL  *string
+	}
+	if err := db.Raw("SELECT name, sql FROM sqlite_master WHERE name NOT LIKE 'sqlite_%' AND name <> 'schema_migrations_lock'").Scan(&rows).Error; err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+
+	out := make(map[string]string, len(rows))
+	for _, r := range rows {
+		if r.SQL != nil {
+			out[r.Name] = *r.SQL
+		}
+	}
+	return out
+}
+
+// Cada migración embebida se aplica y una segunda pasada no hace nada.
+func TestUpTwiceIsNoop(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	m, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := Load(m.fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("first Up: %v", err)
+	}
+	if len(done) != len(all) {
+		t.Fatalf("first Up applied %d migrations, want %d", len(done), len(all))
+	}
+	before := schema(t, db)
+
+	done, err = m.Up(ctx)
+	if err != nil {
+		t.Fatalf("second Up: %v", err)
+	}
+	if len(done) != 0 {
+		t.Fatalf("second Up applied %v, want none", done)
+	}
+	if after := schema(t, db); !reflect.DeepEqual(before, after) {
+		t.Fatal("second Up changed the schema")
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range status {
+		if !st.Applied || st.Modified || st.Missing {
+			t.Errorf("status %04d_%s = %+v, want applied and unmodified", st.Version, st.Name, st)
+		}
+	}
+}
+
+// Bajar todo y volver a subir deja el mismo esquema: cada down deshace su up.
+func TestDownThenUpRestoresSchema(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	m, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := schema(t, db)
+
+	reverted, err := m.Down(ctx, len(applied))
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if len(reverted) != len(applied) {
+		t.Fatalf("Down reverted %d migrations, want %d", len(reverted), len(applied))
+	}
+	if left := schema(t, db); len(left) != 1 || left["schema_migrations"] == "" {
+		t.Fatalf("after Down the schema still has %v", keys(left))
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up after Down: %v", err)
+	}
+	if got := schema(t, db); !reflect.DeepEqual(want, got) {
+		t.Fatal("Up after Down produced a different schema")
+	}
+}
+
+func TestEditedMigrationIsRejected(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	fsys := fstest.MapFS{
+		"0001_things.up.sql":   {Data: []byte("CREATE TABLE things (id INTEGER PRIMARY KEY);")},
+		"0001_things.down.sql": {Data: []byte("DROP TABLE things;")},
+	}
+	if _, err := NewWithFS(db, fsys).Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys["0001_things.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE things (id INTEGER PRIMARY KEY, name TEXT);")}
+	if _, err := NewWithFS(db, fsys).Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Up with edited migration error = %v, want ErrChecksumMismatch", err)
+	}
+
+	delete(fsys, "0001_things.up.sql")
+	delete(fsys, "0001_things.down.sql")
+	if _, err := NewWithFS(db, fsys).Up(ctx); !errors.Is(err, ErrUnknownApplied) {
+		t.Fatalf("Up with missing migration error = %v, want ErrUnknownApplied", err)
+	}
+}
+
+// Una migración que falla a mitad de camino no queda registrada ni deja cambios.
+func TestFailedMigrationRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	fsys := fstest.MapFS{
+		"0001_ok.up.sql":     {Data: []byte("CREATE TABLE ok (id INTEGER PRIMARY KEY);")},
+		"0002_broken.up.sql": {Data: []byte("CREATE TABLE half (id INTEGER PRIMARY KEY); INSERT INTO nope VALUES (1);")},
+	}
+	if _, err := NewWithFS(db, fsys).Up(ctx); err == nil {
+		t.Fatal("Up with a broken migration succeeded")
+	}
+
+	if !db.Migrator().HasTable("ok") {
+		t.Error("the migration before the broken one was not kept")
+	}
+	if db.Migrator().HasTable("half") {
+		t.Error("the broken migration left a table behind")
+	}
+
+	var n int64
+	db.Table("schema_migrations").Count(&n)
+	if n != 1 {
+		t.Errorf("schema_migrations has %d rows, want 1", n)
+	}
+}
+
+// Sin DDL transaccional (MySQL) una migración que falla puede haber dejado cambios: queda sucia y
+// nada corre hasta que se resuelva con Force.
+func TestFailedMigrationWithoutTransactionalDDLIsDirty(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	migrator := func(fsys fstest.MapFS) *Migrator {
+		m := NewWithFS(db, fsys)
+		m.transactionalDDL = false
+		return m
+	}
+
+	fsys := fstest.MapFS{
+		"0001_ok.up.sql":       {Data: []byte("CREATE TABLE ok (id INTEGER PRIMARY KEY);")},
+		"0001_ok.down.sql":     {Data: []byte("DROP TABLE ok;")},
+		"0002_broken.up.sql":   {Data: []byte("CREATE TABLE half (id INTEGER PRIMARY KEY); INSERT INTO nope VALUES (1);")},
+		"0002_broken.down.sql": {Data: []byte("DROP TABLE half;")},
+	}
+	if _, err := migrator(fsys).Up(ctx); err == nil {
+		t.Fatal("Up with a broken migration succeeded")
+	}
+
+	statuses, err := migrator(fsys).Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0].Dirty || !statuses[1].Dirty {
+		t.Fatalf("statuses = %+v, want only 0002 dirty", statuses)
+	}
+
+	if _, err := migrator(fsys).Up(ctx); !errors.Is(err, ErrDirty) {
+		t.Errorf("Up over a dirty migration error = %v, want ErrDirty", err)
+	}
+	if _, err := migrator(fsys).Down(ctx, 1); !errors.Is(err, ErrDirty) {
+		t.Errorf("Down over a dirty migration error = %v, want ErrDirty", err)
+	}
+	if err := migrator(fsys).Force(ctx, 1, true); !errors.Is(err, ErrNotDirty) {
+		t.Errorf("Force on a clean migration error = %v, want ErrNotDirty", err)
+	}
+
+	// Arreglado a mano, se deja pendiente y se corrige el archivo.
+	if err := migrator(fsys).Force(ctx, 2, false); err != nil {
+		t.Fatal(err)
+	}
+	fsys["0002_broken.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE half (id INTEGER PRIMARY KEY);")}
+	if done, err := migrator(fsys).Up(ctx); err != nil || len(done) != 1 {
+		t.Fatalf("Up after force = %v, %v; want 0002 applied", done, err)
+	}
+
+	// Con Force en true queda aplicada tal cual.
+	fsys["0003_more.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE more (id INTEGER PRIMARY KEY); INSERT INTO nope VALUES (1);")}
+	if _, err := migrator(fsys).Up(ctx); err == nil {
+		t.Fatal("Up with a broken migration succeeded")
+	}
+	if err := migrator(fsys).Force(ctx, 3, true); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := migrator(fsys).Up(ctx); err != nil || len(done) != 0 {
+		t.Errorf("Up after forcing 0003 applied = %v, %v; want nothing to do", done, err)
+	}
+}
+
+// Una base migrada antes de la columna dirty la recibe sin perder lo aplicado.
+func TestSchemaMigrationsGainsDirtyColumn(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	fsys := fstest.MapFS{"0001_ok.up.sql": {Data: []byte("CREATE TABLE ok (id INTEGER PRIMARY KEY);")}}
+	migs, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Exec(`CREATE TABLE schema_migrations (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL,
+		checksum VARCHAR(64) NOT NULL, applied_at TIMESTAMP NOT NULL)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE ok (id INTEGER PRIMARY KEY)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO schema_migrations VALUES (1, 'ok', ?, ?)", migs[0].Checksum, time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if done, err := NewWithFS(db, fsys).Up(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("Up = %v, %v; want nothing to do", done, err)
+	}
+	if !db.Migrator().HasColumn(&applied{}, "dirty") {
+		t.Error("schema_migrations has no dirty column")
+	}
+}
+
+func TestLockIsExclusive(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	first := NewWithFS(db, fstest.MapFS{})
+	second := NewWithFS(db, fstest.MapFS{})
+	second.lockTimeout = 10 * time.Millisecond
+
+	err := first.locked(ctx, func(*gorm.DB) error {
+		return second.locked(ctx, func(*gorm.DB) error {
+			t.Error("second migrator took a held lock")
+			return nil
+		})
+	})
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("nested lock error = %v, want ErrLockTimeout", err)
+	}
+
+	// Liberado, se puede volver a tomar.
+	if err := second.locked(ctx, func(*gorm.DB) error { return nil }); err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+}
+
+func TestStaleLockIsTakenOver(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	m := NewWithFS(db, fstest.MapFS{})
+	m.lockTimeout = 10 * time.Millisecond
+
+	if err := m.locked(ctx, func(*gorm.DB) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	// Un proceso que murió con el lock tomado.
+	if err := db.Exec("INSERT INTO schema_migrations_lock (id, locked_at) VALUES (1, ?)", time.Now().UTC().Add(-2*staleLockAt)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.locked(ctx, func(*gorm.DB) error { return nil }); err != nil {
+		t.Fatalf("stale lock was not taken over: %v", err)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"simple", "CREATE TABLE a (id INT); CREATE TABLE b (id INT);",
+			[]string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"}},
+		{"no trailing semicolon", "SELECT 1", []string{"SELECT 1"}},
+		{"empty statements", ";; ;\n", nil},
+		{"semicolon in string", "INSERT INTO t VALUES ('a;b'); SELECT 1;",
+			[]string{"INSERT INTO t VALUES ('a;b')", "SELECT 1"}},
+		{"escaped quote", "INSERT INTO t VALUES ('it''s; fine');",
+			[]string{"INSERT INTO t VALUES ('it''s; fine')"}},
+		{"quoted identifiers", "SELECT \"a;b\", `c;d` FROM t;",
+			[]string{"SELECT \"a;b\", `c;d` FROM t"}},
+		{"line comment", "-- drop; everything\nSELECT 1; -- trailing; comment\n",
+			[]string{"-- drop; everything\nSELECT 1"}},
+		{"only comments", "-- nothing here;\n-- at all\n", nil},
+		{"block comment", "/* a; b */ SELECT 1; SELECT 2;",
+			[]string{"/* a; b */ SELECT 1", "SELECT 2"}},
+		{"dollar quoted", "CREATE FUNCTION f() RETURNS void AS $$ BEGIN PERFORM 1; END; $$ LANGUAGE plpgsql; SELECT 1;",
+			[]string{"CREATE FUNCTION f() RETURNS void AS $$ BEGIN PERFORM 1; END; $$ LANGUAGE plpgsql", "SELECT 1"}},
+		{"tagged dollar quote", "DO $body$ BEGIN RAISE NOTICE 'x;y'; END $body$; SELECT 2;",
+			[]string{"DO $body$ BEGIN RAISE NOTICE 'x;y'; END $body$", "SELECT 2"}},
+		{"positional parameter", "SELECT $1; SELECT 2;", []string{"SELECT $1", "SELECT 2"}},
+		{"unterminated string", "SELECT 'abc; SELECT 2", []string{"SELECT 'abc; SELECT 2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q)\n got  %q\n want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func keys(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
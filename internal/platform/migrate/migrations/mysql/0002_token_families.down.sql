@@ -0,0 +1 @@
+ALTER TABLE tokens DROP INDEX idx_tokens_family_id, DROP COLUMN family_id;
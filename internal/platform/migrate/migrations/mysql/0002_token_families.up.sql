@@ -0,0 +1 @@
+ALTER TABLE tokens ADD COLUMN family_id VARCHAR(64) NULL, ADD INDEX idx_tokens_family_id (family_id);
@@ -0,0 +1 @@
+ALTER TABLE tokens DROP INDEX idx_tokens_jti, DROP COLUMN expires_at, DROP COLUMN jti;
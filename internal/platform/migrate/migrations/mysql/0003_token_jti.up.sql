@@ -0,0 +1,4 @@
+ALTER TABLE tokens
+    ADD COLUMN jti VARCHAR(64) NULL,
+    ADD COLUMN expires_at DATETIME(3) NULL DEFAULT NULL,
+    ADD UNIQUE INDEX idx_tokens_jti (jti);
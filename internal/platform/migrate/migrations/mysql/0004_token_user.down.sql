@@ -0,0 +1 @@
+ALTER TABLE tokens DROP FOREIGN KEY fk_tokens_user, DROP INDEX idx_tokens_user_id, DROP COLUMN user_id;
@@ -0,0 +1,4 @@
+ALTER TABLE tokens
+    ADD COLUMN user_id BIGINT UNSIGNED NULL,
+    ADD INDEX idx_tokens_user_id (user_id),
+    ADD CONSTRAINT fk_tokens_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
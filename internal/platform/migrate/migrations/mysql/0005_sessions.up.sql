@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    family_id VARCHAR(64) NOT NULL,
+    user_agent VARCHAR(255) NULL,
+    ip VARCHAR(64) NULL,
+    last_seen_at DATETIME(3) NULL,
+    expires_at DATETIME(3) NULL,
+    revoked_at DATETIME(3) NULL DEFAULT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_sessions_family_id (family_id),
+    INDEX idx_sessions_user_id (user_id),
+    CONSTRAINT fk_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
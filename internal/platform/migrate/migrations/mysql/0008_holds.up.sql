@@ -0,0 +1,24 @@
+ALTER TABLE accounts ADD COLUMN held BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS holds (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    from_account_id BIGINT UNSIGNED NOT NULL,
+    to_account_id BIGINT UNSIGNED NOT NULL,
+    amount BIGINT NOT NULL,
+    captured_amount BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reference VARCHAR(100) NULL,
+    transaction_id BIGINT UNSIGNED NULL,
+    expires_at DATETIME(3) NOT NULL,
+    closed_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    INDEX idx_holds_from_account_id (from_account_id),
+    INDEX idx_holds_status (status),
+    INDEX idx_holds_expires_at (expires_at),
+    UNIQUE INDEX idx_holds_reference (reference),
+    CONSTRAINT fk_holds_from_account FOREIGN KEY (from_account_id) REFERENCES accounts (id) ON DELETE CASCADE,
+    CONSTRAINT fk_holds_to_account FOREIGN KEY (to_account_id) REFERENCES accounts (id) ON DELETE CASCADE,
+    CONSTRAINT fk_holds_transaction FOREIGN KEY (transaction_id) REFERENCES transactions (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
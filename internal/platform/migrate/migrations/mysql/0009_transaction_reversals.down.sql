@@ -0,0 +1 @@
+ALTER TABLE transactions DROP FOREIGN KEY fk_transactions_reverses, DROP INDEX idx_transactions_reverses_id, DROP COLUMN reversed_amount, DROP COLUMN reverses_id;
@@ -0,0 +1,5 @@
+ALTER TABLE transactions
+    ADD COLUMN reverses_id BIGINT UNSIGNED NULL,
+    ADD COLUMN reversed_amount BIGINT NOT NULL DEFAULT 0,
+    ADD INDEX idx_transactions_reverses_id (reverses_id),
+    ADD CONSTRAINT fk_transactions_reverses FOREIGN KEY (reverses_id) REFERENCES transactions (id);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS schedules (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    from_account_id BIGINT UNSIGNED NOT NULL,
+    to_account_id BIGINT UNSIGNED NOT NULL,
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    cron VARCHAR(100) NULL,
+    interval_seconds BIGINT NOT NULL DEFAULT 0,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    start_at DATETIME(3) NOT NULL,
+    end_at DATETIME(3) NULL,
+    next_run_at DATETIME(3) NULL,
+    retry_at DATETIME(3) NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    runs INT NOT NULL DEFAULT 0,
+    last_run_at DATETIME(3) NULL,
+    last_transaction_id BIGINT UNSIGNED NULL,
+    last_error VARCHAR(255) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    INDEX idx_schedules_user_id (user_id),
+    INDEX idx_schedules_next_run_at (next_run_at),
+    INDEX idx_schedules_status (status),
+    CONSTRAINT fk_schedules_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_schedules_from_account FOREIGN KEY (from_account_id) REFERENCES accounts (id) ON DELETE CASCADE,
+    CONSTRAINT fk_schedules_to_account FOREIGN KEY (to_account_id) REFERENCES accounts (id) ON DELETE CASCADE,
+    CONSTRAINT fk_schedules_last_transaction FOREIGN KEY (last_transaction_id) REFERENCES transactions (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
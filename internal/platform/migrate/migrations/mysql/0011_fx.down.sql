@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS fx_quotes;
+DROP TABLE IF EXISTS fx_rates;
+ALTER TABLE transactions DROP COLUMN fx_quote_id;
+ALTER TABLE transactions DROP COLUMN fx_rate;
+ALTER TABLE transactions DROP COLUMN to_currency;
+ALTER TABLE transactions DROP COLUMN to_amount;
@@ -0,0 +1,32 @@
+ALTER TABLE transactions ADD COLUMN to_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN to_currency VARCHAR(3) NULL;
+ALTER TABLE transactions ADD COLUMN fx_rate VARCHAR(32) NULL;
+ALTER TABLE transactions ADD COLUMN fx_quote_id BIGINT UNSIGNED NULL;
+
+CREATE TABLE IF NOT EXISTS fx_rates (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    base VARCHAR(3) NOT NULL,
+    quote VARCHAR(3) NOT NULL,
+    rate VARCHAR(32) NOT NULL,
+    updated_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_fx_rates_pair (base, quote)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    amount BIGINT NOT NULL,
+    converted_amount BIGINT NOT NULL,
+    mid_rate VARCHAR(32) NOT NULL,
+    rate VARCHAR(32) NOT NULL,
+    spread_bps INT NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    used_at DATETIME(3) NULL,
+    transaction_id BIGINT UNSIGNED NULL,
+    created_at DATETIME(3) NULL,
+    INDEX idx_fx_quotes_user_id (user_id),
+    CONSTRAINT fk_fx_quotes_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_fx_quotes_transaction FOREIGN KEY (transaction_id) REFERENCES transactions (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
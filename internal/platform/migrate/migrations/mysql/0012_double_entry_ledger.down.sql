@@ -0,0 +1,7 @@
+DELETE FROM ledger_entries WHERE account_id IN (SELECT id FROM accounts WHERE system_code IS NOT NULL);
+DELETE FROM accounts WHERE system_code IS NOT NULL;
+DELETE FROM users WHERE role = 'system';
+ALTER TABLE ledger_entries DROP COLUMN currency;
+ALTER TABLE accounts
+    DROP INDEX idx_accounts_system,
+    DROP COLUMN system_code;
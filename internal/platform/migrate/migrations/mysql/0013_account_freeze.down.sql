@@ -0,0 +1,3 @@
+ALTER TABLE accounts
+    DROP COLUMN frozen_reason,
+    DROP COLUMN frozen_at;
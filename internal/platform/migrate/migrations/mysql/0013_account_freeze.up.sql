@@ -0,0 +1,3 @@
+ALTER TABLE accounts
+    ADD COLUMN frozen_at DATETIME(3) NULL,
+    ADD COLUMN frozen_reason VARCHAR(255) NULL;
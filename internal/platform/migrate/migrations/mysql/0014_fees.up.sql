@@ -0,0 +1,38 @@
+ALTER TABLE transactions ADD COLUMN fee BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tx_type VARCHAR(20) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    flat_amount BIGINT NOT NULL DEFAULT 0,
+    percentage_bps BIGINT NOT NULL DEFAULT 0,
+    min_fee BIGINT NOT NULL DEFAULT 0,
+    max_fee BIGINT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_fee_schedules_type_currency (tx_type, currency)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS fee_tiers (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    schedule_id BIGINT UNSIGNED NOT NULL,
+    up_to BIGINT NULL,
+    flat_amount BIGINT NOT NULL DEFAULT 0,
+    percentage_bps BIGINT NOT NULL DEFAULT 0,
+    INDEX idx_fee_tiers_schedule_id (schedule_id),
+    CONSTRAINT fk_fee_tiers_schedule FOREIGN KEY (schedule_id) REFERENCES fee_schedules (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS transaction_fees (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    transaction_id BIGINT UNSIGNED NOT NULL,
+    component VARCHAR(20) NOT NULL,
+    description VARCHAR(100) NULL,
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    created_at DATETIME(3) NULL,
+    INDEX idx_transaction_fees_transaction_id (transaction_id),
+    CONSTRAINT fk_transaction_fees_transaction FOREIGN KEY (transaction_id) REFERENCES transactions (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
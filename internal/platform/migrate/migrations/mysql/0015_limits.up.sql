@@ -0,0 +1,25 @@
+ALTER TABLE users ADD COLUMN kyc_tier INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_limits (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    scope VARCHAR(10) NOT NULL,
+    scope_id BIGINT UNSIGNED NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    value BIGINT NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_transaction_limits_key (scope, scope_id, currency, kind)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS limit_usage (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    scope VARCHAR(10) NOT NULL,
+    scope_id BIGINT UNSIGNED NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    period VARCHAR(10) NOT NULL,
+    volume BIGINT NOT NULL DEFAULT 0,
+    tx_count BIGINT NOT NULL DEFAULT 0,
+    updated_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_limit_usage_key (scope, scope_id, currency, period)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(50) NOT NULL,
+    aggregate_type VARCHAR(30) NOT NULL,
+    aggregate_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(3) NOT NULL,
+    locked_until DATETIME(3) NULL,
+    last_error VARCHAR(500) NULL,
+    delivered_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    INDEX idx_outbox_status (status, id),
+    INDEX idx_outbox_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    events VARCHAR(500) NOT NULL DEFAULT '',
+    secret VARCHAR(100) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    disabled_at DATETIME(3) NULL,
+    disabled_reason VARCHAR(255) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    INDEX idx_webhook_endpoints_user_id (user_id),
+    CONSTRAINT fk_webhook_endpoints_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    endpoint_id BIGINT UNSIGNED NOT NULL,
+    event_id BIGINT UNSIGNED NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(3) NOT NULL,
+    locked_until DATETIME(3) NULL,
+    response_code INT NOT NULL DEFAULT 0,
+    last_error VARCHAR(500) NULL,
+    delivered_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_webhook_deliveries_event (endpoint_id, event_id),
+    INDEX idx_webhook_deliveries_due (status, next_attempt_at),
+    CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    delivery_id BIGINT UNSIGNED NOT NULL,
+    response_code INT NOT NULL DEFAULT 0,
+    response_body VARCHAR(500) NULL,
+    error VARCHAR(500) NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME(3) NULL,
+    INDEX idx_webhook_attempts_delivery_id (delivery_id),
+    CONSTRAINT fk_webhook_attempts_delivery FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
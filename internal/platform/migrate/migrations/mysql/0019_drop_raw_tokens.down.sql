@@ -0,0 +1 @@
+ALTER TABLE tokens ADD COLUMN token VARCHAR(1000) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE recovery_codes
+    DROP INDEX idx_recovery_codes_user_code,
+    ADD UNIQUE INDEX idx_recovery_codes_code_hash (code_hash);
@@ -0,0 +1,4 @@
+-- El hash de un código no es único entre usuarios: solo tiene que serlo dentro de los de cada uno.
+ALTER TABLE recovery_codes
+    DROP INDEX idx_recovery_codes_code_hash,
+    ADD UNIQUE INDEX idx_recovery_codes_user_code (user_id, code_hash);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tokens_jti;
+ALTER TABLE tokens DROP COLUMN expires_at;
+ALTER TABLE tokens DROP COLUMN jti;
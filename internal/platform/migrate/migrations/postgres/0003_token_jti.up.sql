@@ -0,0 +1,3 @@
+ALTER TABLE tokens ADD COLUMN jti VARCHAR(64);
+ALTER TABLE tokens ADD COLUMN expires_at TIMESTAMPTZ DEFAULT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tokens_jti ON tokens (jti);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tokens_user_id;
+ALTER TABLE tokens DROP COLUMN user_id;
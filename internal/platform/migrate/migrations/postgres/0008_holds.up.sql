@@ -0,0 +1,21 @@
+ALTER TABLE accounts ADD COLUMN held BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS holds (
+    id BIGSERIAL PRIMARY KEY,
+    from_account_id BIGINT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    to_account_id BIGINT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL,
+    captured_amount BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reference VARCHAR(100),
+    transaction_id BIGINT REFERENCES transactions (id),
+    expires_at TIMESTAMPTZ NOT NULL,
+    closed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_holds_from_account_id ON holds (from_account_id);
+CREATE INDEX IF NOT EXISTS idx_holds_status ON holds (status);
+CREATE INDEX IF NOT EXISTS idx_holds_expires_at ON holds (expires_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holds_reference ON holds (reference);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_reverses_id;
+ALTER TABLE transactions DROP COLUMN reversed_amount;
+ALTER TABLE transactions DROP COLUMN reverses_id;
@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN reverses_id BIGINT REFERENCES transactions (id);
+ALTER TABLE transactions ADD COLUMN reversed_amount BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_transactions_reverses_id ON transactions (reverses_id);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS schedules (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    from_account_id BIGINT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    to_account_id BIGINT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    cron VARCHAR(100),
+    interval_seconds BIGINT NOT NULL DEFAULT 0,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    start_at TIMESTAMPTZ NOT NULL,
+    end_at TIMESTAMPTZ,
+    next_run_at TIMESTAMPTZ,
+    retry_at TIMESTAMPTZ,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    runs INTEGER NOT NULL DEFAULT 0,
+    last_run_at TIMESTAMPTZ,
+    last_transaction_id BIGINT REFERENCES transactions (id),
+    last_error VARCHAR(255),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_schedules_user_id ON schedules (user_id);
+CREATE INDEX IF NOT EXISTS idx_schedules_next_run_at ON schedules (next_run_at);
+CREATE INDEX IF NOT EXISTS idx_schedules_status ON schedules (status);
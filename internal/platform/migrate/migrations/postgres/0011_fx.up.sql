@@ -0,0 +1,30 @@
+ALTER TABLE transactions ADD COLUMN to_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN to_currency VARCHAR(3);
+ALTER TABLE transactions ADD COLUMN fx_rate VARCHAR(32);
+ALTER TABLE transactions ADD COLUMN fx_quote_id BIGINT;
+
+CREATE TABLE IF NOT EXISTS fx_rates (
+    id BIGSERIAL PRIMARY KEY,
+    base VARCHAR(3) NOT NULL,
+    quote VARCHAR(3) NOT NULL,
+    rate VARCHAR(32) NOT NULL,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fx_rates_pair ON fx_rates (base, quote);
+
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    amount BIGINT NOT NULL,
+    converted_amount BIGINT NOT NULL,
+    mid_rate VARCHAR(32) NOT NULL,
+    rate VARCHAR(32) NOT NULL,
+    spread_bps INTEGER NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    transaction_id BIGINT REFERENCES transactions (id),
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_fx_quotes_user_id ON fx_quotes (user_id);
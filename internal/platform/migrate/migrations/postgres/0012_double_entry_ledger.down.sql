@@ -0,0 +1,6 @@
+DELETE FROM ledger_entries WHERE account_id IN (SELECT id FROM accounts WHERE system_code IS NOT NULL);
+DELETE FROM accounts WHERE system_code IS NOT NULL;
+DELETE FROM users WHERE role = 'system';
+ALTER TABLE ledger_entries DROP COLUMN currency;
+DROP INDEX IF EXISTS idx_accounts_system;
+ALTER TABLE accounts DROP COLUMN system_code;
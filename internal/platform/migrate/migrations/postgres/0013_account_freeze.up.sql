@@ -0,0 +1,2 @@
+ALTER TABLE accounts ADD COLUMN frozen_at TIMESTAMPTZ;
+ALTER TABLE accounts ADD COLUMN frozen_reason VARCHAR(255);
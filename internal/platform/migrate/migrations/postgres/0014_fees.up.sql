@@ -0,0 +1,36 @@
+ALTER TABLE transactions ADD COLUMN fee BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    id BIGSERIAL PRIMARY KEY,
+    tx_type VARCHAR(20) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    flat_amount BIGINT NOT NULL DEFAULT 0,
+    percentage_bps BIGINT NOT NULL DEFAULT 0,
+    min_fee BIGINT NOT NULL DEFAULT 0,
+    max_fee BIGINT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fee_schedules_type_currency ON fee_schedules (tx_type, currency);
+
+CREATE TABLE IF NOT EXISTS fee_tiers (
+    id BIGSERIAL PRIMARY KEY,
+    schedule_id BIGINT NOT NULL REFERENCES fee_schedules (id) ON DELETE CASCADE,
+    up_to BIGINT,
+    flat_amount BIGINT NOT NULL DEFAULT 0,
+    percentage_bps BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_fee_tiers_schedule_id ON fee_tiers (schedule_id);
+
+CREATE TABLE IF NOT EXISTS transaction_fees (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES transactions (id),
+    component VARCHAR(20) NOT NULL,
+    description VARCHAR(100),
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_transaction_fees_transaction_id ON transaction_fees (transaction_id);
@@ -0,0 +1,25 @@
+ALTER TABLE users ADD COLUMN kyc_tier INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_limits (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(10) NOT NULL,
+    scope_id BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    value BIGINT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_limits_key ON transaction_limits (scope, scope_id, currency, kind);
+
+CREATE TABLE IF NOT EXISTS limit_usage (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(10) NOT NULL,
+    scope_id BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    period VARCHAR(10) NOT NULL,
+    volume BIGINT NOT NULL DEFAULT 0,
+    tx_count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_limit_usage_key ON limit_usage (scope, scope_id, currency, period);
@@ -0,0 +1,3 @@
+-- El JWT completo no se guarda: el jti lo identifica y la firma prueba el resto. Los tokens que
+-- había quedan válidos mientras su jti siga en la tabla.
+ALTER TABLE tokens DROP COLUMN token;
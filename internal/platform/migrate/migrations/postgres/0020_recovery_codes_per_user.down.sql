@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_recovery_codes_user_code;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recovery_codes_code_hash ON recovery_codes (code_hash);
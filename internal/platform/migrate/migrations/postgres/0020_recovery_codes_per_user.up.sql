@@ -0,0 +1,3 @@
+-- El hash de un código no es único entre usuarios: solo tiene que serlo dentro de los de cada uno.
+DROP INDEX IF EXISTS idx_recovery_codes_code_hash;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recovery_codes_user_code ON recovery_codes (user_id, code_hash);
@@ -0,0 +1,2 @@
+ALTER TABLE tokens ADD COLUMN family_id TEXT;
+CREATE INDEX IF NOT EXISTS idx_tokens_family_id ON tokens (family_id);
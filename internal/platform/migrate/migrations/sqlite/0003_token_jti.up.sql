@@ -0,0 +1,3 @@
+ALTER TABLE tokens ADD COLUMN jti TEXT;
+ALTER TABLE tokens ADD COLUMN expires_at DATETIME DEFAULT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tokens_jti ON tokens (jti);
@@ -0,0 +1,2 @@
+ALTER TABLE tokens ADD COLUMN user_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_tokens_user_id ON tokens (user_id);
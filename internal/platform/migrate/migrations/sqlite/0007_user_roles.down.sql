@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_role;
+ALTER TABLE users DROP COLUMN role;
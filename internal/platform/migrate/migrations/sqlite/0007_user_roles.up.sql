@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+CREATE INDEX IF NOT EXISTS idx_users_role ON users (role);
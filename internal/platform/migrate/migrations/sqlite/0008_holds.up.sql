@@ -0,0 +1,21 @@
+ALTER TABLE accounts ADD COLUMN held INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS holds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_account_id INTEGER NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    to_account_id INTEGER NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL,
+    captured_amount INTEGER NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL,
+    status TEXT NOT NULL,
+    reference TEXT,
+    transaction_id INTEGER REFERENCES transactions (id),
+    expires_at DATETIME NOT NULL,
+    closed_at DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_holds_from_account_id ON holds (from_account_id);
+CREATE INDEX IF NOT EXISTS idx_holds_status ON holds (status);
+CREATE INDEX IF NOT EXISTS idx_holds_expires_at ON holds (expires_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holds_reference ON holds (reference);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS schedules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    from_account_id INTEGER NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    to_account_id INTEGER NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    cron TEXT,
+    interval_seconds INTEGER NOT NULL DEFAULT 0,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    start_at DATETIME NOT NULL,
+    end_at DATETIME,
+    next_run_at DATETIME,
+    retry_at DATETIME,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL,
+    runs INTEGER NOT NULL DEFAULT 0,
+    last_run_at DATETIME,
+    last_transaction_id INTEGER REFERENCES transactions (id),
+    last_error TEXT,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_schedules_user_id ON schedules (user_id);
+CREATE INDEX IF NOT EXISTS idx_schedules_next_run_at ON schedules (next_run_at);
+CREATE INDEX IF NOT EXISTS idx_schedules_status ON schedules (status);
@@ -0,0 +1,30 @@
+ALTER TABLE transactions ADD COLUMN to_amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN to_currency TEXT;
+ALTER TABLE transactions ADD COLUMN fx_rate TEXT;
+ALTER TABLE transactions ADD COLUMN fx_quote_id INTEGER;
+
+CREATE TABLE IF NOT EXISTS fx_rates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    base TEXT NOT NULL,
+    quote TEXT NOT NULL,
+    rate TEXT NOT NULL,
+    updated_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fx_rates_pair ON fx_rates (base, quote);
+
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    from_currency TEXT NOT NULL,
+    to_currency TEXT NOT NULL,
+    amount INTEGER NOT NULL,
+    converted_amount INTEGER NOT NULL,
+    mid_rate TEXT NOT NULL,
+    rate TEXT NOT NULL,
+    spread_bps INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    transaction_id INTEGER REFERENCES transactions (id),
+    created_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_fx_quotes_user_id ON fx_quotes (user_id);
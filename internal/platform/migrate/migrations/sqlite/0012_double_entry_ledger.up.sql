@@ -0,0 +1,31 @@
+ALTER TABLE accounts ADD COLUMN system_code TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_system ON accounts (system_code, currency);
+
+ALTER TABLE ledger_entries ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+UPDATE ledger_entries SET currency = (SELECT a.currency FROM accounts a WHERE a.id = ledger_entries.account_id);
+
+-- Dueño de las cuentas internas. Su password no es un hash bcrypt, así que no puede iniciar sesión.
+INSERT INTO users (name, email, password, role, created_at, updated_at)
+SELECT 'system', 'system@wallet.internal', '!', 'system', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+WHERE NOT EXISTS (SELECT 1 FROM users WHERE role = 'system');
+
+-- Depósitos, retiros y transferencias FX anteriores quedaron con una sola pata por moneda: lo que
+-- no netea se manda a la cuenta suspense de esa moneda para que todo el ledger balancee.
+INSERT INTO accounts (user_id, currency, balance, held, version, system_code, created_at, updated_at)
+SELECT u.id, c.currency, 0, 0, 0, 'suspense', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM users u,
+    (SELECT DISTINCT x.currency FROM (
+        SELECT transaction_id, currency FROM ledger_entries GROUP BY transaction_id, currency HAVING SUM(amount) <> 0
+    ) x) c
+WHERE u.role = 'system';
+
+INSERT INTO ledger_entries (transaction_id, account_id, amount, currency, created_at)
+SELECT le.transaction_id, a.id, -SUM(le.amount), le.currency, MAX(le.created_at)
+FROM ledger_entries le
+JOIN accounts a ON a.system_code = 'suspense' AND a.currency = le.currency
+GROUP BY le.transaction_id, le.currency, a.id
+HAVING SUM(le.amount) <> 0;
+
+UPDATE accounts
+SET balance = (SELECT COALESCE(SUM(le.amount), 0) FROM ledger_entries le WHERE le.account_id = accounts.id)
+WHERE system_code IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE accounts ADD COLUMN frozen_at DATETIME;
+ALTER TABLE accounts ADD COLUMN frozen_reason TEXT;
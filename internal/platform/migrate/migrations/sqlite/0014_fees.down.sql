@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS transaction_fees;
+DROP TABLE IF EXISTS fee_tiers;
+DROP TABLE IF EXISTS fee_schedules;
+ALTER TABLE transactions DROP COLUMN fee;
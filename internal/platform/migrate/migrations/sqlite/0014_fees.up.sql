@@ -0,0 +1,36 @@
+ALTER TABLE transactions ADD COLUMN fee INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tx_type TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    flat_amount INTEGER NOT NULL DEFAULT 0,
+    percentage_bps INTEGER NOT NULL DEFAULT 0,
+    min_fee INTEGER NOT NULL DEFAULT 0,
+    max_fee INTEGER NOT NULL DEFAULT 0,
+    active NUMERIC NOT NULL DEFAULT 1,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fee_schedules_type_currency ON fee_schedules (tx_type, currency);
+
+CREATE TABLE IF NOT EXISTS fee_tiers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL REFERENCES fee_schedules (id) ON DELETE CASCADE,
+    up_to INTEGER,
+    flat_amount INTEGER NOT NULL DEFAULT 0,
+    percentage_bps INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_fee_tiers_schedule_id ON fee_tiers (schedule_id);
+
+CREATE TABLE IF NOT EXISTS transaction_fees (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER NOT NULL REFERENCES transactions (id),
+    component TEXT NOT NULL,
+    description TEXT,
+    amount INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    created_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_transaction_fees_transaction_id ON transaction_fees (transaction_id);
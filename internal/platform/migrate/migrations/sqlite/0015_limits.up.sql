@@ -0,0 +1,25 @@
+ALTER TABLE users ADD COLUMN kyc_tier INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_limits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scope TEXT NOT NULL,
+    scope_id INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    value INTEGER NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_limits_key ON transaction_limits (scope, scope_id, currency, kind);
+
+CREATE TABLE IF NOT EXISTS limit_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scope TEXT NOT NULL,
+    scope_id INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    period TEXT NOT NULL,
+    volume INTEGER NOT NULL DEFAULT 0,
+    tx_count INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_limit_usage_key ON limit_usage (scope, scope_id, currency, period);
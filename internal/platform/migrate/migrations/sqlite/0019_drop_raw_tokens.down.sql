@@ -0,0 +1 @@
+ALTER TABLE tokens ADD COLUMN token TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,115 @@
+package migrate
+
+import "strings"
+
+// splitStatements separa un script SQL en sentencias por ";" ignorando los que aparecen dentro de
+// strings, identificadores citados, comentarios y bloques $tag$ de Postgres.
+func splitStatements(script string) []string {
+	var (
+		out   []string
+		buf   strings.Builder
+		runes = []rune(script)
+	)
+
+	flush := func() {
+		if stmt := strings.TrimSpace(buf.String()); stmt != "" && !onlyComments(stmt) {
+			out = append(out, stmt)
+		}
+		buf.Reset()
+	}
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		switch {
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			end := indexFrom(runes, i, "\n")
+			buf.WriteString(string(runes[i:end]))
+			i = end - 1
+
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := indexFrom(runes, i+2, "*/") + 2
+			if end > len(runes) {
+				end = len(runes)
+			}
+			buf.WriteString(string(runes[i:end]))
+			i = end - 1
+
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(runes) {
+				if runes[end] == c {
+					// comilla escapada duplicándola: 'it''s'
+					if end+1 < len(runes) && runes[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end < len(runes) {
+				end++
+			}
+			buf.WriteString(string(runes[i:end]))
+			i = end - 1
+
+		case c == '$':
+			tag, ok := dollarTag(runes, i)
+			if !ok {
+				buf.WriteRune(c)
+				continue
+			}
+			end := indexFrom(runes, i+len(tag), tag) + len(tag)
+			if end > len(runes) {
+				end = len(runes)
+			}
+			buf.WriteString(string(runes[i:end]))
+			i = end - 1
+
+		case c == ';':
+			flush()
+
+		default:
+			buf.WriteRune(c)
+		}
+	}
+
+	flush()
+	return out
+}
+
+// indexFrom devuelve la posición de sub a partir de from, o len(runes) si no aparece.
+func indexFrom(runes []rune, from int, sub string) int {
+	if from > len(runes) {
+		return len(runes)
+	}
+	idx := strings.Index(string(runes[from:]), sub)
+	if idx < 0 {
+		return len(runes)
+	}
+	return from + len([]rune(string(runes[from:])[:idx]))
+}
+
+func dollarTag(runes []rune, i int) (string, bool) {
+	for j := i + 1; j < len(runes); j++ {
+		c := runes[j]
+		if c == '$' {
+			return string(runes[i : j+1]), true
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || j > i+1 && c >= '0' && c <= '9') {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func onlyComments(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package pdf
+
+import "strings"
+
+// Font es una de las fuentes estándar que el documento declara.
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+type fontMetrics struct {
+	base string
+	// Ancho de los caracteres 32 a 126 en milésimas del tamaño, de los AFM de Adobe.
+	widths [95]int
+	// Ancho que se usa para lo que no es ASCII (acentos, símbolos).
+	fallback int
+}
+
+var fonts = []fontMetrics{
+	Helvetica: {
+		base: "Helvetica",
+		widths: [95]int{
+			278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // ' ' a '/'
+			556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // '0' a '?'
+			1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // '@' a 'O'
+			667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // 'P' a '_'
+			333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // '`' a 'o'
+			556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // 'p' a '~'
+		},
+		fallback: 556,
+	},
+	HelveticaBold: {
+		base: "Helvetica-Bold",
+		widths: [95]int{
+			278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+			556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+			975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+			667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+			333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+			611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+		},
+		fallback: 611,
+	},
+}
+
+func (f Font) resource() string {
+	return "F" + string(rune('1'+f))
+}
+
+// TextWidth es el ancho en puntos de s escrito con f en el tamaño dado.
+func TextWidth(f Font, size float64, s string) float64 {
+	m := fonts[f]
+
+	total := 0
+	for _, c := range encode(s) {
+		if c >= 32 && c <= 126 {
+			total += m.widths[c-32]
+		} else {
+			total += m.fallback
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Truncate recorta s para que entre en width, terminando en "…" si hizo falta cortar.
+func Truncate(f Font, size float64, s string, width float64) string {
+	if TextWidth(f, size, s) <= width {
+		return s
+	}
+
+	r := []rune(s)
+	for len(r) > 0 {
+		r = r[:len(r)-1]
+		t := strings.TrimRight(string(r), " ") + "…"
+		if TextWidth(f, size, t) <= width {
+			return t
+		}
+	}
+	return ""
+}
+
+// winAnsi son los caracteres de WinAnsiEncoding entre 0x80 y 0x9F que no coinciden con Latin-1.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, '‰': 0x89, '‹': 0x8B,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99, '›': 0x9B,
+}
+
+// encode pasa s a WinAnsiEncoding, la codificación con la que se declaran las fuentes. Lo que
+// no tiene representación sale como '?'.
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 32 && r <= 126, r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		case winAnsi[r] != 0:
+			out = append(out, winAnsi[r])
+		case r == '\t', r == '\n', r == '\r':
+			out = append(out, ' ')
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
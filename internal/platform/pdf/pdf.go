@@ -0,0 +1,227 @@
+// Package pdf arma documentos PDF 1.4 simples sin dependencias: páginas con texto en Helvetica y
+// Helvetica-Bold (dos de las 14 fuentes estándar, que todo lector trae y no hace falta embeber),
+// líneas y rectángulos. Alcanza para reportes tabulares; no hay imágenes ni ajuste de texto.
+//
+// Las coordenadas van en puntos (1/72 de pulgada) desde la esquina superior izquierda de la
+// página, al revés que en PDF: y crece hacia abajo.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tamaño A4 en puntos.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Color RGB con componentes entre 0 y 1.
+type Color struct{ R, G, B float64 }
+
+// RGB arma un Color a partir de componentes de 0 a 255.
+func RGB(r, g, b uint8) Color {
+	return Color{float64(r) / 255, float64(g) / 255, float64(b) / 255}
+}
+
+var (
+	Black = Color{0, 0, 0}
+	White = Color{1, 1, 1}
+)
+
+// Document es un PDF en construcción. Las páginas quedan en memoria hasta WriteTo, así que se
+// pueden seguir dibujando después de agregar otras (por ejemplo, el "página x de n").
+type Document struct {
+	width, height float64
+	pages         []*Page
+
+	Title   string
+	Author  string
+	Created time.Time
+}
+
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height, Created: time.Now()}
+}
+
+func (d *Document) Width() float64  { return d.width }
+func (d *Document) Height() float64 { return d.height }
+
+func (d *Document) Pages() []*Page { return d.pages }
+
+// AddPage agrega una página en blanco al final.
+func (d *Document) AddPage() *Page {
+	p := &Page{height: d.height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Page acumula el content stream de una página.
+type Page struct {
+	height float64
+	buf    bytes.Buffer
+}
+
+// Text escribe s con la línea base en (x, y).
+func (p *Page) Text(x, y float64, f Font, size float64, c Color, s string) {
+	fmt.Fprintf(&p.buf, "BT %s rg /%s %s Tf %s %s Td (%s) Tj ET\n",
+		c.ops(), f.resource(), num(size), num(x), num(p.height-y), escape(encode(s)))
+}
+
+// TextRight escribe s terminando en x.
+func (p *Page) TextRight(x, y float64, f Font, size float64, c Color, s string) {
+	p.Text(x-TextWidth(f, size, s), y, f, size, c, s)
+}
+
+// Rect rellena el rectángulo con esquina superior izquierda en (x, y).
+func (p *Page) Rect(x, y, w, h float64, c Color) {
+	fmt.Fprintf(&p.buf, "%s rg %s %s %s %s re f\n",
+		c.ops(), num(x), num(p.height-y-h), num(w), num(h))
+}
+
+// Line traza una línea de (x1, y1) a (x2, y2).
+func (p *Page) Line(x1, y1, x2, y2, width float64, c Color) {
+	fmt.Fprintf(&p.buf, "%s RG %s w %s %s m %s %s l S\n",
+		c.ops(), num(width), num(x1), num(p.height-y1), num(x2), num(p.height-y2))
+}
+
+// WriteTo escribe el documento completo. Los content streams van comprimidos con Flate.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pw := &writer{w: bufio.NewWriter(w)}
+
+	// Objetos fijos: 1 catálogo, 2 árbol de páginas, 3 info y las fuentes desde el 4. Cada
+	// página ocupa dos más: la página y su contenido.
+	const catalogID, pagesID, infoID, firstFontID = 1, 2, 3, 4
+	firstPageID := firstFontID + len(fonts)
+	pageID := func(i int) int { return firstPageID + 2*i }
+
+	pw.raw("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	pw.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageID(i))
+	}
+	pw.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	pw.object(infoID, fmt.Sprintf("<< /Title (%s) /Author (%s) /Producer (wallet-go-api) /CreationDate (%s) >>",
+		escape(encode(d.Title)), escape(encode(d.Author)), pdfDate(d.Created)))
+
+	fontRefs := make([]string, len(fonts))
+	for i, f := range fonts {
+		id := firstFontID + i
+		pw.object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.base))
+		fontRefs[i] = fmt.Sprintf("/%s %d 0 R", Font(i).resource(), id)
+	}
+	resources := fmt.Sprintf("<< /Font << %s >> >>", strings.Join(fontRefs, " "))
+
+	for i, p := range d.pages {
+		id := pageID(i)
+		pw.object(id, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			pagesID, num(d.width), num(d.height), resources, id+1))
+
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(p.buf.Bytes())
+		zw.Close()
+		pw.stream(id+1, z.Bytes())
+	}
+
+	// Tabla de referencias: una entrada de 20 bytes por objeto con su offset.
+	xref := pw.n
+	count := firstPageID + 2*len(d.pages)
+	pw.raw(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", count))
+	for id := 1; id < count; id++ {
+		pw.raw(fmt.Sprintf("%010d 00000 n \n", pw.offsets[id]))
+	}
+	pw.raw(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		count, catalogID, infoID, xref))
+
+	if pw.err == nil {
+		pw.err = pw.w.Flush()
+	}
+	return pw.n, pw.err
+}
+
+// writer lleva la cuenta de bytes para los offsets de la tabla de referencias.
+type writer struct {
+	w       *bufio.Writer
+	n       int64
+	offsets map[int]int64
+	err     error
+}
+
+func (pw *writer) raw(s string) {
+	pw.bytes([]byte(s))
+}
+
+func (pw *writer) bytes(b []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(b)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+func (pw *writer) object(id int, body string) {
+	pw.begin(id)
+	pw.raw(body)
+	pw.raw("\nendobj\n")
+}
+
+func (pw *writer) stream(id int, data []byte) {
+	pw.begin(id)
+	pw.raw(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n", len(data)))
+	pw.bytes(data)
+	pw.raw("\nendstream\nendobj\n")
+}
+
+func (pw *writer) begin(id int) {
+	if pw.offsets == nil {
+		pw.offsets = map[int]int64{}
+	}
+	pw.offsets[id] = pw.n
+	pw.raw(fmt.Sprintf("%d 0 obj\n", id))
+}
+
+func (c Color) ops() string {
+	return num(c.R) + " " + num(c.G) + " " + num(c.B)
+}
+
+// num formatea un número con hasta dos decimales, sin ceros de más.
+func num(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// escape protege los caracteres especiales de un string literal de PDF.
+func escape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch c {
+		case '(', ')', '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func pdfDate(t time.Time) string {
+	return "D:" + t.UTC().Format("20060102150405") + "Z"
+}
@@ -22,6 +22,12 @@ func NewValidator() *Validator {
 		return ok
 	})
 
+	// Montos decimales como string ("10.50"); la precisión por moneda la valida money.Parse.
+	_ = v.RegisterValidation("amount", func(fl validator.FieldLevel) bool {
+		ok, _ := regexp.MatchString(`^\d+(\.\d+)?$`, fl.Field().String())
+		return ok && strings.Trim(fl.Field().String(), "0.") != ""
+	})
+
 	return &Validator{v: v}
 }
 
@@ -43,6 +49,8 @@ func (val *Validator) ValidateStruct(dst any) (map[string]string, bool) {
 				fieldErrs[field] = "max length " + fe.Param()
 			case "iso4217":
 				fieldErrs[field] = "must be 3-letter ISO code"
+			case "amount":
+				fieldErrs[field] = "must be a positive decimal string"
 			case "nefield":
 				fieldErrs[field] = "must be different from " + fe.Param()
 			case "eqfield":